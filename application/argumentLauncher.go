@@ -0,0 +1,71 @@
+// Package application: This file generates the argument-forwarding launcher for compiled
+// binaries (argument_launcher). The binary is copied as <executable>-bin and a small shell
+// script becomes the bundle executable (CFBundleExecutable). The script replaces itself with
+// the binary (exec), passing all arguments on, so the binary runs as the application process:
+// it receives the arguments of "open --args" and the document-open events LaunchServices sends
+// for the document types of the bundle. Wrapper options can be added to the script later
+// without rebuilding the binary.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// launchedBinarySuffix is appended to the bundle executable name for the wrapped binary
+const launchedBinarySuffix = "-bin"
+
+// launchedBinaryName returns the name of the binary started by the launcher.
+func launchedBinaryName() string {
+	return GetBundleExecutable() + launchedBinarySuffix
+}
+
+// launchedBinaryComponents returns the binary started by the launcher of a bundle as a component
+// that is signed before the bundle, or nothing if the bundle has no launcher. Signing the bundle
+// does not sign it: the launcher script is the main executable, and --deep is not used once
+// other nested components (e.g. frameworks) are signed individually.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+func launchedBinaryComponents(appPath string) []nestedComponent {
+	binaryPath := filepath.Join(appPath, "Contents", "MacOS", mainExecutableName(appPath)+launchedBinarySuffix)
+	if info, err := os.Lstat(binaryPath); err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	return []nestedComponent{{path: binaryPath, depth: 1}}
+}
+
+// renderArgumentLauncher returns the launcher script starting the given binary of
+// Contents/MacOS/ with all arguments of the launcher.
+func renderArgumentLauncher(binaryName string) string {
+	return fmt.Sprintf("#!/bin/bash\n\nDIR=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\nexec \"$DIR\"/%s \"$@\"\n", quoteArgument(binaryName))
+}
+
+// warnArgumentLauncherIgnored logs a warning if argument_launcher is set for an executable
+// that is not a compiled binary: JARs and scripts already are or get their own launcher.
+func warnArgumentLauncherIgnored(sourcePath string) {
+	if GetArgumentLauncher() {
+		logger.Warn("argument_launcher only applies to compiled binaries, ignored for %s", sourcePath)
+	}
+}
+
+// writeArgumentLauncher writes the launcher script as the bundle executable.
+//
+// Parameters:
+//   - binaryName: Name of the wrapped binary in Contents/MacOS/
+//
+// Returns an error if the script cannot be written.
+func writeArgumentLauncher(binaryName string) error {
+	launcherPath := filepath.Join(macosDir, GetBundleExecutable())
+
+	err := tracedWriteFile(launcherPath, []byte(renderArgumentLauncher(binaryName)), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the launcher %s: %v", launcherPath, err)
+	}
+
+	// The default is 0755 (rwxr-xr-x), executable_mode overrides it
+	return tracedChmod(launcherPath, GetExecutableMode())
+}
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestArgumentLauncherForwardsArguments runs the launcher script in front of a stand-in binary
+// that prints each of its arguments on a line.
+func TestArgumentLauncherForwardsArguments(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+
+	directory := t.TempDir()
+	binaryName := "My App" + launchedBinarySuffix
+	err := os.WriteFile(filepath.Join(directory, binaryName), []byte("#!/bin/sh\nfor argument in \"$@\"; do printf '%s\\n' \"$argument\"; done\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	launcherPath := filepath.Join(directory, "My App")
+	if err := os.WriteFile(launcherPath, []byte(renderArgumentLauncher(binaryName)), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	arguments := []string{"/Users/john/My Documents/report.txt", "-psn_0_12345", "it's", "$HOME", "*"}
+	output, err := exec.Command(launcherPath, arguments...).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n"); !reflect.DeepEqual(got, arguments) {
+		t.Errorf("binary received %q, want %q", got, arguments)
+	}
+}
+
+// TestSignLaunchedBinary signs a bundle with an argument launcher: the wrapped binary must be
+// signed before the bundle, also when other nested components prevent --deep.
+func TestSignLaunchedBinary(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"launcher only", []string{"Contents/MacOS/MyApp", "Contents/MacOS/MyApp-bin"}},
+		{"with framework", []string{"Contents/MacOS/MyApp", "Contents/MacOS/MyApp-bin", "Contents/Frameworks/Helper.framework/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callsFile := setTestCodesign(t)
+			appPath := writeTestApp(t, test.files...)
+
+			if err := SignApplication(appPath); err != nil {
+				t.Fatal(err)
+			}
+
+			binaryPath := filepath.Join(appPath, "Contents", "MacOS", "MyApp-bin")
+			binaryIndex, bundleIndex := -1, -1
+			calls := readTestCalls(t, callsFile)
+			for index, call := range calls {
+				switch {
+				case strings.HasPrefix(call, "--sign") && strings.HasSuffix(call, " "+binaryPath):
+					binaryIndex = index
+				case strings.HasPrefix(call, "--sign") && strings.HasSuffix(call, " "+appPath):
+					bundleIndex = index
+				}
+			}
+			if binaryIndex == -1 || bundleIndex == -1 || binaryIndex > bundleIndex {
+				t.Errorf("wrapped binary not signed before the bundle:\n%s", strings.Join(calls, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package application: This file generates date-based build numbers (CalVer).
+// With "version_scheme: calver", CFBundleVersion is set to the UTC build time in the form
+// YYYYMMDD.HHMMSS (e.g. 20250115.093012). Both parts are zero-padded to a fixed width, so later
+// builds always get a higher version, compared numerically (as macOS does) or as text. The
+// seconds keep two builds in quick succession (e.g. a rebuild after a failed notarization)
+// from getting the same build number.
+// CFBundleShortVersionString keeps the value from the configuration.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"fmt"
+	"time"
+)
+
+// Supported values of version_scheme
+const versionSchemeCalver = "calver" // CFBundleVersion from the build time (YYYYMMDD.HHMMSS)
+
+// calverFormat is the time layout of a CalVer build number
+const calverFormat = "20060102.150405"
+
+// versionClock returns the build time used for CalVer build numbers
+var versionClock = time.Now
+
+// calendarVersion returns the CalVer build number for a point in time, in UTC so that the
+// sequence does not jump back when the clocks change or builds run in other time zones.
+func calendarVersion(buildTime time.Time) string {
+	return buildTime.UTC().Format(calverFormat)
+}
+
+// applyVersionScheme sets the build version (CFBundleVersion) according to version_scheme.
+// Only builds within the same second get the same build number.
+//
+// Returns an error if the scheme is unknown.
+func applyVersionScheme() error {
+	switch packageInfo.VersionScheme {
+	case "":
+		return nil
+	case versionSchemeCalver:
+		if packageInfo.BundleVersion != "" {
+			logger.Debug("version %q is replaced by the CalVer build number", packageInfo.BundleVersion)
+		}
+		packageInfo.BundleVersion = calendarVersion(versionClock())
+		return nil
+	}
+
+	return fmt.Errorf("invalid version_scheme %q: supported is %q", packageInfo.VersionScheme, versionSchemeCalver)
+}
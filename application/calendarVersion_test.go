@@ -0,0 +1,93 @@
+package application
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCalendarVersion(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		newYork = time.FixedZone("EST", -5*60*60)
+	}
+
+	tests := []struct {
+		name      string
+		buildTime time.Time
+		want      string
+	}{
+		{"padded", time.Date(2025, 1, 5, 9, 3, 7, 0, time.UTC), "20250105.090307"},
+		{"end of day", time.Date(2025, 12, 31, 23, 59, 59, 999, time.UTC), "20251231.235959"},
+		{"other time zone", time.Date(2025, 1, 15, 21, 30, 0, 0, newYork), "20250116.023000"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := calendarVersion(test.buildTime); got != test.want {
+				t.Errorf("calendarVersion() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCalendarVersionIncreases(t *testing.T) {
+	buildTime := time.Date(2025, 1, 15, 9, 30, 58, 0, time.UTC)
+	previous := calendarVersion(buildTime)
+
+	// Builds one second apart, across a minute, an hour and a day boundary
+	for _, step := range []time.Duration{time.Second, time.Second, time.Minute, time.Hour, 24 * time.Hour} {
+		buildTime = buildTime.Add(step)
+		next := calendarVersion(buildTime)
+
+		previousNumber, _ := strconv.ParseFloat(previous, 64)
+		nextNumber, _ := strconv.ParseFloat(next, 64)
+		if next <= previous || nextNumber <= previousNumber {
+			t.Errorf("build number %q after %q does not increase", next, previous)
+		}
+		previous = next
+	}
+}
+
+func TestApplyVersionScheme(t *testing.T) {
+	previousClock := versionClock
+	versionClock = func() time.Time { return time.Date(2025, 1, 15, 9, 30, 12, 0, time.UTC) }
+	t.Cleanup(func() { versionClock = previousClock })
+
+	tests := []struct {
+		name    string
+		scheme  string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{name: "no scheme", version: "7", want: "7"},
+		{name: "calver", scheme: versionSchemeCalver, version: "7", want: "20250115.093012"},
+		{name: "unknown scheme", scheme: "semver", version: "7", wantErr: true},
+	}
+
+	format := regexp.MustCompile(`^\d{8}\.\d{6}$`)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{BundleVersion: test.version, VersionScheme: test.scheme, CFBundleShortVersionString: "1.0"})
+
+			err := applyVersionScheme()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got := GetBundleVersion(); got != test.want {
+				t.Errorf("version = %q, want %q", got, test.want)
+			}
+			if test.scheme == versionSchemeCalver && !format.MatchString(GetBundleVersion()) {
+				t.Errorf("version %q does not match YYYYMMDD.HHMMSS", GetBundleVersion())
+			}
+			if got := GetCFBundleShortVersionString(); got != "1.0" {
+				t.Errorf("short version = %q, want it unchanged", got)
+			}
+		})
+	}
+}
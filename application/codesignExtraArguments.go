@@ -0,0 +1,92 @@
+// Package application: This file handles additional codesign arguments given on the
+// command line (-codesign-extra). codesign has many options that are not modeled by the
+// configuration; they are passed through verbatim after the managed arguments. The value
+// is split like a shell would split it, but no shell is involved.
+package application
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// codesignExtraArguments are appended to every codesign signing call (set via SetCodesignExtraArguments)
+var codesignExtraArguments []string
+
+// SetCodesignExtraArguments sets the additional arguments for codesign from a single string,
+// e.g. `--entitlements "My App.entitlements" --generate-entitlement-der`.
+// The arguments are not checked; conflicting or invalid options break signing.
+//
+// Parameters:
+//   - arguments: Arguments separated by whitespace, with shell-like quoting
+//
+// Returns an error if the quoting is invalid.
+func SetCodesignExtraArguments(arguments string) error {
+	split, err := splitArguments(arguments)
+	if err != nil {
+		return fmt.Errorf("invalid codesign arguments %q: %v", arguments, err)
+	}
+
+	codesignExtraArguments = split
+	return nil
+}
+
+// splitArguments splits a string into arguments at unquoted whitespace. Single quotes keep
+// their content literally, double quotes allow \" and \\ escapes, and a backslash outside
+// quotes escapes the next character. Variables and other shell expansions are not supported.
+func splitArguments(value string) ([]string, error) {
+	var arguments []string
+	var current strings.Builder
+	inArgument := false
+	quote := rune(0)
+	escaped := false
+
+	for _, character := range value {
+		switch {
+		case escaped:
+			current.WriteRune(character)
+			escaped = false
+		case quote == '\'':
+			if character == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(character)
+			}
+		case quote == '"':
+			if character == '"' {
+				quote = 0
+			} else if character == '\\' {
+				escaped = true
+			} else {
+				current.WriteRune(character)
+			}
+		case character == '\'' || character == '"':
+			quote = character
+			inArgument = true
+		case character == '\\':
+			escaped = true
+			inArgument = true
+		case unicode.IsSpace(character):
+			if inArgument {
+				arguments = append(arguments, current.String())
+				current.Reset()
+				inArgument = false
+			}
+		default:
+			current.WriteRune(character)
+			inArgument = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inArgument {
+		arguments = append(arguments, current.String())
+	}
+
+	return arguments, nil
+}
@@ -0,0 +1,60 @@
+package application
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "", want: nil},
+		{value: "  --verbose   --strict ", want: []string{"--verbose", "--strict"}},
+		{value: `--entitlements "My App.entitlements"`, want: []string{"--entitlements", "My App.entitlements"}},
+		{value: `--prefix 'com.example.$NAME'`, want: []string{"--prefix", "com.example.$NAME"}},
+		{value: `"say \"hi\"" My\ App ''`, want: []string{`say "hi"`, "My App", ""}},
+		{value: `--entitlements "My App`, wantErr: "unterminated \" quote"},
+		{value: `--verbose\`, wantErr: "trailing backslash"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := splitArguments(test.value)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("splitArguments(%q) = %q, %v, want %q", test.value, got, err, test.want)
+			}
+		})
+	}
+}
+
+// TestCodesignExtraArguments checks that -codesign-extra arguments follow the managed
+// arguments and precede the signed path.
+func TestCodesignExtraArguments(t *testing.T) {
+	previousArguments := codesignExtraArguments
+	t.Cleanup(func() { codesignExtraArguments = previousArguments })
+	setTestTimestampServer(t)
+	timestampURL, timestampDisable = "", false
+
+	if err := SetCodesignExtraArguments(`--generate-entitlement-der --prefix "com.example."`); err != nil {
+		t.Fatal(err)
+	}
+
+	got := codesignArguments("-", "MyApp.app", codesignOptions{requirements: "=designated => anchor apple"})
+	want := []string{
+		"--sign", "-", "--force", "--options", "runtime", "--timestamp", "--requirements", "=designated => anchor apple",
+		"--generate-entitlement-der", "--prefix", "com.example.", "MyApp.app",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("codesignArguments() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,283 @@
+// Package application: This file compares two existing application bundles.
+// When a build regression appears, the differences between a good and a bad bundle
+// (Info.plist keys and values, added/removed/changed files) usually point at the cause.
+// The comparison is read-only and does not need a configuration file.
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Kinds of differences reported by CompareBundles
+const (
+	DifferenceAdded   = "added"   // Only present in the second bundle
+	DifferenceRemoved = "removed" // Only present in the first bundle
+	DifferenceChanged = "changed" // Present in both bundles with different values
+)
+
+// BundleDifference is a single difference between two bundles.
+//   - Kind: DifferenceAdded, DifferenceRemoved or DifferenceChanged
+//   - Plist: true for an Info.plist key, false for a file
+//   - Name: Info.plist key, or file path relative to the bundle
+//   - Old: Value in the first bundle (empty if added)
+//   - New: Value in the second bundle (empty if removed)
+type BundleDifference struct {
+	Kind  string
+	Plist bool
+	Name  string
+	Old   string
+	New   string
+}
+
+// String formats the difference as a single line, e.g.
+// "~ plist CFBundleVersion: 1 -> 2" or "+ file Contents/Resources/help.txt".
+func (difference BundleDifference) String() string {
+	area := "file"
+	if difference.Plist {
+		area = "plist"
+	}
+
+	switch difference.Kind {
+	case DifferenceAdded:
+		return fmt.Sprintf("+ %s %s: %s", area, difference.Name, difference.New)
+	case DifferenceRemoved:
+		return fmt.Sprintf("- %s %s: %s", area, difference.Name, difference.Old)
+	default:
+		return fmt.Sprintf("~ %s %s: %s -> %s", area, difference.Name, difference.Old, difference.New)
+	}
+}
+
+// hashFile returns the hex encoded SHA-256 digest of a file.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// manifestWorkers is the number of files hashed concurrently by bundleManifest.
+var manifestWorkers = runtime.NumCPU()
+
+// bundleManifest lists every entry of a bundle with a value describing its content:
+// the SHA-256 digest for files, "-> <target>" for symbolic links and "directory" for
+// directories. The keys are slash-separated paths relative to the bundle.
+// Files are hashed concurrently by manifestWorkers workers; the result does not depend
+// on the number of workers.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the manifest or an error if the bundle cannot be read.
+func bundleManifest(appPath string) (map[string]string, error) {
+	manifest := make(map[string]string)
+
+	// Walk the bundle first, collecting the files to hash
+	files := make(map[string]string) // manifest key -> file path
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == appPath {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relativePath)
+
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			manifest[name] = "-> " + target
+		case entry.IsDir():
+			manifest[name] = "directory"
+		default:
+			files[name] = path
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digests, err := hashFiles(files, manifestWorkers)
+	if err != nil {
+		return nil, err
+	}
+	for name, digest := range digests {
+		manifest[name] = digest
+	}
+
+	return manifest, nil
+}
+
+// hashFiles computes the SHA-256 digests of the given files with at most workers files
+// hashed at the same time.
+//
+// Parameters:
+//   - files: Map of names to file paths
+//   - workers: Maximum number of concurrently hashed files (values below 1 are treated as 1)
+//
+// Returns the digests by name, or the error of the first file (in name order) that failed.
+func hashFiles(files map[string]string, workers int) (map[string]string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Process the files in name order, so the reported error is deterministic
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	digests := make([]string, len(names))
+	errs := make([]error, len(names))
+
+	indexes := make(chan int)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				digests[index], errs[index] = hashFile(files[names[index]])
+			}
+		}()
+	}
+
+	for index := range names {
+		indexes <- index
+	}
+	close(indexes)
+	waitGroup.Wait()
+
+	result := make(map[string]string, len(names))
+	for index, name := range names {
+		if errs[index] != nil {
+			return nil, errs[index]
+		}
+		result[name] = digests[index]
+	}
+
+	return result, nil
+}
+
+// compareValues returns the differences between two maps of the same area, sorted by name.
+func compareValues(old map[string]interface{}, new map[string]interface{}, plist bool) []BundleDifference {
+	names := make(map[string]bool)
+	for name := range old {
+		names[name] = true
+	}
+	for name := range new {
+		names[name] = true
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	var differences []BundleDifference
+	for _, name := range sortedNames {
+		oldValue, inOld := old[name]
+		newValue, inNew := new[name]
+
+		difference := BundleDifference{Plist: plist, Name: name}
+		switch {
+		case !inOld:
+			difference.Kind = DifferenceAdded
+			difference.New = fmt.Sprint(newValue)
+		case !inNew:
+			difference.Kind = DifferenceRemoved
+			difference.Old = fmt.Sprint(oldValue)
+		case !reflect.DeepEqual(oldValue, newValue):
+			difference.Kind = DifferenceChanged
+			difference.Old = fmt.Sprint(oldValue)
+			difference.New = fmt.Sprint(newValue)
+		default:
+			continue
+		}
+		differences = append(differences, difference)
+	}
+
+	return differences
+}
+
+// CompareBundles compares the Info.plist keys and the file trees of two bundles.
+// Info.plist differences are listed first, followed by file differences.
+//
+// Parameters:
+//   - oldApp: Path to the first (reference) bundle
+//   - newApp: Path to the second bundle
+//
+// Returns the differences (empty if the bundles are identical), or an error if a bundle
+// or its Info.plist cannot be read.
+func CompareBundles(oldApp string, newApp string) ([]BundleDifference, error) {
+	for _, appPath := range []string{oldApp, newApp} {
+		info, err := os.Stat(appPath)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not an application bundle (.app directory)", appPath)
+		}
+	}
+
+	oldPlist, err := readPlistDictionary(oldApp)
+	if err != nil {
+		return nil, err
+	}
+	newPlist, err := readPlistDictionary(newApp)
+	if err != nil {
+		return nil, err
+	}
+
+	oldManifest, err := bundleManifest(oldApp)
+	if err != nil {
+		return nil, err
+	}
+	newManifest, err := bundleManifest(newApp)
+	if err != nil {
+		return nil, err
+	}
+
+	differences := compareValues(oldPlist, newPlist, true)
+	differences = append(differences, compareValues(manifestValues(oldManifest), manifestValues(newManifest), false)...)
+
+	return differences, nil
+}
+
+// manifestValues converts a manifest to the generic map used by compareValues.
+func manifestValues(manifest map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(manifest))
+	for name, value := range manifest {
+		values[name] = value
+	}
+	return values
+}
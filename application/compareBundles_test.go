@@ -0,0 +1,146 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles creates count files of different sizes below directory and returns them by
+// name, as bundleManifest passes them to hashFiles.
+func writeTestFiles(t testing.TB, directory string, count int) map[string]string {
+	t.Helper()
+	files := make(map[string]string, count)
+	for index := 0; index < count; index++ {
+		name := fmt.Sprintf("Contents/Resources/%02d/file%04d.dat", index%10, index)
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat(fmt.Sprintf("content of file %d\n", index), index%50+1)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files[name] = path
+	}
+	return files
+}
+
+// TestHashFilesWorkerCount hashes the same tree with different numbers of workers, which must
+// all produce the digests of a serial hashFile.
+func TestHashFilesWorkerCount(t *testing.T) {
+	files := writeTestFiles(t, t.TempDir(), 200)
+
+	want := make(map[string]string, len(files))
+	for name, path := range files {
+		digest, err := hashFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[name] = digest
+	}
+
+	for _, workers := range []int{0, 1, 4, runtime.NumCPU(), 500} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			got, err := hashFiles(files, workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("hashFiles() with %d workers differs from the serial digests", workers)
+			}
+		})
+	}
+}
+
+func TestHashFilesMissingFile(t *testing.T) {
+	files := writeTestFiles(t, t.TempDir(), 20)
+	files["Contents/Resources/missing.dat"] = filepath.Join(t.TempDir(), "missing.dat")
+
+	for _, workers := range []int{1, 8} {
+		if _, err := hashFiles(files, workers); !os.IsNotExist(err) {
+			t.Errorf("error with %d workers = %v, want a missing file", workers, err)
+		}
+	}
+}
+
+func BenchmarkHashFiles(b *testing.B) {
+	files := writeTestFiles(b, b.TempDir(), 400)
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for iteration := 0; iteration < b.N; iteration++ {
+				if _, err := hashFiles(files, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// writeCompareBundle creates a bundle with the given CFBundleVersion and Resources/help.txt.
+func writeCompareBundle(t *testing.T, name string, version string, help string) string {
+	t.Helper()
+	appPath := filepath.Join(t.TempDir(), name)
+	data := testPlistData()
+	data.BundleVersion = version
+	content, err := RenderPlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeBundleFile(t, appPath, "Contents/Info.plist", content)
+	writeBundleFile(t, appPath, "Contents/MacOS/MyApp", testMachOContent)
+	writeBundleFile(t, appPath, "Contents/Resources/help.txt", help)
+	return appPath
+}
+
+// TestCompareBundles compares two fixture bundles that differ in one plist key and one file.
+func TestCompareBundles(t *testing.T) {
+	oldApp := writeCompareBundle(t, "Old.app", "1", "old help")
+	newApp := writeCompareBundle(t, "New.app", "2", "new help")
+	digest := func(appPath string, name string) string {
+		value, err := hashFile(filepath.Join(appPath, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	tests := []struct {
+		name   string
+		newApp string
+		want   []string
+	}{
+		{"identical", writeCompareBundle(t, "Same.app", "1", "old help"), nil},
+		{
+			name:   "one plist key and one file",
+			newApp: newApp,
+			want: []string{
+				"~ plist CFBundleVersion: 1 -> 2",
+				"~ file Contents/Info.plist: " + digest(oldApp, "Contents/Info.plist") + " -> " + digest(newApp, "Contents/Info.plist"),
+				"~ file Contents/Resources/help.txt: " + digest(oldApp, "Contents/Resources/help.txt") + " -> " + digest(newApp, "Contents/Resources/help.txt"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			differences, err := CompareBundles(oldApp, test.newApp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, difference := range differences {
+				got = append(got, difference.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("differences = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package application: This file prints the resolved configuration for debugging.
+// It shows every value as the bundler will use it (after defaults and path resolution),
+// so it's easy to see why a wrong value ends up in Info.plist. Nothing is built or
+// modified; missing source files are reported instead of causing an error.
+package application
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// pathStatus returns "(found)" or "(not found)" for a path, used in the configuration report.
+func pathStatus(path string) string {
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "(not found)"
+	}
+
+	return "(found)"
+}
+
+// PrintConfiguration writes all resolved configuration values as a table.
+//
+// Parameters:
+//   - w: Destination of the report (usually os.Stdout)
+//
+// Returns an error if writing the report fails.
+func PrintConfiguration(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	row := func(key string, values ...string) {
+		fmt.Fprintf(table, "%s\t%s\n", key, strings.TrimSpace(strings.Join(values, " ")))
+	}
+
+	row("KEY", "VALUE")
+
+	// Bundle metadata
+	row("Bundle identifier", GetBundleIdentifier())
+	row("Bundle name", GetBundleName())
+	row("Display name", GetBundleDisplayName())
+	if GetBundleSpokenName() != "" {
+		row("Spoken name", GetBundleSpokenName())
+	}
+	row("Bundle version", GetBundleVersion())
+	if GetVersionScheme() != "" {
+		row("Version scheme", GetVersionScheme())
+	}
+	row("Short version string", GetCFBundleShortVersionString())
+	row("Bundle executable", GetBundleExecutable())
+	row("Package type", GetPackageType())
+	row("Signature", GetBundleSignature())
+	row("Minimum macOS version", GetMinimumMacOSVersion())
+	for _, architecture := range slices.Sorted(maps.Keys(GetMinimumMacOSVersionByArchitecture())) {
+		row("Minimum macOS ("+architecture+")", GetMinimumMacOSVersionByArchitecture()[architecture])
+	}
+	row("Development region", GetDevelopmentRegion())
+	row("Copyright", GetNSHumanReadableCopyright())
+	row("Principal class", GetNSPrincipalClass())
+	row("Main NIB file", GetNSMainNibFile())
+	row("Document types", strconv.Itoa(len(GetCFBundleDocumentTypes())))
+	row("Services", strconv.Itoa(len(GetServices())))
+	if agent := GetLaunchAgent(); agent != nil {
+		row("Launch agent", agent.Label+" ("+agent.Executable+")")
+	}
+
+	// Source files (resolved paths)
+	if GetGoPackage() != "" {
+		row("Go package", GetGoPackage(), pathStatus(GetGoPackage()))
+		if architectures := GetGoArchitectures(); len(architectures) > 0 {
+			row("Go targets (universal)", GetGoOS()+"/"+strings.Join(architectures, ", "+GetGoOS()+"/"))
+		} else {
+			row("Go target", GetGoOS()+"/"+GetGoArch())
+		}
+	} else if isExecutableURL(GetExecutablePath()) {
+		row("Executable URL", GetExecutablePath())
+		row("Executable SHA-256", GetExecutableChecksum())
+	} else {
+		row("Executable path", GetExecutablePath(), pathStatus(GetExecutablePath()))
+	}
+	if GetIconFileName() != "" {
+		row("Icon path", GetIconFilePath(), pathStatus(GetIconFilePath()))
+	} else {
+		row("Icon path", "")
+	}
+	row("Icon name", GetIconName())
+	if GetAssetCatalog() != "" {
+		row("Asset catalog", GetAssetCatalog(), pathStatus(GetAssetCatalog()))
+	}
+	for _, resource := range GetResources() {
+		row("Resource", resource, pathStatus(resource))
+	}
+
+	// Java settings
+	row("Metadata from manifest", strconv.FormatBool(GetMetadataFromManifest()))
+	row("Local Java enabled", strconv.FormatBool(GetUseLocalJava()))
+	if GetUseLocalJava() {
+		if GetPerArchitectureJava() {
+			row("Java home (arm64)", GetJavaHomeArm64(), pathStatus(GetJavaHomeArm64()))
+			row("Java home (x86_64)", GetJavaHomeAmd64(), pathStatus(GetJavaHomeAmd64()))
+		} else {
+			row("Java home", GetJavaHomeDirectory(), pathStatus(GetJavaHomeDirectory()))
+		}
+		row("Java runtime mode", GetJavaRuntimeMode())
+		row("Target architecture", GetTargetArchitecture())
+	}
+
+	// Build options
+	row("Strip binary", strconv.FormatBool(GetStripBinary()))
+	row("Preserve xattrs", strconv.FormatBool(GetPreserveXattrs()))
+	row("Argument launcher", strconv.FormatBool(GetArgumentLauncher()))
+	row("Executable mode", GetExecutableMode().String())
+	row("Directory mode", GetDirectoryMode().String())
+	row("Skip PkgInfo", strconv.FormatBool(GetSkipPkgInfo()))
+
+	// Result of the pre-flight validation
+	if err := ValidateConfiguration(); err != nil {
+		row("Validation", "failed:", err.Error())
+	} else {
+		row("Validation", "ok")
+	}
+
+	return table.Flush()
+}
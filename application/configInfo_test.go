@@ -0,0 +1,38 @@
+package application
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// TestPrintConfiguration prints the configuration with a missing icon: the report shows the
+// resolved values, marks the icon as not found and reports the failed validation.
+func TestPrintConfiguration(t *testing.T) {
+	if err := readTestConfig(t, testPlistConfig+"short_version_string: \"1.2\"\nlocal_java: \"true\"\nlocal_java_home: /opt/jdk\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	var report bytes.Buffer
+	if err := PrintConfiguration(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`Bundle identifier +com\.example\.myapp`,
+		`Bundle name +MyApp`,
+		`Short version string +1\.2`,
+		`Minimum macOS version +10\.13\.0`,
+		`Development region +en`,
+		`Executable path +` + regexp.QuoteMeta(filepath.Join(GetExecutableDirectory(), "MyApp")) + ` \(found\)`,
+		`Icon path +MyApp\.icns \(not found\)`,
+		`Local Java enabled +true`,
+		`Java home +/opt/jdk \(not found\)`,
+		`Validation +failed: icon file not found: MyApp\.icns`,
+	} {
+		if !regexp.MustCompile(`(?m)^` + want + `$`).Match(report.Bytes()) {
+			t.Errorf("report has no line matching %q:\n%s", want, report.String())
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package application: This file prints an example configuration file listing every supported
+// field (-config-schema). The fields are taken from the yaml tags of packageParameter by
+// reflection, including the structured sections (document_types, launch_agent, ...), so the
+// example cannot miss a field. The descriptions are the comments of the struct fields, read
+// from the source files embedded into the binary.
+package application
+
+import (
+	"embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// packageSources are the source files of this package, read for the field comments
+//
+//go:embed *.go
+var packageSources embed.FS
+
+// schemaCommentColumn is the column the field descriptions start at
+const schemaCommentColumn = 44
+
+// schemaComments holds the comments of struct fields, keyed by "<type>.<field>"
+type schemaComments struct {
+	descriptions map[string]string // Trailing comment of a field
+	sections     map[string]string // Comment line above the first field of a group
+}
+
+// readSchemaComments collects the field comments of all struct types of the package sources.
+// Files that cannot be parsed are skipped; their fields are printed without description.
+func readSchemaComments() schemaComments {
+	comments := schemaComments{descriptions: map[string]string{}, sections: map[string]string{}}
+
+	entries, err := packageSources.ReadDir(".")
+	if err != nil {
+		return comments
+	}
+
+	fileSet := token.NewFileSet()
+	for _, entry := range entries {
+		source, err := packageSources.ReadFile(entry.Name())
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, entry.Name(), source, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			typeSpec, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+
+			for _, field := range structType.Fields.List {
+				for _, name := range field.Names {
+					key := typeSpec.Name.Name + "." + name.Name
+					if field.Comment != nil {
+						comments.descriptions[key] = strings.TrimSpace(field.Comment.Text())
+					}
+					if field.Doc != nil {
+						comments.sections[key] = strings.TrimSpace(strings.Split(field.Doc.Text(), "\n")[0])
+					}
+				}
+			}
+			return false
+		})
+	}
+
+	return comments
+}
+
+// ConfigSchema returns an example configuration file with every supported field, set to its
+// empty value and commented with its description.
+func ConfigSchema() string {
+	var schema strings.Builder
+
+	schema.WriteString("# Example application.yaml with all supported fields (generated by -config-schema).\n")
+	schema.WriteString("# Remove the fields you don't need; empty fields keep their defaults.\n")
+	writeSchemaStruct(&schema, reflect.TypeOf(packageParameter{}), "", "", readSchemaComments())
+
+	return schema.String()
+}
+
+// writeSchemaStruct writes the yaml-tagged fields of a struct type.
+//
+// Parameters:
+//   - schema: Output
+//   - structType: Struct type whose fields are written
+//   - indent: Indentation of the fields
+//   - firstIndent: Indentation of the first field ("- " for list entries), empty to use indent
+//   - comments: Field comments of the package
+func writeSchemaStruct(schema *strings.Builder, structType reflect.Type, indent string, firstIndent string, comments schemaComments) {
+	first := true
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		commentKey := structType.Name() + "." + field.Name
+		if section := comments.sections[commentKey]; section != "" && indent == "" {
+			fmt.Fprintf(schema, "\n# %s\n", section)
+		}
+
+		lineIndent := indent
+		if first && firstIndent != "" {
+			lineIndent = firstIndent
+		}
+		first = false
+
+		writeSchemaField(schema, lineIndent+key, field.Type, indent, comments.descriptions[commentKey], comments)
+	}
+}
+
+// writeSchemaField writes one field: scalars, lists and maps as their empty value, structs and
+// lists of structs as nested entries.
+func writeSchemaField(schema *strings.Builder, prefix string, fieldType reflect.Type, indent string, description string, comments schemaComments) {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	switch {
+	case fieldType.Kind() == reflect.Struct:
+		writeSchemaLine(schema, prefix+":", description)
+		writeSchemaStruct(schema, fieldType, indent+"  ", "", comments)
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct:
+		writeSchemaLine(schema, prefix+":", description)
+		writeSchemaStruct(schema, fieldType.Elem(), indent+"    ", indent+"  - ", comments)
+	default:
+		writeSchemaLine(schema, prefix+": "+schemaEmptyValue(fieldType), description)
+	}
+}
+
+// writeSchemaLine writes a line of the example, followed by the description as a comment.
+func writeSchemaLine(schema *strings.Builder, line string, description string) {
+	if description == "" {
+		schema.WriteString(line + "\n")
+		return
+	}
+
+	padding := max(schemaCommentColumn-len(line), 1)
+	schema.WriteString(line + strings.Repeat(" ", padding) + "# " + description + "\n")
+}
+
+// schemaEmptyValue returns the YAML notation of the empty value of a type.
+func schemaEmptyValue(fieldType reflect.Type) string {
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		return "false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "0"
+	case reflect.Slice, reflect.Array:
+		return "[]"
+	case reflect.Map:
+		return "{}"
+	default:
+		return `""`
+	}
+}
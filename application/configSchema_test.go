@@ -0,0 +1,65 @@
+package application
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaKeys returns the yaml keys of a struct type and the struct types nested in it,
+// in the form "<type>.<key>".
+func schemaKeys(structType reflect.Type) []string {
+	var keys []string
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		keys = append(keys, structType.Name()+"."+key)
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			keys = append(keys, schemaKeys(fieldType)...)
+		}
+	}
+	return keys
+}
+
+// TestConfigSchemaListsAllFields checks that every yaml tag of packageParameter and its nested
+// types appears in the -config-schema output.
+func TestConfigSchemaListsAllFields(t *testing.T) {
+	schema := ConfigSchema()
+
+	keys := schemaKeys(reflect.TypeOf(packageParameter{}))
+	if len(keys) == 0 {
+		t.Fatal("packageParameter has no yaml tags")
+	}
+
+	for _, key := range keys {
+		name := key[strings.LastIndex(key, ".")+1:]
+		pattern := regexp.MustCompile(`(?m)^ *(- )?` + regexp.QuoteMeta(name) + `:`)
+		if !pattern.MatchString(schema) {
+			t.Errorf("%s is missing from the schema", key)
+		}
+	}
+}
+
+// TestConfigSchemaIsValidConfig parses the schema strictly: every key it lists must be a
+// field of the configuration.
+func TestConfigSchemaIsValidConfig(t *testing.T) {
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(ConfigSchema())))
+	decoder.KnownFields(true)
+
+	var parameters packageParameter
+	if err := decoder.Decode(&parameters); err != nil {
+		t.Fatalf("schema is not a valid configuration: %v", err)
+	}
+}
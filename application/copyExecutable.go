@@ -17,6 +17,9 @@ import (
 // handles each case appropriately:
 //   - JAR files: Copies JAR, optionally bundles Java runtime, and creates a launcher script
 //   - Compiled binaries: Copies the binary and sets executable permissions
+//   - Go packages (go_package): Builds the binary with "go build", then copies it
+//   - Scripts (#!): Copies the script as the bundle executable and sets executable permissions
+//   - HTTP(S) URLs: Downloads the file into a temporary directory, then handles it as above
 //
 // Returns an error if the copy operation fails.
 func CopyExecutable() error {
@@ -24,82 +27,134 @@ func CopyExecutable() error {
 
 	var err error
 
-	// Get the executable filename and directory from the configuration
-	execFile := GetExecutableName()
-	execPath := GetExecutableDirectory()
+	// Make sure Contents/MacOS/ exists before writing into it
+	if err := ensureBundleDirectory(macosDir, "Contents/MacOS"); err != nil {
+		return err
+	}
+
+	// A Go package is compiled first, the resulting binary is copied like any compiled executable
+	if GetGoPackage() != "" {
+		err = buildGoPackage()
+		if err != nil {
+			logger.Debug("failed to build go package: %s: %s", GetGoPackage(), err.Error())
+		}
+		return err
+	}
 
-	// If local_exec_directory is provided, use it instead of the default exec_file_directory
-	if GetLocalExecDirectory() != "" {
-		execPath = GetLocalExecDirectory()
+	// Get the resolved source path of the executable from the configuration
+	// (handles absolute paths, nested relative paths and local_exec_directory)
+	sourcePath := GetExecutablePath()
+
+	// A remote executable is downloaded into a temporary directory first
+	if isExecutableURL(sourcePath) {
+		downloadDirectory, err := os.MkdirTemp("", "appbundler-download-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(downloadDirectory)
+
+		sourcePath, err = downloadExecutable(sourcePath, GetExecutableChecksum(), downloadDirectory)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Determine if this is a Java JAR file or a compiled executable
+	// Determine if this is a Java JAR file or a compiled executable (by content, see isJarExecutable)
 	// JAR files need special handling: they require a launcher script and optionally a Java runtime
-	if strings.HasSuffix(execFile, "jar") {
-		err = copyJarExec(execPath, execFile)
+	// Scripts with a shebang line are launched directly, so they become the bundle executable
+	if isJarExecutable(sourcePath) {
+		warnArgumentLauncherIgnored(sourcePath)
+		err = copyJarExec(sourcePath)
+	} else if isScriptExecutable(sourcePath) {
+		warnArgumentLauncherIgnored(sourcePath)
+		err = copyScriptExec(sourcePath)
 	} else {
 		// For compiled executables (Go binaries, C/C++ binaries, etc.), just copy and set permissions
-		err = copyCompExec(execPath, execFile)
+		err = copyCompExec(sourcePath)
 	}
 
 	if err != nil {
-		logger.Debug("failed to copy executable file:", execFile, err.Error())
+		logger.Debug("failed to copy executable file: %s: %s", sourcePath, err.Error())
 	}
 
-	return nil
+	return err
 }
 
 // copyJarExec handles copying Java JAR files and creating a launcher script.
 // This function performs three main tasks:
 //  1. Optionally copies the Java runtime into the bundle (if local_java is enabled)
-//  2. Copies the JAR file into the MacOS directory
+//  2. Copies the JAR file into the MacOS directory (and the optional splash image into Resources)
 //  3. Creates a bash script that launches the JAR file
 //
 // Parameters:
-//   - execPath: Directory containing the JAR file
-//   - execFile: Name of the JAR file
+//   - sourcePath: Resolved path of the JAR file
 //
 // Returns an error if any step fails.
-func copyJarExec(execPath string, execFile string) error {
+func copyJarExec(sourcePath string) error {
 	var err error
 
+	// The JAR is placed directly in Contents/MacOS/, regardless of its source directory.
+	// A JAR without suffix (e.g. a download named after the bundle executable) gets one,
+	// so it does not collide with the launcher script.
+	execFile := filepath.Base(sourcePath)
+	if !strings.HasSuffix(strings.ToLower(execFile), ".jar") {
+		execFile += ".jar"
+	}
+
 	// The launcher script will be created in Contents/MacOS/ with the bundle executable name
 	// This is the file that macOS will execute when the user double-clicks the app
 	executableName := filepath.Join(macosDir, GetBundleExecutable())
 
 	// Step 1: Optionally bundle a local Java runtime
 	// If local_java is set to true in the config, copy the entire Java installation
-	// into Contents/Java/runtime. This makes the app self-contained and doesn't
-	// require users to have Java installed on their system.
+	// into Contents/Java/runtime (or Contents/runtime for the jpackage layout). This makes
+	// the app self-contained and doesn't require users to have Java installed on their system.
+	// With java_home_arm64 and java_home_amd64, each runtime is copied into its own
+	// subdirectory (runtime/arm64, runtime/x86_64) and the launcher picks one at start.
 	if GetUseLocalJava() == true {
-		javaSourceName := GetJavaHomeDirectory()
-		javaDestName := filepath.Join(javaDir, "runtime")
-
-		// Copy the entire Java installation directory (this can be large, ~200MB+)
-		err = fileManagement.CopyDirectory(javaSourceName, javaDestName)
-		if err != nil {
-			logger.Debug("failed to copy java installation:", javaSourceName, err.Error())
-			return err
+		for _, runtime := range bundledRuntimes() {
+			err = copyJavaRuntime(runtime)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	// Step 2: Copy the JAR file into Contents/MacOS/
 	// The JAR file will be executed by the launcher script
-	compiledJarSourceName := filepath.Join(execPath, execFile)
+	compiledJarSourceName := sourcePath
 	compiledJarTargetName := filepath.Join(macosDir, execFile)
 
 	err = fileManagement.Copy(compiledJarSourceName, compiledJarTargetName)
 	if err != nil {
-		logger.Debug("failed to copy java executable:", compiledJarSourceName, err.Error())
+		logger.Debug("failed to copy java executable: %s: %s", compiledJarSourceName, err.Error())
 		return err
 	}
 
+	// Copy the splash screen image into Contents/Resources/ (optional)
+	splashOption := ""
+	if splashImage := GetSplashImage(); splashImage != "" {
+		if err = ensureBundleDirectory(resourcesDir, "Contents/Resources"); err != nil {
+			return err
+		}
+
+		splashName := filepath.Base(splashImage)
+		err = fileManagement.Copy(splashImage, filepath.Join(resourcesDir, splashName))
+		if err != nil {
+			logger.Debug("failed to copy splash image: %s: %s", splashImage, err.Error())
+			return err
+		}
+
+		// The JVM shows the image until the first window opens
+		splashOption = fmt.Sprintf("-splash:\"$DIR/../Resources/%s\" ", splashName)
+	}
+
 	// Step 3: Create a shell script launcher
 	// macOS will execute this script when the app is launched
 	// The script runs the JAR file using either the bundled Java or system Java
-	file, err := os.Create(executableName)
+	file, err := tracedCreate(executableName)
 	if err != nil || file == nil {
-		logger.Debug("failed to generate start script:", executableName)
+		logger.Debug("failed to generate start script: %s", executableName)
 		return err
 	}
 
@@ -108,11 +163,11 @@ func copyJarExec(execPath string, execFile string) error {
 	var startString string
 
 	if GetUseLocalJava() == true {
-		// Script for bundled Java runtime
-		startString = fmt.Sprintf("#!/bin/bash\n\nDIR=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\nexport JAVA_HOME=\"$DIR/../Java/runtime\"\n\"$JAVA_HOME/bin/java\" -jar \"$DIR/%s\"\n", execFile)
+		// Script for bundled Java runtime (the path depends on the runtime layout)
+		startString = fmt.Sprintf("#!/bin/bash\n\nDIR=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\n%s\"$JAVA_HOME/bin/java\" %s-jar \"$DIR/%s\"\n", javaHomeScript(), splashOption, execFile)
 	} else {
 		// Script for system Java
-		startString = fmt.Sprintf("#!/bin/bash\n\nDIR=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\njava -jar \"$DIR/%s\"\n", execFile)
+		startString = fmt.Sprintf("#!/bin/bash\n\nDIR=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\njava %s-jar \"$DIR/%s\"\n", splashOption, execFile)
 	}
 
 	_, err = file.WriteString(startString)
@@ -123,8 +178,8 @@ func copyJarExec(execPath string, execFile string) error {
 	err = file.Close()
 
 	// Make the script executable (required for macOS to run it)
-	// 0755 = rwxr-xr-x: owner can read/write/execute, others can read/execute
-	err = os.Chmod(executableName, 0755)
+	// The default is 0755 (rwxr-xr-x), executable_mode overrides it
+	err = tracedChmod(executableName, GetExecutableMode())
 	if err != nil {
 		logger.Debug("failed to make script executable")
 		return err
@@ -133,33 +188,203 @@ func copyJarExec(execPath string, execFile string) error {
 	return nil
 }
 
+// copyScriptExec copies a script with a shebang line (shell, Python, ...) into Contents/MacOS/
+// under the bundle executable name (CFBundleExecutable), so macOS launches it directly with
+// its interpreter. No launcher is generated.
+//
+// Parameters:
+//   - sourcePath: Resolved path of the script
+//
+// Returns an error if the copy operation fails.
+func copyScriptExec(sourcePath string) error {
+	executablePath := filepath.Join(macosDir, GetBundleExecutable())
+
+	logger.Warn("%s is a script: the app can only be notarized if it is signed, and it depends on the interpreter installed on the user's Mac", sourcePath)
+
+	err := fileManagement.Copy(sourcePath, executablePath)
+	if err != nil {
+		logger.Debug("failed to copy script: %s: %s", sourcePath, err.Error())
+		return err
+	}
+
+	// The default is 0755 (rwxr-xr-x), executable_mode overrides it
+	return tracedChmod(executablePath, GetExecutableMode())
+}
+
+// bundledRuntime is a Java installation copied into the bundle.
+type bundledRuntime struct {
+	source       string // Path of the Java installation
+	architecture string // Architecture the runtime must support
+	subdirectory string // Subdirectory of the runtime directory ("" for a single runtime)
+}
+
+// bundledRuntimes returns the Java installations to copy into the bundle: one per architecture
+// if java_home_arm64 and java_home_amd64 are set, otherwise local_java_home (which may be a
+// universal JDK) for the target architecture.
+func bundledRuntimes() []bundledRuntime {
+	if GetPerArchitectureJava() {
+		return []bundledRuntime{
+			{source: GetJavaHomeArm64(), architecture: "arm64", subdirectory: "arm64"},
+			{source: GetJavaHomeAmd64(), architecture: "x86_64", subdirectory: "x86_64"},
+		}
+	}
+
+	return []bundledRuntime{{source: GetJavaHomeDirectory(), architecture: GetTargetArchitecture()}}
+}
+
+// copyJavaRuntime copies a Java installation into the runtime directory of the bundle.
+//
+// Returns an error if the architecture check fails in strict mode or the copy fails.
+func copyJavaRuntime(runtime bundledRuntime) error {
+	javaDestName := filepath.Join(runtimeDir, runtime.subdirectory)
+
+	// Make sure the runtime can run on its architecture before copying it
+	err := checkRuntimeArchitecture(runtime.source, runtime.architecture)
+	if err != nil {
+		return err
+	}
+
+	// A linked runtime saves the copy, but the bundle then depends on this machine
+	if GetJavaRuntimeMode() == javaRuntimeModeSymlink {
+		return linkJavaRuntime(runtime.source, javaDestName)
+	}
+
+	// Make sure the runtime directory exists (one subdirectory per architecture)
+	if err = createDir(javaDestName); err != nil {
+		return err
+	}
+
+	// Copy the entire Java installation directory (this can be large, ~200MB+)
+	err = fileManagement.CopyDirectory(runtime.source, javaDestName)
+	if err != nil {
+		logger.Debug("failed to copy java installation: %s: %s", runtime.source, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// linkJavaRuntime creates the runtime directory of the bundle as a symbolic link to the Java
+// installation (java_runtime_mode: symlink), for fast local development builds. The link
+// points outside the bundle, so the bundle only runs on this machine and cannot be signed
+// for distribution or notarized.
+//
+// Parameters:
+//   - source: Path of the Java installation
+//   - destination: Path of the runtime directory in the bundle
+//
+// Returns an error if the link cannot be created.
+func linkJavaRuntime(source string, destination string) error {
+	// The link must not depend on the working directory of the build
+	target, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+
+	if err := createDir(filepath.Dir(destination)); err != nil {
+		return err
+	}
+	// The link replaces the empty runtime directory created with the bundle
+	if err := tracedRemove(destination); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace the runtime directory %s: %v", destination, err)
+	}
+	if err := tracedSymlink(target, destination); err != nil {
+		return fmt.Errorf("failed to link the Java runtime %s: %v", target, err)
+	}
+
+	logger.Warn("The Java runtime is a symbolic link to %s (java_runtime_mode: symlink): the bundle only runs on this machine and cannot be distributed or notarized", target)
+	return nil
+}
+
+// javaHomeScript returns the launcher script lines setting JAVA_HOME to the bundled runtime.
+// With a runtime per architecture, "uname -m" selects it (arm64 on Apple silicon, x86_64 on
+// Intel Macs and under Rosetta).
+func javaHomeScript() string {
+	runtimePath := filepath.ToSlash(runtimeRelativePath())
+
+	if !GetPerArchitectureJava() {
+		return fmt.Sprintf("export JAVA_HOME=\"$DIR/../%s\"\n", runtimePath)
+	}
+
+	return fmt.Sprintf("if [ \"$(uname -m)\" = \"arm64\" ]; then\n"+
+		"    export JAVA_HOME=\"$DIR/../%[1]s/arm64\"\n"+
+		"else\n"+
+		"    export JAVA_HOME=\"$DIR/../%[1]s/x86_64\"\n"+
+		"fi\n", runtimePath)
+}
+
 // copyCompExec handles copying compiled executable binaries (Go, C/C++, etc.).
 // Unlike JAR files, compiled executables don't need a launcher script - they can
 // be executed directly by macOS.
 //
 // Parameters:
-//   - execPath: Directory containing the executable file
-//   - execFile: Name of the executable file
+//   - sourcePath: Resolved path of the executable file
 //
-// Returns an error if the copy operation fails.
-func copyCompExec(execPath string, execFile string) error {
+// Returns an error if the copy operation fails, or in strict mode if the file is not a Mach-O binary.
+func copyCompExec(sourcePath string) error {
+	// A binary for another system (e.g. Linux) would be bundled, but never launch
+	if err := checkMachOExecutable(sourcePath); err != nil {
+		return err
+	}
+
 	// Destination path: Contents/MacOS/executable_name
-	executablePath := filepath.Join(macosDir, execFile)
-	sourceFileName := filepath.Join(execPath, execFile)
+	// With argument_launcher the binary is renamed and a launcher takes its place
+	executablePath := filepath.Join(macosDir, filepath.Base(sourcePath))
+	if GetArgumentLauncher() {
+		executablePath = filepath.Join(macosDir, launchedBinaryName())
+	}
+	sourceFileName := sourcePath
 
 	// Copy the executable binary from source to the bundle
-	err := fileManagement.Copy(sourceFileName, executablePath)
+	// fileManagement.Copy drops extended attributes, ditto keeps them if requested
+	var err error
+	if GetPreserveXattrs() {
+		err = dittoCopy(sourceFileName, executablePath)
+	} else {
+		err = fileManagement.Copy(sourceFileName, executablePath)
+	}
 	if err != nil {
-		logger.Debug("failed to copy executable file from source to destination file:", sourceFileName, err.Error())
+		logger.Debug("failed to copy executable file from source to destination file: %s: %s", sourceFileName, err.Error())
 		return err
 	}
 
+	// Optionally remove debug symbols to reduce the bundle size (must happen before signing)
+	if GetStripBinary() {
+		err = stripBinary(executablePath)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Set executable permissions (required for macOS to run the binary)
-	// 0755 = rwxr-xr-x: owner can read/write/execute, others can read/execute
-	err = os.Chmod(executablePath, 0755)
+	// The default is 0755 (rwxr-xr-x), executable_mode overrides it
+	err = tracedChmod(executablePath, GetExecutableMode())
+	if err != nil {
+		return err
+	}
+
+	// The launcher becomes the bundle executable and forwards its arguments to the binary
+	if GetArgumentLauncher() {
+		return writeArgumentLauncher(filepath.Base(executablePath))
+	}
+
+	return nil
+}
+
+// dittoCopy copies a file including its extended attributes and resource fork by running:
+// ditto <source> <destination>
+func dittoCopy(source string, destination string) error {
+	dittoPath, err := fileManagement.FindProgramPath("ditto")
 	if err != nil {
 		return err
 	}
 
+	// The copy is part of the bundle, so it also runs with -print-commands
+	options := commandOptions{timeout: commandTimeout, alwaysRun: true}
+	_, stderr, err := runCommandWithOptions(options, dittoPath, source, destination)
+	if err != nil {
+		return fmt.Errorf("failed to copy %q with ditto: %v\n%s", source, err, stderr)
+	}
+
 	return nil
 }
@@ -0,0 +1,351 @@
+package application
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestCopyJarWithoutSuffix bundles a JAR named like the bundle executable, as a download is:
+// the JAR gets a .jar suffix instead of being overwritten by the launcher script.
+func TestCopyJarWithoutSuffix(t *testing.T) {
+	jarDirectory := writeTestExecutable(t, "MyApp", testJarContent)
+	setTestBundle(t, packageParameter{ExecFileName: "MyApp", ExecFileDirectory: jarDirectory, BundleExecutable: "MyApp"})
+
+	if err := CopyExecutable(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readBundleFile(t, GetApplicationDirectory(), "Contents/MacOS/MyApp.jar"); got != testJarContent {
+		t.Errorf("MyApp.jar = %q, want the JAR", got)
+	}
+	if launcher := readBundleFile(t, GetApplicationDirectory(), "Contents/MacOS/MyApp"); !strings.Contains(launcher, `-jar "$DIR/MyApp.jar"`) {
+		t.Errorf("launcher does not start MyApp.jar:\n%s", launcher)
+	}
+}
+
+// TestJavaRuntimeMode bundles a Java installation in both runtime modes: symlink mode links
+// Contents/Java/runtime to the installation instead of copying it.
+func TestJavaRuntimeMode(t *testing.T) {
+	javaHome := t.TempDir()
+	writeBundleFile(t, javaHome, "bin/java", "#!/bin/sh\n")
+
+	tests := []struct {
+		name        string
+		mode        string
+		wantSymlink bool
+	}{
+		{"default", "", false},
+		{"copy", javaRuntimeModeCopy, false},
+		{"symlink", javaRuntimeModeSymlink, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestStrictMode(t, false)
+			setTestBundle(t, packageParameter{LocalJava: "true", LocalJavaHome: javaHome, JavaRuntimeMode: test.mode})
+
+			if err := copyJavaRuntime(bundledRuntimes()[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Lstat(runtimeDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isSymlink := info.Mode()&os.ModeSymlink != 0; isSymlink != test.wantSymlink {
+				t.Fatalf("runtime is a symbolic link: %v, want %v", isSymlink, test.wantSymlink)
+			}
+			if test.wantSymlink {
+				if target, err := os.Readlink(runtimeDir); err != nil || target != javaHome {
+					t.Errorf("runtime links to %q (%v), want %s", target, err, javaHome)
+				}
+			}
+			if got := readBundleFile(t, runtimeDir, "bin/java"); got != "#!/bin/sh\n" {
+				t.Errorf("runtime bin/java = %q", got)
+			}
+		})
+	}
+}
+
+// TestCopyExecutablePaths copies binaries configured with a nested relative and an absolute
+// exec_file: both are copied into Contents/MacOS under their file name.
+func TestCopyExecutablePaths(t *testing.T) {
+	directory := t.TempDir()
+	writeBundleFile(t, directory, "build/release/MyApp", testMachOContent)
+
+	tests := []struct {
+		name      string
+		parameter packageParameter
+	}{
+		{"nested relative path", packageParameter{ExecFileName: "build/release/MyApp", ExecFileDirectory: directory}},
+		{"absolute path", packageParameter{ExecFileName: filepath.Join(directory, "build", "release", "MyApp")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, test.parameter)
+
+			if err := CopyExecutable(); err != nil {
+				t.Fatal(err)
+			}
+			if got := readBundleFile(t, GetApplicationDirectory(), "Contents/MacOS/MyApp"); got != testMachOContent {
+				t.Errorf("Contents/MacOS/MyApp = %q, want the binary", got)
+			}
+		})
+	}
+}
+
+// TestRuntimeLayout bundles a JAR with a Java runtime in both layouts and runs the launcher:
+// it must start the java binary of the runtime copied into the bundle.
+func TestRuntimeLayout(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	javaHome := t.TempDir()
+	writeBundleFile(t, javaHome, "bin/java", "#!/bin/sh\necho \"$0 $*\"\n")
+	if err := os.Chmod(filepath.Join(javaHome, "bin", "java"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	jarDirectory := writeTestExecutable(t, "MyApp.jar", testJarContent)
+
+	tests := []struct {
+		name        string
+		layout      string
+		wantRuntime string
+	}{
+		{"default", "", "Contents/Java/runtime"},
+		{"java", runtimeLayoutJava, "Contents/Java/runtime"},
+		{"jpackage", runtimeLayoutJPackage, "Contents/runtime"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestStrictMode(t, false)
+			setTestBundle(t, packageParameter{
+				ExecFileName: "MyApp.jar", ExecFileDirectory: jarDirectory, BundleExecutable: "MyApp",
+				LocalJava: "true", LocalJavaHome: javaHome, RuntimeLayout: test.layout,
+			})
+
+			if err := CopyExecutable(); err != nil {
+				t.Fatal(err)
+			}
+
+			output, err := exec.Command("bash", filepath.Join(macosDir, "MyApp"), "--flag").Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := strings.Fields(string(output))
+			wantJava := filepath.Join(GetApplicationDirectory(), filepath.FromSlash(test.wantRuntime), "bin", "java")
+			if len(fields) == 0 || filepath.Clean(fields[0]) != wantJava {
+				t.Errorf("launcher started %q, want %s", output, wantJava)
+			}
+		})
+	}
+}
+
+// TestSplashImage bundles a JAR with and without a splash image: the image is copied into
+// Resources and the launcher passes it to java relative to the launcher.
+func TestSplashImage(t *testing.T) {
+	jarDirectory := writeTestExecutable(t, "MyApp.jar", testJarContent)
+	writeBundleFile(t, jarDirectory, "images/splash.png", "splash")
+
+	tests := []struct {
+		name       string
+		splash     string
+		wantOption string
+	}{
+		{"no splash image", "", ""},
+		{"splash image", filepath.Join(jarDirectory, "images", "splash.png"), `java -splash:"$DIR/../Resources/splash.png" -jar "$DIR/MyApp.jar"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, packageParameter{
+				ExecFileName: "MyApp.jar", ExecFileDirectory: jarDirectory, BundleExecutable: "MyApp", SplashImage: test.splash,
+			})
+
+			if err := CopyExecutable(); err != nil {
+				t.Fatal(err)
+			}
+
+			launcher := readBundleFile(t, GetApplicationDirectory(), "Contents/MacOS/MyApp")
+			if test.wantOption == "" {
+				if strings.Contains(launcher, "-splash") {
+					t.Errorf("launcher passes a splash image:\n%s", launcher)
+				}
+				return
+			}
+			if !strings.Contains(launcher, test.wantOption) {
+				t.Errorf("launcher does not contain %s:\n%s", test.wantOption, launcher)
+			}
+			if got := readBundleFile(t, resourcesDir, "splash.png"); got != "splash" {
+				t.Errorf("Resources/splash.png = %q, want the splash image", got)
+			}
+		})
+	}
+}
+
+// TestPreserveXattrs copies a binary with an extended attribute into the bundle: the attribute
+// survives with preserve_xattrs, which copies with ditto (faked with cp if it is not installed).
+func TestPreserveXattrs(t *testing.T) {
+	if _, err := exec.LookPath("ditto"); err != nil {
+		directory := t.TempDir()
+		if err := os.WriteFile(filepath.Join(directory, "ditto"), []byte("#!/bin/sh\ncp --preserve=xattr \"$1\" \"$2\"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", directory+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	const attribute = "user.appbundler.test"
+	sourceDirectory := writeTestExecutable(t, "MyApp", testMachOContent)
+	if err := syscall.Setxattr(filepath.Join(sourceDirectory, "MyApp"), attribute, []byte("kept"), 0); err != nil {
+		t.Skipf("extended attributes are not supported: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		preserve bool
+		want     string
+	}{
+		{"plain copy", false, ""},
+		{"preserve_xattrs", true, "kept"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, packageParameter{ExecFileName: "MyApp", ExecFileDirectory: sourceDirectory, PreserveXattrs: test.preserve})
+
+			if err := CopyExecutable(); err != nil {
+				t.Fatal(err)
+			}
+
+			value := make([]byte, 64)
+			size, err := syscall.Getxattr(filepath.Join(macosDir, "MyApp"), attribute, value)
+			if err != nil {
+				size = 0
+			}
+			if got := string(value[:size]); got != test.want {
+				t.Errorf("%s = %q, want %q", attribute, got, test.want)
+			}
+		})
+	}
+}
+
+// TestExecutableMode copies a binary with and without executable_mode: the configured mode,
+// including the setgid bit, replaces the default 0755.
+func TestExecutableMode(t *testing.T) {
+	sourceDirectory := writeTestExecutable(t, "MyApp", testMachOContent)
+
+	tests := []struct {
+		name string
+		mode string
+		want os.FileMode
+	}{
+		{"default", "", 0755},
+		{"group only", "0750", 0750},
+		{"setgid", "2755", os.ModeSetgid | 0755},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, packageParameter{ExecFileName: "MyApp", ExecFileDirectory: sourceDirectory, ExecutableMode: test.mode})
+
+			if err := CopyExecutable(); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(filepath.Join(macosDir, "MyApp"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode() & (os.ModePerm | os.ModeSetgid); got != test.want {
+				t.Errorf("mode = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestPerArchitectureJava bundles a JAR with a runtime per architecture: the launcher selects
+// the runtime by "uname -m", faked to report each architecture.
+func TestPerArchitectureJava(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	javaHomes := map[string]string{}
+	for _, architecture := range []string{"arm64", "x86_64"} {
+		javaHomes[architecture] = t.TempDir()
+		writeBundleFile(t, javaHomes[architecture], "bin/java", "#!/bin/sh\necho "+architecture+" runtime\n")
+		if err := os.Chmod(filepath.Join(javaHomes[architecture], "bin", "java"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jarDirectory := writeTestExecutable(t, "MyApp.jar", testJarContent)
+
+	setTestStrictMode(t, false)
+	setTestBundle(t, packageParameter{
+		ExecFileName: "MyApp.jar", ExecFileDirectory: jarDirectory, BundleExecutable: "MyApp", LocalJava: "true",
+		JavaHomeArm64: javaHomes["arm64"], JavaHomeAmd64: javaHomes["x86_64"],
+	})
+	if err := CopyExecutable(); err != nil {
+		t.Fatal(err)
+	}
+
+	launcherPath := filepath.Join(macosDir, "MyApp")
+	if launcher := readBundleFile(t, macosDir, "MyApp"); !strings.Contains(launcher, `if [ "$(uname -m)" = "arm64" ]; then`) {
+		t.Fatalf("launcher has no architecture detection:\n%s", launcher)
+	}
+
+	for _, architecture := range []string{"arm64", "x86_64"} {
+		t.Run(architecture, func(t *testing.T) {
+			toolDirectory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(toolDirectory, "uname"), []byte("#!/bin/sh\necho "+architecture+"\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			output, err := exec.Command("bash", launcherPath).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := architecture + " runtime\n"; string(output) != want {
+				t.Errorf("launcher started %q, want %q", output, want)
+			}
+		})
+	}
+}
+
+// TestCopyScriptExecutable bundles a non-executable bash script: the script itself becomes the
+// bundle executable, without a generated launcher.
+func TestCopyScriptExecutable(t *testing.T) {
+	const script = "#!/bin/bash\necho started\n"
+	scriptDirectory := t.TempDir()
+	writeBundleFile(t, scriptDirectory, "run.sh", script)
+	setTestBundle(t, packageParameter{ExecFileName: "run.sh", ExecFileDirectory: scriptDirectory, BundleExecutable: "MyApp"})
+	log := captureTestLog(t)
+
+	if err := CopyExecutable(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(macosDir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "MyApp" {
+		t.Fatalf("Contents/MacOS contains %v (%v), want only MyApp", entries, err)
+	}
+	if got := readBundleFile(t, macosDir, "MyApp"); got != script {
+		t.Errorf("MyApp = %q, want the script", got)
+	}
+	info, err := os.Stat(filepath.Join(macosDir, "MyApp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("MyApp mode = %v, want 0755", info.Mode().Perm())
+	}
+	if !strings.Contains(log.String(), "is a script: the app can only be notarized if it is signed") {
+		t.Errorf("log = %q, want a notarization warning", log.String())
+	}
+}
@@ -5,6 +5,7 @@
 package application
 
 import (
+	"appbundler/utilities/fileManagement"
 	"appbundler/utilities/logger"
 	"errors"
 	"fmt"
@@ -13,21 +14,58 @@ import (
 	"path/filepath"
 )
 
+// skipIcon builds the bundle without an icon (set via SetSkipIcon).
+var skipIcon bool
+
+// SetSkipIcon enables or disables building the bundle without an icon, e.g. for command-line
+// tools packaged as .app. No icon or asset catalog is copied and Info.plist contains neither
+// CFBundleIconFile nor CFBundleIconName; Finder shows the generic application icon.
+//
+// Parameters:
+//   - skip: true to build the bundle without an icon
+func SetSkipIcon(skip bool) {
+	skipIcon = skip
+}
+
 // CopyIcon copies the application icon file from the source location to
 // Contents/Resources/ within the bundle. The icon file name is specified in
-// the configuration YAML file.
+// the configuration YAML file. A compiled asset catalog (asset_catalog) is copied
+// as Contents/Resources/Assets.car; with an icon_name, the .icns file is optional.
+//
+// Does nothing with -no-icon (see SetSkipIcon).
 //
 // Returns an error if:
-//   - Icon filename is not defined in config
+//   - Neither an icon filename nor an icon name is defined in config
 //   - Source file doesn't exist
 //   - Copy operation fails
 func CopyIcon() error {
+	if skipIcon {
+		logger.Info("Building the bundle without an icon (-no-icon)")
+		return nil
+	}
+
 	logger.Info("Copying the Icon File")
 
-	// Get icon filename and directory from configuration
+	// Copy the asset catalog containing the icon referenced by CFBundleIconName
+	if assetCatalog := GetAssetCatalog(); assetCatalog != "" {
+		if err := ensureBundleDirectory(resourcesDir, "Contents/Resources"); err != nil {
+			return err
+		}
+		if err := fileManagement.Copy(assetCatalog, filepath.Join(resourcesDir, "Assets.car")); err != nil {
+			logger.Debug("failed to copy asset catalog: %s: %s", assetCatalog, err.Error())
+			return err
+		}
+	}
+
+	// Get icon filename from configuration
 	iconSource := GetIconFileName()
-	iconDirectory := GetIconFileDirectory()
-	
+
+	// An icon from the asset catalog does not need an .icns file
+	if iconSource == "" && GetIconName() != "" {
+		logger.Debug("No icon file configured, using icon %s from the asset catalog", GetIconName())
+		return nil
+	}
+
 	// Destination path: Contents/Resources/icon_filename.icns
 	iconPath := filepath.Join(resourcesDir, iconSource)
 
@@ -37,29 +75,32 @@ func CopyIcon() error {
 		var err error
 
 		err = errors.New(fmt.Sprintf("icon filename %s is not defined", iconSource))
-		logger.Debug("failed to open source file:", iconSource, err.Error())
+		logger.Debug("failed to open source file: %s: %s", iconSource, err.Error())
 		return err
 	}
 
-	// If a directory was specified for the icon, construct the full source path
+	// Make sure Contents/Resources/ exists before writing into it
+	if err := ensureBundleDirectory(resourcesDir, "Contents/Resources"); err != nil {
+		return err
+	}
+
+	// Construct the full source path from the icon directory and filename
 	// Example: icon_file_directory: ./test/icon, icon_file: appIcon.icns
 	// Results in: ./test/icon/appIcon.icns
-	if iconDirectory != "" {
-		iconSource = filepath.Join(iconDirectory, iconSource)
-	}
+	iconSource = GetIconFilePath()
 
 	// Open the source icon file for reading
 	sourceFile, err := os.Open(iconSource)
 	if err != nil {
-		logger.Debug("failed to open source file:", iconSource, err.Error())
+		logger.Debug("failed to open source file: %s: %s", iconSource, err.Error())
 		return err
 	}
 	defer sourceFile.Close() // Ensure file is closed when function exits
 
 	// Create the destination icon file in Contents/Resources/
-	destinationFile, err := os.Create(iconPath)
+	destinationFile, err := tracedCreate(iconPath)
 	if err != nil {
-		logger.Debug("failed to open the destination file:", iconPath, err.Error())
+		logger.Debug("failed to open the destination file: %s: %s", iconPath, err.Error())
 		return err
 	}
 	defer destinationFile.Close() // Ensure file is closed when function exits
@@ -68,7 +109,7 @@ func CopyIcon() error {
 	// io.Copy efficiently handles the transfer, even for large files
 	_, err = io.Copy(destinationFile, sourceFile)
 	if err != nil {
-		logger.Debug("failed to copy icon from source to destination file:", iconPath, err.Error())
+		logger.Debug("failed to copy icon from source to destination file: %s: %s", iconPath, err.Error())
 		return err
 	}
 
@@ -76,16 +117,56 @@ func CopyIcon() error {
 	// This ensures the icon file has appropriate read permissions
 	sourceFileInfo, err := sourceFile.Stat()
 	if err != nil {
-		logger.Debug("failed to stat source file:", iconSource, err.Error())
+		logger.Debug("failed to stat source file: %s: %s", iconSource, err.Error())
 		return err
 	}
 
 	// Apply the same permissions to the destination file
-	err = os.Chmod(iconPath, sourceFileInfo.Mode())
+	err = tracedChmod(iconPath, sourceFileInfo.Mode())
 	if err != nil {
-		logger.Debug("failed to set permissions on destination file:", iconPath, err.Error())
+		logger.Debug("failed to set permissions on destination file: %s: %s", iconPath, err.Error())
 		return err
 	}
 
 	return nil
 }
+
+// UseIconFromApp replaces the configured icon with the icon of an existing bundle, e.g. to
+// reuse the icon of a previously built version. The icon is looked up via CFBundleIconFile
+// in the bundle's Info.plist and copied from its Contents/Resources/ by CopyIcon.
+//
+// Parameters:
+//   - appPath: Path to the existing .app bundle
+//
+// Returns an error if:
+//   - The Info.plist of the bundle cannot be read
+//   - The bundle has no icon (no CFBundleIconFile) or the icon file is missing
+func UseIconFromApp(appPath string) error {
+	data, err := ReadPlist(appPath)
+	if err != nil {
+		return err
+	}
+
+	if data.IconFile == "" {
+		return fmt.Errorf("bundle %s has no icon (CFBundleIconFile is not set)", appPath)
+	}
+
+	// CFBundleIconFile may omit the .icns extension
+	iconFile := data.IconFile
+	if filepath.Ext(iconFile) == "" {
+		iconFile += ".icns"
+	}
+
+	iconDirectory, err := filepath.Abs(filepath.Join(appPath, "Contents", "Resources"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(iconDirectory, iconFile)); err != nil {
+		return fmt.Errorf("icon %s of bundle %s not found: %v", iconFile, appPath, err)
+	}
+
+	logger.Info("Using icon %s from %s", iconFile, appPath)
+	packageInfo.IconFileName = iconFile
+	packageInfo.IconFileDirectory = iconDirectory
+	return nil
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAssetCatalogIcon builds the icon of a bundle using an asset catalog: the plist references
+// the icon by CFBundleIconName, Assets.car is copied and no .icns file is needed.
+func TestAssetCatalogIcon(t *testing.T) {
+	assetCatalog := filepath.Join(t.TempDir(), "Assets.car")
+	if err := os.WriteFile(assetCatalog, []byte("compiled assets"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setTestBundle(t, packageParameter{IconName: "AppIcon", AssetCatalog: assetCatalog})
+
+	if err := CopyIcon(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(resourcesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "Assets.car" {
+		t.Errorf("Resources contains %v, want only Assets.car", entries)
+	}
+
+	data := testPlistData()
+	data.IconFile, data.IconName = GetIconFileName(), GetIconName()
+	dictionary := renderTestPlist(t, data)
+	if _, ok := dictionary["CFBundleIconFile"]; ok || dictionary["CFBundleIconName"] != "AppIcon" {
+		t.Errorf("CFBundleIconName = %v, CFBundleIconFile = %v, want only the icon name", dictionary["CFBundleIconName"], dictionary["CFBundleIconFile"])
+	}
+}
+
+// TestNoIcon builds a bundle with -no-icon: the configured icon does not need to exist, nothing
+// is copied into Resources and Info.plist references no icon.
+func TestNoIcon(t *testing.T) {
+	previousSkipIcon := skipIcon
+	t.Cleanup(func() { skipIcon = previousSkipIcon })
+	SetSkipIcon(true)
+
+	if err := readTestConfig(t, testPlistConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfiguration(); err != nil {
+		t.Fatalf("missing icon file rejected with -no-icon: %v", err)
+	}
+
+	setTestBundle(t, packageInfo)
+	if err := CopyIcon(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(resourcesDir); err != nil || len(entries) != 0 {
+		t.Errorf("Resources contains %v (%v), want no icon", entries, err)
+	}
+
+	dictionary := renderTestPlist(t, NewInfoPlistData())
+	for _, key := range []string{"CFBundleIconFile", "CFBundleIconName"} {
+		if value, ok := dictionary[key]; ok {
+			t.Errorf("%s = %v, want no icon key", key, value)
+		}
+	}
+
+	// Without -no-icon, the missing icon is rejected
+	SetSkipIcon(false)
+	if err := ValidateConfiguration(); err == nil || !strings.Contains(err.Error(), "icon file not found") {
+		t.Errorf("error = %v, want it to contain %q", err, "icon file not found")
+	}
+}
+
+// TestUseIconFromApp builds a bundle reusing the icon of a fixture bundle: the icon named by
+// CFBundleIconFile is copied into the new bundle instead of the configured one.
+func TestUseIconFromApp(t *testing.T) {
+	tests := []struct {
+		name     string
+		iconFile string
+		files    []string
+		wantIcon string
+		wantErr  string
+	}{
+		{"icon file", "OldIcon.icns", []string{"OldIcon.icns"}, "OldIcon.icns", ""},
+		{"icon without extension", "OldIcon", []string{"OldIcon.icns"}, "OldIcon.icns", ""},
+		{"no icon", "", nil, "", "has no icon"},
+		{"missing icon file", "OldIcon.icns", nil, "", "icon OldIcon.icns of bundle"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sourceApp := filepath.Join(t.TempDir(), "Old.app")
+			data := testPlistData()
+			data.IconFile, data.SkipIcon = test.iconFile, test.iconFile == ""
+			content, err := RenderPlist(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeBundleFile(t, sourceApp, "Contents/Info.plist", content)
+			for _, name := range test.files {
+				writeBundleFile(t, sourceApp, "Contents/Resources/"+name, "old icon")
+			}
+			setTestBundle(t, packageParameter{IconFileName: "MyApp.icns", IconFileDirectory: t.TempDir()})
+
+			err = UseIconFromApp(sourceApp)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := CopyIcon(); err != nil {
+				t.Fatal(err)
+			}
+			if got := readBundleFile(t, resourcesDir, test.wantIcon); got != "old icon" {
+				t.Errorf("%s = %q, want the icon of the source bundle", test.wantIcon, got)
+			}
+			if GetIconFileName() != test.wantIcon {
+				t.Errorf("icon file = %s, want %s", GetIconFileName(), test.wantIcon)
+			}
+		})
+	}
+}
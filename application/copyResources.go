@@ -0,0 +1,63 @@
+// Package application: This file handles copying additional resource files into the bundle.
+// Besides the icon, applications often need extra assets (sample files, data directories).
+// These are listed in the "resources" configuration field and copied into Contents/Resources/.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"os"
+	"path/filepath"
+)
+
+// CopyResources copies every entry of the "resources" configuration list, and the help
+// book folder if configured, into Contents/Resources/. Files are copied under their base
+// name; directories are copied recursively with their subdirectory structure preserved.
+//
+// Example: resources: [./assets/readme.txt, ./samples]
+// Results in: Contents/Resources/readme.txt and Contents/Resources/samples/...
+//
+// Returns an error if a source cannot be read or a copy operation fails.
+func CopyResources() error {
+	resources := GetResources()
+	if helpBookFolder := GetHelpBookFolder(); helpBookFolder != "" {
+		resources = append(resources, helpBookFolder)
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	logger.Info("Copying the Resource Files")
+
+	// Make sure Contents/Resources/ exists before writing into it
+	if err := ensureBundleDirectory(resourcesDir, "Contents/Resources"); err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		destination := filepath.Join(resourcesDir, filepath.Base(resource))
+
+		sourceInfo, err := os.Stat(resource)
+		if err != nil {
+			logger.Debug("failed to stat resource: %s: %s", resource, err.Error())
+			return err
+		}
+
+		if sourceInfo.IsDir() {
+			// Copy the directory with its complete structure
+			err = createDir(destination)
+			if err == nil {
+				err = fileManagement.CopyDirectory(resource, destination)
+			}
+		} else {
+			err = fileManagement.Copy(resource, destination)
+		}
+
+		if err != nil {
+			logger.Debug("failed to copy resource: %s: %s", resource, err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
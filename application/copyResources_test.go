@@ -0,0 +1,46 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCopyResources copies a single file and a directory with subdirectories into
+// Contents/Resources.
+func TestCopyResources(t *testing.T) {
+	source := t.TempDir()
+	writeBundleFile(t, source, "README.md", "readme")
+	writeBundleFile(t, source, "data/config.json", "{}")
+	writeBundleFile(t, source, "data/templates/letter.txt", "letter")
+
+	setTestBundle(t, packageParameter{Resources: []string{
+		filepath.Join(source, "README.md"),
+		filepath.Join(source, "data"),
+	}})
+
+	if err := CopyResources(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"Contents/Resources/README.md":                 "readme",
+		"Contents/Resources/data/config.json":          "{}",
+		"Contents/Resources/data/templates/letter.txt": "letter",
+	} {
+		if got := readBundleFile(t, GetApplicationDirectory(), name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMissingResourceRejected(t *testing.T) {
+	if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\nresources:\n  - /nonexistent/README.md\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ValidateConfiguration()
+	if err == nil || !strings.Contains(err.Error(), "resource not found: /nonexistent/README.md") {
+		t.Errorf("error = %v, want it to contain %q", err, "resource not found: /nonexistent/README.md")
+	}
+}
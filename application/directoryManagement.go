@@ -12,21 +12,69 @@ package application
 import (
 	"appbundler/utilities/logger"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // Package-level variables storing paths to key directories in the bundle.
 // These are set by CreateDirectoryStructure() and used by other functions.
 var (
-	applicationDirectory string // Root of the bundle: MyApp.app
-	contentsDir         string // Contents/ directory (required by macOS)
-	macosDir            string // Contents/MacOS/ (executables go here)
-	resourcesDir        string // Contents/Resources/ (icons, assets)
-	javaDir             string // Contents/Java/ (for bundled Java runtime)
-	runtimeDir          string // Contents/Java/runtime/ (actual Java installation)
+	applicationDirectory string // Root of the bundle while building: MyApp.app.tmp-<pid>, MyApp.app after FinalizeBundle
+	finalBundleDirectory string // Final location of the bundle: MyApp.app
+	contentsDir          string // Contents/ directory (required by macOS)
+	macosDir             string // Contents/MacOS/ (executables go here)
+	resourcesDir         string // Contents/Resources/ (icons, assets)
+	javaDir              string // Contents/Java/ (for bundled Java runtime)
+	runtimeDir           string // Contents/Java/runtime/ (actual Java installation)
 )
 
+// bundleMutex serializes the changes of the bundle paths with the removal of the incomplete
+// bundle, which the signal handler of main.go runs in its own goroutine while the build continues.
+var bundleMutex sync.Mutex
+
+// Supported layouts for the bundled Java runtime (runtime_layout configuration field)
+const (
+	runtimeLayoutJava     = "java"     // Contents/Java/runtime (default)
+	runtimeLayoutJPackage = "jpackage" // Contents/runtime (as created by jpackage)
+)
+
+// Supported ways to place the Java runtime into the bundle (java_runtime_mode configuration field)
+const (
+	javaRuntimeModeCopy    = "copy"    // Copy of the Java installation (default)
+	javaRuntimeModeSymlink = "symlink" // Symbolic link to the Java installation (local development builds only)
+)
+
+// runtimeRelativePath returns the location of the bundled Java runtime relative to Contents/,
+// depending on the configured runtime layout.
+func runtimeRelativePath() string {
+	if GetRuntimeLayout() == runtimeLayoutJPackage {
+		return "runtime"
+	}
+
+	return filepath.Join("Java", "runtime")
+}
+
+// ValidateOutputName checks that a name can be used as the .app directory name.
+// The name is used as is in the current directory, so it must not contain path separators.
+//
+// Parameters:
+//   - outputName: Name of the .app directory (without .app extension)
+//
+// Returns an error if the name is empty or contains a path separator.
+func ValidateOutputName(outputName string) error {
+	if strings.TrimSpace(outputName) == "" {
+		return errors.New("output name cannot be empty")
+	}
+	if strings.ContainsAny(outputName, `/\`) || outputName == "." || outputName == ".." {
+		return fmt.Errorf("invalid output name %q: must not contain path separators", outputName)
+	}
+
+	return nil
+}
+
 // CreateDirectoryStructure creates the complete directory hierarchy for a macOS application bundle.
 // This function builds the required structure that macOS expects for .app bundles.
 //
@@ -35,9 +83,12 @@ var (
 //     Contents/
 //       MacOS/          (executables)
 //       Resources/      (icons, assets)
-//       Java/           (optional, for Java apps)
+//
+//       Java/           (only for Java applications, see bundlesJava)
 //         runtime/      (bundled Java installation)
 //
+// With runtime_layout "jpackage" the runtime is placed in Contents/runtime/ instead.
+//
 // Parameters:
 //   - applicationRoot: Base name of the application (without .app extension)
 //
@@ -45,86 +96,258 @@ var (
 //   - applicationRoot is empty
 //   - Any directory creation fails
 //
+// The bundle is built in a temporary directory next to the final one (MyApp.app.tmp-<pid>)
+// and only moved to MyApp.app by FinalizeBundle once all build steps succeeded, so a failed
+// build never leaves a broken bundle behind.
+//
 // Note: If any directory creation fails, the function attempts to clean up
 // by deleting the partially created bundle.
 func CreateDirectoryStructure(applicationRoot string) error {
 	logger.Info("Creating and setting up the bundle directories")
-	
+
+	bundleMutex.Lock()
+	defer bundleMutex.Unlock()
+
 	// Validate that application root name is provided
 	// All macOS application bundles must have a .app extension
 	if applicationRoot != "" {
-		// Build the complete directory paths
-		applicationDirectory = applicationRoot + ".app"                    // MyApp.app
-		contentsDir = filepath.Join(applicationDirectory, "Contents")    // MyApp.app/Contents
-		macosDir = filepath.Join(contentsDir, "MacOS")                   // MyApp.app/Contents/MacOS
-		resourcesDir = filepath.Join(contentsDir, "Resources")            // MyApp.app/Contents/Resources
-		javaDir = filepath.Join(contentsDir, "Java")                     // MyApp.app/Contents/Java
-		runtimeDir = filepath.Join(javaDir, "runtime")                    // MyApp.app/Contents/Java/runtime
+		// Build the complete directory paths, starting in the temporary build directory
+		finalBundleDirectory = applicationRoot + ".app"                      // MyApp.app
+		setBundleDirectories(temporaryBundleDirectory(finalBundleDirectory)) // MyApp.app.tmp-<pid>
 	} else {
 		applicationError := errors.New("Application root directory cannot be empty")
 		return applicationError
 	}
 
-	// Create each directory in the hierarchy
+	failedBundleReported = false
+
+	// Remove leftovers of a previous run with the same process ID
+	if err := tracedRemoveAll(applicationDirectory); err != nil {
+		return err
+	}
+
+	// Create each directory in the hierarchy, parents first
 	// If any creation fails, clean up and return the error
 	// This ensures we don't leave partial bundles on disk
+	for _, directory := range bundleDirectories() {
+		if creationError := createDir(directory); creationError != nil {
+			discardFailedBundle()
+			return creationError
+		}
+	}
+
+	return nil
+}
 
-	creationError := createDir(applicationDirectory)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+// bundleDirectories returns the directories of the bundle in creation order (parents first).
+// The Java runtime directories are only part of Java bundles (see bundlesJava), so compiled
+// binaries and scripts don't get empty Java/runtime directories.
+func bundleDirectories() []string {
+	directories := []string{applicationDirectory, contentsDir, macosDir, resourcesDir}
+
+	if bundlesJava() {
+		// The Java/ directory is only part of the default runtime layout
+		if GetRuntimeLayout() == runtimeLayoutJava {
+			directories = append(directories, javaDir)
+		}
+		directories = append(directories, runtimeDir)
 	}
 
-	creationError = createDir(contentsDir)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+	return directories
+}
+
+// bundlesJava reports whether the bundle is a Java application: a local Java runtime is
+// bundled (local_java), or the executable is a JAR file (see isJarExecutable).
+func bundlesJava() bool {
+	if GetUseLocalJava() {
+		return true
 	}
+	if GetGoPackage() != "" {
+		return false
+	}
+
+	isJar, _ := classifyJarExecutable(GetExecutablePath())
+	return isJar
+}
 
-	creationError = createDir(macosDir)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+// setBundleDirectories sets the paths of all bundle directories below the given bundle root.
+func setBundleDirectories(bundleDirectory string) {
+	applicationDirectory = bundleDirectory                         // MyApp.app
+	contentsDir = filepath.Join(applicationDirectory, "Contents")  // MyApp.app/Contents
+	macosDir = filepath.Join(contentsDir, "MacOS")                 // MyApp.app/Contents/MacOS
+	resourcesDir = filepath.Join(contentsDir, "Resources")         // MyApp.app/Contents/Resources
+	javaDir = filepath.Join(contentsDir, "Java")                   // MyApp.app/Contents/Java
+	runtimeDir = filepath.Join(contentsDir, runtimeRelativePath()) // MyApp.app/Contents/Java/runtime or Contents/runtime
+}
+
+// keepFailedBundle keeps the incomplete bundle of a failed build (set via SetKeepFailedBundle);
+// failedBundleReported avoids logging its location more than once
+var (
+	keepFailedBundle     bool
+	failedBundleReported bool
+)
+
+// SetKeepFailedBundle disables the removal of the incomplete bundle when a build step fails
+// or the build is interrupted (-no-clean-on-error), so the broken bundle remains for debugging.
+//
+// Parameters:
+//   - keep: true to keep the bundle of a failed build
+func SetKeepFailedBundle(keep bool) {
+	bundleMutex.Lock()
+	defer bundleMutex.Unlock()
+
+	keepFailedBundle = keep
+}
+
+// temporaryBundleDirectory returns the directory the bundle is built in before it is
+// moved to its final location. The process ID keeps parallel builds apart.
+func temporaryBundleDirectory(finalDirectory string) string {
+	return fmt.Sprintf("%s.tmp-%d", finalDirectory, os.Getpid())
+}
+
+// FinalizeBundle moves the completely built bundle from its temporary directory to the final
+// <name>.app location, replacing an existing bundle. The existing bundle is first moved aside
+// and only removed once the new bundle is in place; if the move fails, it is restored.
+// Afterwards all bundle paths point to the final location.
+//
+// Returns an error if the directory structure was not created or a rename fails. If the
+// previous bundle cannot be restored either, the error names the directory it remains in.
+func FinalizeBundle() error {
+	bundleMutex.Lock()
+	defer bundleMutex.Unlock()
+
+	if finalBundleDirectory == "" {
+		return errors.New("bundle directory is not set up, the directory structure must be created first")
 	}
+	if applicationDirectory == finalBundleDirectory {
+		return nil // already finalized
+	}
+
+	logger.Info("Moving the bundle to %s", finalBundleDirectory)
 
-	creationError = createDir(resourcesDir)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+	// Move an existing bundle aside, so the new one can take its place
+	backupDirectory := ""
+	if _, err := os.Lstat(finalBundleDirectory); err == nil {
+		backupDirectory = fmt.Sprintf("%s.old-%d", finalBundleDirectory, os.Getpid())
+		if err := tracedRename(finalBundleDirectory, backupDirectory); err != nil {
+			return fmt.Errorf("failed to replace existing bundle %s: %v", finalBundleDirectory, err)
+		}
 	}
 
-	creationError = createDir(javaDir)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+	if err := tracedRename(applicationDirectory, finalBundleDirectory); err != nil {
+		if backupDirectory != "" {
+			if restoreErr := tracedRename(backupDirectory, finalBundleDirectory); restoreErr != nil {
+				logger.Warn("Failed to restore previous bundle, it remains at %s: %v", backupDirectory, restoreErr)
+				return fmt.Errorf("failed to move bundle to %s: %v (previous bundle could not be restored and remains at %s: %v)",
+					finalBundleDirectory, err, backupDirectory, restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to move bundle to %s: %v", finalBundleDirectory, err)
 	}
 
-	creationError = createDir(runtimeDir)
-	if creationError != nil {
-		DeleteAll()
-		return creationError
+	if backupDirectory != "" {
+		if err := tracedRemoveAll(backupDirectory); err != nil {
+			logger.Warn("Failed to remove previous bundle %s: %v", backupDirectory, err)
+		}
 	}
+
+	// Point all bundle paths to the final location
+	setBundleDirectories(finalBundleDirectory)
 	return nil
 }
 
+// GetApplicationDirectory returns the current path of the bundle: the temporary build
+// directory before FinalizeBundle, and the final <name>.app afterwards.
+func GetApplicationDirectory() string {
+	return applicationDirectory
+}
+
+// DiscardIncompleteBundle removes the temporary build directory if the bundle was not
+// finalized (e.g. because a build step failed). A finalized bundle is never touched.
+func DiscardIncompleteBundle() {
+	bundleMutex.Lock()
+	defer bundleMutex.Unlock()
+
+	discardIncompleteBundle()
+}
+
+// discardIncompleteBundle implements DiscardIncompleteBundle, the caller holds bundleMutex.
+func discardIncompleteBundle() {
+	if applicationDirectory == "" || applicationDirectory == finalBundleDirectory {
+		return
+	}
+
+	logger.Debug("Removing incomplete bundle: %s", applicationDirectory)
+	if err := tracedRemoveAll(applicationDirectory); err != nil {
+		logger.Warn("Failed to remove incomplete bundle %s: %v", applicationDirectory, err)
+	}
+}
+
+// DiscardFailedBundle removes the incomplete bundle after a failed build step, like
+// DiscardIncompleteBundle. With -no-clean-on-error (SetKeepFailedBundle) the bundle is kept
+// for inspection instead and its location is logged.
+//
+// It is safe to call while the build is running in another goroutine (signal handler).
+func DiscardFailedBundle() {
+	bundleMutex.Lock()
+	defer bundleMutex.Unlock()
+
+	discardFailedBundle()
+}
+
+// discardFailedBundle implements DiscardFailedBundle, the caller holds bundleMutex.
+func discardFailedBundle() {
+	if !keepFailedBundle {
+		discardIncompleteBundle()
+		return
+	}
+	if applicationDirectory == "" || applicationDirectory == finalBundleDirectory || failedBundleReported {
+		return
+	}
+
+	failedBundleReported = true
+	logger.Warn("Keeping the incomplete bundle %s for inspection (-no-clean-on-error)", applicationDirectory)
+}
+
 // createDir creates a directory and all necessary parent directories.
 // Uses os.MkdirAll which is idempotent - it won't fail if the directory already exists.
+// The directory gets the mode of directory_mode (default 0755 = rwxr-xr-x); it is set
+// explicitly, so the umask does not remove bits from it.
 //
 // Parameters:
 //   - path: Full path of the directory to create
 //
 // Returns an error if directory creation fails.
 func createDir(path string) error {
-	// 0755 = rwxr-xr-x permissions:
-	// - Owner: read, write, execute
-	// - Group: read, execute
-	// - Others: read, execute
-	err := os.MkdirAll(path, 0755)
+	mode := GetDirectoryMode()
+
+	err := tracedMkdirAll(path, mode)
+	if err == nil {
+		err = tracedChmod(path, mode)
+	}
 	if err != nil {
-		logger.Debug("Error creating directory:", path, err)
+		return fmt.Errorf("failed to create directory %s: %v", path, err)
 	}
 
-	return err
+	return nil
+}
+
+// ensureBundleDirectory makes sure a bundle directory is set up before files are copied into it.
+// If CreateDirectoryStructure was skipped, the directory path is empty and copying would write
+// to a path relative to the working directory, so a clear error is returned instead.
+// If the path is set but the directory is missing (e.g. it was removed), it is recreated.
+//
+// Parameters:
+//   - directory: The bundle directory (e.g. resourcesDir or macosDir)
+//   - name: Name of the directory used in the error message (e.g. "Contents/Resources")
+//
+// Returns an error if the directory is not set up or cannot be created.
+func ensureBundleDirectory(directory string, name string) error {
+	if directory == "" {
+		return fmt.Errorf("bundle directory %s is not set up, the directory structure must be created first", name)
+	}
+
+	return createDir(directory)
 }
 
 // DeleteAll removes the entire application bundle directory structure.
@@ -135,10 +358,69 @@ func DeleteAll() error {
 	logger.Info("Delete all bundle directories")
 	
 	// os.RemoveAll recursively deletes the directory and all its contents
-	err := os.RemoveAll(applicationDirectory)
+	err := tracedRemoveAll(applicationDirectory)
 	if err != nil {
-		logger.Debug("Error deleting directory:", applicationDirectory, err)
+		logger.Debug("Error deleting directory: %s: %s", applicationDirectory, err)
 	}
 
 	return err
 }
+
+// CleanBundle removes the bundle <appName>.app of a previous build (-clean). Unlike DeleteAll,
+// it does not depend on the current bundle paths, which still point to the previous bundle of
+// a batch run until CreateDirectoryStructure sets them up for the next one.
+//
+// Parameters:
+//   - appName: Base name of the application (without .app extension)
+//
+// Returns an error if appName is empty or contains a path, or if the deletion fails.
+func CleanBundle(appName string) error {
+	if appName == "" || appName != filepath.Base(appName) {
+		return errors.New("bundle cleanup requires a plain application name")
+	}
+
+	bundleDirectory := appName + ".app"
+	if _, err := os.Lstat(bundleDirectory); os.IsNotExist(err) {
+		return nil
+	}
+
+	logger.Info("Delete previous bundle %s", bundleDirectory)
+	if err := tracedRemoveAll(bundleDirectory); err != nil {
+		return fmt.Errorf("failed to delete previous bundle %s: %v", bundleDirectory, err)
+	}
+
+	return nil
+}
+
+// artifactSuffixes lists the distribution artifacts and temporary files created next to the
+// bundle. Only files named <appName><suffix> are removed by CleanArtifacts.
+var artifactSuffixes = []string{".zip", ".dmg", ".pkg", ".iconset"}
+
+// CleanArtifacts removes the distribution artifacts (zip, dmg, pkg) and temporary iconsets
+// created for the given bundle name. Files belonging to other bundles are never touched,
+// as only the exact names <appName>.zip, <appName>.dmg, <appName>.pkg and <appName>.iconset are removed.
+//
+// Parameters:
+//   - appName: Base name of the application (without .app extension)
+//
+// Returns an error if appName is empty or contains a path, or if a deletion fails.
+func CleanArtifacts(appName string) error {
+	if appName == "" || appName != filepath.Base(appName) {
+		return errors.New("artifact cleanup requires a plain application name")
+	}
+
+	for _, suffix := range artifactSuffixes {
+		artifact := appName + suffix
+		if _, err := os.Lstat(artifact); os.IsNotExist(err) {
+			continue
+		}
+
+		logger.Info("Delete artifact %s", artifact)
+		if err := tracedRemoveAll(artifact); err != nil {
+			logger.Debug("Error deleting artifact: %s: %s", artifact, err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,391 @@
+package application
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// setTestBundle creates the directory structure of a bundle named MyApp in a temporary
+// directory and restores the bundle paths after the test. Returns the final bundle path.
+func setTestBundle(t *testing.T, parameter packageParameter) string {
+	t.Helper()
+	setTestPackageInfo(t, parameter)
+
+	previousApplication, previousFinal := applicationDirectory, finalBundleDirectory
+	previousContents, previousMacOS, previousResources := contentsDir, macosDir, resourcesDir
+	previousJava, previousRuntime := javaDir, runtimeDir
+	previousKeep, previousReported := keepFailedBundle, failedBundleReported
+	t.Cleanup(func() {
+		applicationDirectory, finalBundleDirectory = previousApplication, previousFinal
+		contentsDir, macosDir, resourcesDir = previousContents, previousMacOS, previousResources
+		javaDir, runtimeDir = previousJava, previousRuntime
+		keepFailedBundle, failedBundleReported = previousKeep, previousReported
+	})
+
+	applicationRoot := filepath.Join(t.TempDir(), "MyApp")
+	if err := CreateDirectoryStructure(applicationRoot); err != nil {
+		t.Fatal(err)
+	}
+	return applicationRoot + ".app"
+}
+
+// writeBundleFile writes a file below a bundle directory.
+func writeBundleFile(t *testing.T, bundleDirectory string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(bundleDirectory, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readBundleFile returns the content of a file below a bundle directory.
+func readBundleFile(t *testing.T, bundleDirectory string, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(bundleDirectory, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// bundleSiblings returns the names of the entries next to the bundle.
+func bundleSiblings(t *testing.T, finalBundle string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(finalBundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// TestFailedBuildKeepsPreviousBundle fails a build after the executable was copied: the
+// previous bundle must remain untouched and no partial bundle may be left behind.
+func TestFailedBuildKeepsPreviousBundle(t *testing.T) {
+	finalBundle := setTestBundle(t, packageParameter{})
+	writeBundleFile(t, finalBundle, "Contents/Info.plist", "previous build")
+
+	writeBundleFile(t, GetApplicationDirectory(), "Contents/MacOS/MyApp", "#!/bin/sh\n")
+	DiscardFailedBundle()
+
+	if got := readBundleFile(t, finalBundle, "Contents/Info.plist"); got != "previous build" {
+		t.Errorf("previous bundle Info.plist = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(finalBundle, "Contents", "MacOS", "MyApp")); !os.IsNotExist(err) {
+		t.Errorf("partial build reached the final bundle: %v", err)
+	}
+	if got := bundleSiblings(t, finalBundle); len(got) != 1 || got[0] != "MyApp.app" {
+		t.Errorf("directory contains %q, want only MyApp.app", got)
+	}
+}
+
+func TestFinalizeBundle(t *testing.T) {
+	t.Run("replaces the previous bundle", func(t *testing.T) {
+		finalBundle := setTestBundle(t, packageParameter{})
+		writeBundleFile(t, finalBundle, "Contents/Info.plist", "previous build")
+		writeBundleFile(t, GetApplicationDirectory(), "Contents/Info.plist", "new build")
+
+		if err := FinalizeBundle(); err != nil {
+			t.Fatal(err)
+		}
+		if GetApplicationDirectory() != finalBundle {
+			t.Errorf("application directory = %s, want %s", GetApplicationDirectory(), finalBundle)
+		}
+		if got := readBundleFile(t, finalBundle, "Contents/Info.plist"); got != "new build" {
+			t.Errorf("Info.plist = %q, want the new build", got)
+		}
+		if got := bundleSiblings(t, finalBundle); len(got) != 1 {
+			t.Errorf("directory contains %q, want only MyApp.app", got)
+		}
+	})
+
+	t.Run("restores the previous bundle", func(t *testing.T) {
+		finalBundle := setTestBundle(t, packageParameter{})
+		writeBundleFile(t, finalBundle, "Contents/Info.plist", "previous build")
+
+		// The new bundle disappeared, so it cannot be moved into place
+		if err := os.RemoveAll(GetApplicationDirectory()); err != nil {
+			t.Fatal(err)
+		}
+		if err := FinalizeBundle(); err == nil {
+			t.Fatal("FinalizeBundle() succeeded without a bundle")
+		}
+		if got := readBundleFile(t, finalBundle, "Contents/Info.plist"); got != "previous build" {
+			t.Errorf("previous bundle Info.plist = %q", got)
+		}
+		if got := bundleSiblings(t, finalBundle); len(got) != 1 {
+			t.Errorf("directory contains %q, want only MyApp.app", got)
+		}
+	})
+}
+
+// writeTestExecutable writes an executable with the given content into a temporary directory
+// and returns the directory.
+func writeTestExecutable(t *testing.T, name string, content string) string {
+	t.Helper()
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return directory
+}
+
+// Start of the test executables: a zip archive (JAR) and a 64-bit Mach-O binary
+const (
+	testJarContent   = "PK\x03\x04 jar content"
+	testMachOContent = "\xcf\xfa\xed\xfe binary content"
+)
+
+// TestJavaDirectories checks that only Java applications get the Java runtime directories.
+func TestJavaDirectories(t *testing.T) {
+	binaryDirectory := writeTestExecutable(t, "MyApp", testMachOContent)
+	jarDirectory := writeTestExecutable(t, "MyApp", testJarContent)
+	scriptDirectory := writeTestExecutable(t, "MyApp", "#!/bin/sh\necho MyApp\n")
+
+	tests := []struct {
+		name        string
+		parameter   packageParameter
+		wantPresent []string
+		wantAbsent  []string
+	}{
+		{
+			name:       "compiled binary",
+			parameter:  packageParameter{ExecFileName: "MyApp", ExecFileDirectory: binaryDirectory},
+			wantAbsent: []string{"Contents/Java", "Contents/runtime"},
+		},
+		{
+			name:       "script",
+			parameter:  packageParameter{ExecFileName: "MyApp", ExecFileDirectory: scriptDirectory},
+			wantAbsent: []string{"Contents/Java", "Contents/runtime"},
+		},
+		{
+			name:       "go package",
+			parameter:  packageParameter{GoPackage: "./cmd/myapp"},
+			wantAbsent: []string{"Contents/Java", "Contents/runtime"},
+		},
+		{
+			name:        "JAR without suffix",
+			parameter:   packageParameter{ExecFileName: "MyApp", ExecFileDirectory: jarDirectory},
+			wantPresent: []string{"Contents/Java/runtime"},
+		},
+		{
+			name:        "local Java",
+			parameter:   packageParameter{ExecFileName: "MyApp", ExecFileDirectory: binaryDirectory, LocalJava: "true"},
+			wantPresent: []string{"Contents/Java/runtime"},
+		},
+		{
+			name:        "JAR with jpackage layout",
+			parameter:   packageParameter{ExecFileName: "MyApp", ExecFileDirectory: jarDirectory, RuntimeLayout: runtimeLayoutJPackage},
+			wantPresent: []string{"Contents/runtime"},
+			wantAbsent:  []string{"Contents/Java"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, test.parameter)
+
+			for _, name := range test.wantPresent {
+				if info, err := os.Stat(filepath.Join(GetApplicationDirectory(), filepath.FromSlash(name))); err != nil || !info.IsDir() {
+					t.Errorf("%s missing: %v", name, err)
+				}
+			}
+			for _, name := range test.wantAbsent {
+				if _, err := os.Stat(filepath.Join(GetApplicationDirectory(), filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Errorf("%s exists", name)
+				}
+			}
+		})
+	}
+}
+
+// TestKeepFailedBundle fails a build after the executable was copied, with and without
+// -no-clean-on-error: the incomplete bundle is only kept for inspection with the flag, and
+// never replaces the final bundle.
+func TestKeepFailedBundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		keep     bool
+		failStep func() error
+	}{
+		// CopyIcon fails, as the configured icon does not exist
+		{"failed step", false, CopyIcon},
+		{"failed step with -no-clean-on-error", true, CopyIcon},
+		{"cleanup after error", false, func() error { return cleanAfterError(errors.New("write failed")) }},
+		{"cleanup after error with -no-clean-on-error", true, func() error { return cleanAfterError(errors.New("write failed")) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			finalBundle := setTestBundle(t, packageParameter{IconFileName: "missing.icns", IconFileDirectory: t.TempDir()})
+			SetKeepFailedBundle(test.keep)
+			incompleteBundle := GetApplicationDirectory()
+			writeBundleFile(t, incompleteBundle, "Contents/MacOS/MyApp", "#!/bin/sh\n")
+
+			if err := test.failStep(); err == nil {
+				t.Fatal("build step succeeded")
+			}
+			// The build deferred the cleanup, so it runs once the step failed
+			DiscardFailedBundle()
+
+			_, err := os.Stat(filepath.Join(incompleteBundle, "Contents", "MacOS", "MyApp"))
+			if test.keep && err != nil {
+				t.Errorf("incomplete bundle was removed: %v", err)
+			}
+			if !test.keep && !os.IsNotExist(err) {
+				t.Errorf("incomplete bundle was kept: %v", err)
+			}
+			if _, err := os.Stat(finalBundle); !os.IsNotExist(err) {
+				t.Errorf("failed build reached the final bundle: %v", err)
+			}
+		})
+	}
+}
+
+// setTestWorkingDirectory changes into a new temporary directory for the duration of a test
+// and returns it.
+func setTestWorkingDirectory(t *testing.T) string {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	directory := t.TempDir()
+	if err := os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+	return directory
+}
+
+// TestCleanArtifacts creates the artifacts of two bundles and checks that only those of the
+// cleaned bundle are removed.
+func TestCleanArtifacts(t *testing.T) {
+	directory := setTestWorkingDirectory(t)
+	for _, name := range []string{"MyApp.zip", "MyApp.dmg", "MyApp.pkg", "MyApp.iconset/icon_16x16.png",
+		"MyApp.app/Contents/Info.plist", "MyApp.txt", "MyApp2.zip", "Other.dmg", "MyApp-1.0.zip"} {
+		writeBundleFile(t, directory, name, "artifact")
+	}
+
+	if err := CleanArtifacts("MyApp"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"MyApp-1.0.zip", "MyApp.app", "MyApp.txt", "MyApp2.zip", "Other.dmg"}
+	if got := bundleSiblings(t, filepath.Join(directory, "MyApp.app")); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining files = %q, want %q", got, want)
+	}
+}
+
+func TestCleanArtifactsRequiresName(t *testing.T) {
+	for _, appName := range []string{"", "../MyApp", "build/MyApp"} {
+		err := CleanArtifacts(appName)
+		if err == nil || !strings.Contains(err.Error(), "requires a plain application name") {
+			t.Errorf("CleanArtifacts(%q) error = %v, want a plain name to be required", appName, err)
+		}
+	}
+}
+
+// TestCopyWithoutDirectoryStructure runs copy steps whose bundle directory was not set up or
+// was removed after the setup.
+func TestCopyWithoutDirectoryStructure(t *testing.T) {
+	iconDirectory := writeTestExecutable(t, "MyApp.icns", "icon")
+	executableDirectory := writeTestExecutable(t, "MyApp", testMachOContent)
+	parameter := packageParameter{
+		IconFileName: "MyApp.icns", IconFileDirectory: iconDirectory,
+		ExecFileName: "MyApp", ExecFileDirectory: executableDirectory,
+	}
+
+	tests := []struct {
+		name     string
+		copyStep func() error
+		clear    func()
+		file     string
+		wantErr  string
+	}{
+		{"icon without setup", CopyIcon, func() { resourcesDir = "" }, "", "bundle directory Contents/Resources is not set up"},
+		{"executable without setup", CopyExecutable, func() { macosDir = "" }, "", "bundle directory Contents/MacOS is not set up"},
+		{"icon after removal", CopyIcon, func() { os.RemoveAll(resourcesDir) }, "Contents/Resources/MyApp.icns", ""},
+		{"executable after removal", CopyExecutable, func() { os.RemoveAll(macosDir) }, "Contents/MacOS/MyApp", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, parameter)
+			test.clear()
+
+			err := test.copyStep()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			readBundleFile(t, GetApplicationDirectory(), test.file)
+		})
+	}
+}
+
+// TestDirectoryMode creates the bundle with and without directory_mode under a restrictive
+// umask: every bundle directory gets exactly the configured mode, invalid modes are rejected.
+func TestDirectoryMode(t *testing.T) {
+	previousUmask := syscall.Umask(0077)
+	t.Cleanup(func() { syscall.Umask(previousUmask) })
+
+	tests := []struct {
+		name    string
+		config  string
+		want    os.FileMode
+		wantErr string
+	}{
+		{name: "default", want: 0755},
+		{name: "private", config: "directory_mode: \"0700\"\n", want: 0700},
+		{name: "group readable", config: "directory_mode: \"750\"\n", want: 0750},
+		{name: "not octal", config: "directory_mode: rwx------\n", wantErr: "invalid directory_mode"},
+		{name: "owner cannot write", config: "directory_mode: \"0555\"\n", wantErr: "the owner needs read, write and execute permission"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\n"+test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			setTestBundle(t, packageInfo)
+			for _, directory := range bundleDirectories() {
+				info, err := os.Stat(directory)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != test.want {
+					t.Errorf("%s has mode %v, want %v", directory, got, test.want)
+				}
+			}
+		})
+	}
+}
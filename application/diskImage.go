@@ -0,0 +1,210 @@
+// Package application: This file creates a stapled disk image (.dmg) of the bundle (-staple-dmg).
+// A ticket stapled to the bundle only travels with the bundle; a disk image that is notarized
+// and stapled itself lets Gatekeeper verify it offline when it is opened. The order matters:
+// the bundle is notarized and stapled first, then the disk image is created from it, signed,
+// notarized and finally stapled, so every artifact carries its own ticket.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// volumesDirectory is the directory macOS mounts disk images in
+const volumesDirectory = "/Volumes"
+
+// hdiutilCreateArguments returns the hdiutil arguments creating a compressed disk image of
+// the bundle:
+//
+//	-volname: Name of the mounted volume
+//	-srcfolder: Content of the disk image (the bundle)
+//	-ov: Overwrite an existing disk image
+//	-format UDZO: Compressed read-only image, the usual format for distribution
+func hdiutilCreateArguments(appPath string, imagePath string, volumeName string) []string {
+	return []string{"create", "-volname", volumeName, "-srcfolder", appPath, "-ov", "-format", "UDZO", imagePath}
+}
+
+// parseMountPoints returns the mount points of the attached disk images listed by
+// "hdiutil info -plist".
+func parseMountPoints(output string) ([]string, error) {
+	root, err := decodePlist([]byte(output))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hdiutil info: %v", err)
+	}
+
+	info, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse hdiutil info: expected a dictionary")
+	}
+
+	var mountPoints []string
+	for _, element := range plistArray(info, "images") {
+		image, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, entityElement := range plistArray(image, "system-entities") {
+			entity, ok := entityElement.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if mountPoint := plistString(entity, "mount-point"); mountPoint != "" {
+				mountPoints = append(mountPoints, mountPoint)
+			}
+		}
+	}
+
+	return mountPoints, nil
+}
+
+// isVolumeMounted reports whether a disk image is attached with a volume of the given name.
+func isVolumeMounted(hdiutilPath string, volumeName string) (bool, error) {
+	stdout, stderr, err := runQuery(hdiutilPath, "info", "-plist")
+	if err != nil {
+		return false, fmt.Errorf("failed to list attached disk images: %v\n%s", err, stderr)
+	}
+
+	mountPoints, err := parseMountPoints(stdout)
+	if err != nil {
+		return false, err
+	}
+
+	mountPoint := filepath.Join(volumesDirectory, volumeName)
+	for _, candidate := range mountPoints {
+		if candidate == mountPoint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// detachVolume detaches the disk image mounted with the given volume name.
+func detachVolume(hdiutilPath string, volumeName string) error {
+	mountPoint := filepath.Join(volumesDirectory, volumeName)
+	_, stderr, err := runCommand(hdiutilPath, "detach", mountPoint)
+	if err != nil {
+		return fmt.Errorf("failed to detach %q: %v\n%s", mountPoint, err, stderr)
+	}
+	return nil
+}
+
+// resolveVolumeName returns the volume name of the disk image. hdiutil mounts the new image
+// while creating it and fails if a volume of the same name is already attached, typically
+// a disk image of an earlier build that is still open. Such a volume is detached; if that
+// fails (e.g. because a file on it is in use), the first free name "<name> 2", "<name> 3",
+// ... is used instead.
+//
+// Returns an error if the attached disk images cannot be listed.
+func resolveVolumeName(hdiutilPath string, volumeName string) (string, error) {
+	mounted, err := isVolumeMounted(hdiutilPath, volumeName)
+	if err != nil || !mounted {
+		return volumeName, err
+	}
+
+	logger.Info("Volume %s is already mounted, detaching it", volumeName)
+	if err := detachVolume(hdiutilPath, volumeName); err != nil {
+		logger.Warn("%v", err)
+	} else if mounted, err = isVolumeMounted(hdiutilPath, volumeName); err != nil || !mounted {
+		return volumeName, err
+	}
+
+	for number := 2; ; number++ {
+		candidate := fmt.Sprintf("%s %d", volumeName, number)
+		mounted, err := isVolumeMounted(hdiutilPath, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !mounted {
+			logger.Warn("Volume %s is still mounted, naming the disk image volume %s", volumeName, candidate)
+			return candidate, nil
+		}
+	}
+}
+
+// CreateStapledDiskImage creates <name>.dmg from a notarized and stapled bundle, signs it,
+// submits it for notarization and staples the ticket to it. Stapling is the last step.
+//
+// Parameters:
+//   - appPath: Path to the notarized .app bundle
+//   - appleIDProfile: Keychain profile name containing Apple ID credentials
+//
+// Returns the path of the disk image, or an error if one of the steps fails.
+func CreateStapledDiskImage(appPath string, appleIDProfile string) (string, error) {
+	hdiutilPath, err := fileManagement.FindProgramPath("hdiutil")
+	if err != nil {
+		return "", err
+	}
+
+	imagePath := strings.TrimSuffix(appPath, ".app") + ".dmg"
+	logger.Info("Creating disk image %s", imagePath)
+
+	// Stapling changed the bundle; hdiutil copies the modification times into the image
+	if err := NormalizeTimestamps(appPath); err != nil {
+		return "", err
+	}
+
+	volumeName, err := resolveVolumeName(hdiutilPath, strings.TrimSuffix(filepath.Base(appPath), ".app"))
+	if err != nil {
+		return "", err
+	}
+
+	_, stderr, err := runCommand(hdiutilPath, hdiutilCreateArguments(appPath, imagePath, volumeName)...)
+
+	// hdiutil detaches the volume it mounts while creating the image, but not always when it
+	// is interrupted or fails
+	if mounted, infoErr := isVolumeMounted(hdiutilPath, volumeName); infoErr == nil && mounted {
+		if detachErr := detachVolume(hdiutilPath, volumeName); detachErr != nil {
+			logger.Warn("%v", detachErr)
+		}
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to create disk image %q: %v\n%s", imagePath, err, stderr)
+	}
+
+	if err := signDiskImage(imagePath); err != nil {
+		return "", err
+	}
+
+	logger.Info("Notarizing disk image %s (this may take several minutes)...", imagePath)
+	if err := submitForNotarization(imagePath, appleIDProfile); err != nil {
+		return "", err
+	}
+
+	if err := staple(imagePath); err != nil {
+		return "", err
+	}
+
+	logger.Info("Disk image stapled: %s", imagePath)
+	return imagePath, nil
+}
+
+// signDiskImage signs a disk image with the signing identity of the bundle. Disk images carry
+// no nested code and no hardened runtime, so only the identity and the timestamp are passed.
+func signDiskImage(imagePath string) error {
+	codeSignPath, err := fileManagement.FindProgramPath("codesign")
+	if err != nil {
+		return err
+	}
+
+	release, err := acquireSigningLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	identity, _, err := resolveSigningIdentity()
+	if err != nil {
+		return err
+	}
+
+	_, stderr, err := runCommand(codeSignPath, "--sign", identity, "--force", timestampArgument(), imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to sign %q: %v\n%s", imagePath, err, stderr)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeHdiutil is a stand-in for hdiutil. It lists the volume names in the file "mounted"
+// next to it as attached disk images, and detaches them unless the file "busy" exists.
+const fakeHdiutil = `#!/bin/sh
+state=$(dirname "$0")
+case "$1" in
+info)
+	echo '<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict><key>images</key><array>'
+	while IFS= read -r name; do
+		echo "<dict><key>system-entities</key><array><dict><key>content-hint</key><string>Apple_HFS</string><key>mount-point</key><string>/Volumes/$name</string></dict></array></dict>"
+	done < "$state/mounted"
+	echo '</array></dict></plist>'
+	;;
+detach)
+	if [ -f "$state/busy" ]; then
+		echo "hdiutil: couldn't unmount - Resource busy" >&2
+		exit 16
+	fi
+	grep -vxF "${2#/Volumes/}" "$state/mounted" > "$state/mounted.new"
+	mv "$state/mounted.new" "$state/mounted"
+	;;
+esac
+`
+
+// writeFakeHdiutil installs fakeHdiutil with the given volumes attached and returns its path.
+func writeFakeHdiutil(t *testing.T, busy bool, mounted ...string) string {
+	t.Helper()
+	directory := t.TempDir()
+	hdiutilPath := filepath.Join(directory, "hdiutil")
+
+	if err := os.WriteFile(hdiutilPath, []byte(fakeHdiutil), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := ""
+	for _, name := range mounted {
+		content += name + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(directory, "mounted"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if busy {
+		if err := os.WriteFile(filepath.Join(directory, "busy"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hdiutilPath
+}
+
+func TestHdiutilCreateArguments(t *testing.T) {
+	got := hdiutilCreateArguments("build/MyApp.app", "build/MyApp.dmg", "MyApp 2")
+	want := []string{"create", "-volname", "MyApp 2", "-srcfolder", "build/MyApp.app", "-ov", "-format", "UDZO", "build/MyApp.dmg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hdiutilCreateArguments() = %q, want %q", got, want)
+	}
+}
+
+func TestParseMountPoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "no images",
+			output: `<plist version="1.0"><dict><key>framework</key><string>671</string></dict></plist>`,
+		},
+		{
+			name: "mounted and unmounted entities",
+			output: `<plist version="1.0"><dict><key>images</key><array>
+				<dict><key>system-entities</key><array>
+					<dict><key>content-hint</key><string>GUID_partition_scheme</string></dict>
+					<dict><key>mount-point</key><string>/Volumes/My App</string></dict>
+				</array></dict>
+				<dict><key>system-entities</key><array>
+					<dict><key>mount-point</key><string>/Volumes/Other</string></dict>
+				</array></dict>
+			</array></dict></plist>`,
+			want: []string{"/Volumes/My App", "/Volumes/Other"},
+		},
+		{
+			name:    "not a property list",
+			output:  "hdiutil: info failed",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseMountPoints(test.output)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseMountPoints() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveVolumeName(t *testing.T) {
+	tests := []struct {
+		name        string
+		busy        bool
+		mounted     []string
+		want        string
+		wantMounted []string
+	}{
+		{"no collision", false, []string{"Other"}, "MyApp", []string{"Other"}},
+		{"collision is detached", false, []string{"Other", "MyApp"}, "MyApp", []string{"Other"}},
+		{"busy volume gets a unique name", true, []string{"MyApp", "MyApp 2"}, "MyApp 3", []string{"MyApp", "MyApp 2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hdiutilPath := writeFakeHdiutil(t, test.busy, test.mounted...)
+
+			got, err := resolveVolumeName(hdiutilPath, "MyApp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("resolveVolumeName() = %q, want %q", got, test.want)
+			}
+
+			content, err := os.ReadFile(filepath.Join(filepath.Dir(hdiutilPath), "mounted"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mounted := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"); !reflect.DeepEqual(mounted, test.wantMounted) {
+				t.Errorf("mounted volumes = %q, want %q", mounted, test.wantMounted)
+			}
+		})
+	}
+}
+
+// TestCreateStapledDiskImageStaplesLast runs the disk image steps with -print-commands against
+// stand-ins for hdiutil, codesign and xcrun, and checks the order of the printed commands.
+func TestCreateStapledDiskImageStaplesLast(t *testing.T) {
+	hdiutilPath := writeFakeHdiutil(t, false)
+	toolDirectory := filepath.Dir(hdiutilPath)
+	for _, tool := range []string{"codesign", "xcrun"} {
+		if err := os.WriteFile(filepath.Join(toolDirectory, tool), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	previousIdentity := signingIdentity
+	signingIdentity = "Developer ID Application: Example Inc (ABCDE12345)"
+	t.Cleanup(func() { signingIdentity = previousIdentity })
+
+	var printed strings.Builder
+	SetPrintCommands(&printed)
+	t.Cleanup(func() { SetPrintCommands(nil) })
+
+	appPath := filepath.Join(t.TempDir(), "MyApp.app")
+	imagePath, err := CreateStapledDiskImage(appPath, "notary-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSuffix(appPath, ".app") + ".dmg"; imagePath != want {
+		t.Errorf("disk image = %s, want %s", imagePath, want)
+	}
+
+	var steps []string
+	for _, line := range strings.Split(strings.TrimSpace(printed.String()), "\n") {
+		fields := strings.Fields(line)
+		steps = append(steps, filepath.Base(fields[0])+" "+fields[1])
+	}
+	want := []string{"hdiutil create", "codesign --sign", "xcrun notarytool", "xcrun stapler"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("commands = %q, want %q\n%s", steps, want, printed.String())
+	}
+	if lastLine := strings.Split(strings.TrimSpace(printed.String()), "\n")[3]; !strings.HasSuffix(lastLine, imagePath) {
+		t.Errorf("last command %q does not staple the disk image", lastLine)
+	}
+}
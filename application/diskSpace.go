@@ -0,0 +1,130 @@
+// Package application: This file checks the free disk space before a bundle is built.
+// Bundling a Java runtime copies several hundred megabytes; running out of space halfway
+// leaves a confusing partial copy. The space needed is estimated from the sources (executable,
+// Java runtime, icon and resources) times a safety factor, which covers the zip archive for
+// notarization and file system overhead, and compared with the space available on the volume
+// receiving the bundle.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// defaultDiskSpaceFactor is the safety factor applied to the size of the sources
+const defaultDiskSpaceFactor = 2.0
+
+// statfs reads the file system statistics of a path (replaceable to simulate a full disk)
+var statfs = syscall.Statfs
+
+// pathSize returns the total size in bytes of a file or of all files below a directory.
+// Symbolic links are not followed.
+func pathSize(path string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
+
+// bundleSources returns the local files and directories copied into the bundle.
+// Downloaded executables and Go builds are not known before the build and are left out.
+func bundleSources() []string {
+	var sources []string
+
+	if GetGoPackage() == "" && !isExecutableURL(GetExecutablePath()) {
+		sources = append(sources, GetExecutablePath())
+	}
+	if GetUseLocalJava() && GetJavaRuntimeMode() == javaRuntimeModeCopy {
+		for _, runtime := range bundledRuntimes() {
+			sources = append(sources, runtime.source)
+		}
+	}
+	if GetIconFileName() != "" {
+		sources = append(sources, GetIconFilePath())
+	}
+	sources = append(sources, GetResources()...)
+
+	return sources
+}
+
+// availableSpace returns the number of bytes available to the user on the volume of a path.
+func availableSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// formatBytes formats a size in bytes with a binary unit (e.g. "1.5 GiB").
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value, exponent := float64(size)/unit, 0
+	for value >= unit && exponent < 4 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exponent])
+}
+
+// CheckDiskSpace estimates the space needed for the bundle (size of the sources times
+// disk_space_factor) and compares it with the space available on the volume of the
+// output directory.
+//
+// Parameters:
+//   - outputDirectory: Directory receiving the bundle
+//
+// Returns an error if the available space is lower than the estimate, or if the sources or
+// the volume cannot be inspected.
+func CheckDiskSpace(outputDirectory string) error {
+	var sourceSize int64
+	for _, source := range bundleSources() {
+		size, err := pathSize(source)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		sourceSize += size
+	}
+	required := uint64(float64(sourceSize) * GetDiskSpaceFactor())
+
+	available, err := availableSpace(outputDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to determine the free disk space of %q: %v", outputDirectory, err)
+	}
+
+	logger.Debug("Disk space: %s required (sources %s x %g), %s available", formatBytes(required),
+		formatBytes(uint64(sourceSize)), GetDiskSpaceFactor(), formatBytes(available))
+	if available < required {
+		return fmt.Errorf("not enough disk space in %q: about %s required, %s available (adjust with disk_space_factor)",
+			outputDirectory, formatBytes(required), formatBytes(available))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// setTestStatfs replaces statfs for the duration of a test, reporting the given number of
+// available bytes for every path, or the error if it is not nil.
+func setTestStatfs(t *testing.T, available uint64, statfsErr error) {
+	t.Helper()
+	previous := statfs
+	statfs = func(path string, stat *syscall.Statfs_t) error {
+		if statfsErr != nil {
+			return statfsErr
+		}
+		stat.Bsize = 1024
+		stat.Bavail = available / 1024
+		return nil
+	}
+	t.Cleanup(func() { statfs = previous })
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	// A 10 KiB executable needs 20 KiB with the default factor
+	executableDirectory := writeTestExecutable(t, "MyApp", strings.Repeat("x", 10*1024))
+
+	tests := []struct {
+		name      string
+		factor    float64
+		available uint64
+		statfsErr error
+		wantErr   string
+	}{
+		{name: "enough space", available: 1024 * 1024},
+		{name: "exactly enough", available: 20 * 1024},
+		{name: "low space", available: 8 * 1024, wantErr: "not enough disk space"},
+		{name: "higher factor", factor: 3, available: 20 * 1024, wantErr: "about 30.0 KiB required, 20.0 KiB available"},
+		{name: "statfs fails", statfsErr: syscall.ENOENT, wantErr: "failed to determine the free disk space"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{ExecFileName: "MyApp", ExecFileDirectory: executableDirectory, DiskSpaceFactor: test.factor})
+			setTestStatfs(t, test.available, test.statfsErr)
+
+			err := CheckDiskSpace(filepath.Join(t.TempDir(), "build"))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size uint64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{300 * 1024 * 1024, "300.0 MiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+
+	for _, test := range tests {
+		if got := formatBytes(test.size); got != test.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package application: This file handles the document types an application can open.
+// Document types are rendered as the CFBundleDocumentTypes array in Info.plist, which
+// tells Finder and LaunchServices which files can be opened with the application.
+//
+// The document_types configuration field accepts three forms:
+//
+//	document_types: com.example.text          # a single content type (UTI)
+//
+//	document_types: [public.plain-text, public.rtf]
+//
+//	document_types:
+//	  - name: Example Document
+//	    role: Editor
+//	    content_types: [com.example.text]
+//	    extensions: [extxt]
+//	    icon_file: document.icns
+package application
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDocumentRole is used for CFBundleTypeRole when no role is configured.
+const defaultDocumentRole = "Viewer"
+
+// DocumentType describes one entry of the CFBundleDocumentTypes array in Info.plist.
+type DocumentType struct {
+	Name         string   `yaml:"name"`          // Name of the document type (CFBundleTypeName)
+	Role         string   `yaml:"role"`          // Editor, Viewer, Shell or None (CFBundleTypeRole)
+	ContentTypes []string `yaml:"content_types"` // Uniform type identifiers (LSItemContentTypes)
+	Extensions   []string `yaml:"extensions"`    // File extensions without dot (CFBundleTypeExtensions)
+	IconFile     string   `yaml:"icon_file"`     // Document icon in Resources/ (CFBundleTypeIconFile)
+}
+
+// documentTypes is the list of configured document types. It implements yaml.Unmarshaler
+// to accept a plain content type string as well as a list of strings or structured entries.
+type documentTypes []DocumentType
+
+// UnmarshalYAML parses the document_types field in any of its supported forms.
+func (types *documentTypes) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		// A single content type, e.g. "document_types: com.example.text"
+		if value.Value != "" {
+			*types = documentTypes{{ContentTypes: []string{value.Value}}}
+		}
+		return nil
+
+	case yaml.SequenceNode:
+		var parsed documentTypes
+		for _, item := range value.Content {
+			if item.Kind == yaml.ScalarNode {
+				parsed = append(parsed, DocumentType{ContentTypes: []string{item.Value}})
+				continue
+			}
+
+			var documentType DocumentType
+			if err := item.Decode(&documentType); err != nil {
+				return err
+			}
+			parsed = append(parsed, documentType)
+		}
+		*types = parsed
+		return nil
+	}
+
+	return fmt.Errorf("line %d: document_types must be a content type or a list of document types", value.Line)
+}
+
+// GetCFBundleDocumentTypes returns the document types this app can open, with defaults applied:
+// the role defaults to "Viewer" and the name defaults to the first content type or extension.
+func GetCFBundleDocumentTypes() []DocumentType {
+	var types []DocumentType
+
+	for _, documentType := range packageInfo.CFBundleDocumentTypes {
+		if documentType.Role == "" {
+			documentType.Role = defaultDocumentRole
+		}
+		if documentType.Name == "" {
+			if len(documentType.ContentTypes) > 0 {
+				documentType.Name = documentType.ContentTypes[0]
+			} else if len(documentType.Extensions) > 0 {
+				documentType.Name = documentType.Extensions[0]
+			}
+		}
+		types = append(types, documentType)
+	}
+
+	return types
+}
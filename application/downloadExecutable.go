@@ -0,0 +1,90 @@
+// Package application: This file downloads the executable when exec_file is an HTTP(S) URL.
+// Build pipelines often publish the binary or JAR as an artifact on a web server; instead of
+// fetching it in a separate step, it is downloaded into a temporary directory, optionally
+// verified against a SHA-256 checksum, and then copied into the bundle like a local file.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sha256Pattern matches a hex encoded SHA-256 checksum.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// isExecutableURL reports whether an exec_file value is an HTTP(S) URL.
+func isExecutableURL(execFile string) bool {
+	lower := strings.ToLower(execFile)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// validateExecutableURL checks the URL of a remote executable and the optional checksum.
+func validateExecutableURL(executableURL string, checksum string) error {
+	parsedURL, err := url.Parse(executableURL)
+	if err != nil || parsedURL.Host == "" {
+		return fmt.Errorf("invalid exec_file URL %q", executableURL)
+	}
+
+	if checksum != "" && !sha256Pattern.MatchString(checksum) {
+		return fmt.Errorf("invalid exec_file_sha256 %q: expected 64 hexadecimal characters", checksum)
+	}
+
+	return nil
+}
+
+// downloadExecutable downloads a remote executable into the given directory. The download is
+// limited by the command timeout (-command-timeout). The file is named after the bundle
+// executable (CFBundleExecutable), independent of the URL path, which may be empty or
+// contain ".." elements.
+//
+// Parameters:
+//   - executableURL: HTTP(S) URL of the executable
+//   - checksum: Expected hex encoded SHA-256 checksum ("" to skip the verification)
+//   - directory: Directory receiving the file
+//
+// Returns the path of the downloaded file, or an error if the download fails, the server
+// does not answer with 200 OK or the checksum does not match.
+func downloadExecutable(executableURL string, checksum string, directory string) (string, error) {
+	logger.Info("Downloading the executable from %s", executableURL)
+
+	client := &http.Client{Timeout: commandTimeout}
+	response, err := client.Get(executableURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", executableURL, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", executableURL, response.Status)
+	}
+
+	downloadPath := filepath.Join(directory, filepath.Base(GetBundleExecutable()))
+	file, err := os.Create(downloadPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// Hash the content while writing it
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), response.Body); err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", executableURL, err)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && !strings.EqualFold(digest, checksum) {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", executableURL, strings.ToLower(checksum), digest)
+	}
+	logger.Debug("Downloaded %s (sha256 %s)", downloadPath, digest)
+
+	return downloadPath, file.Close()
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBinary is the content served as the remote executable
+const testBinary = "\xcf\xfa\xed\xfe fake binary"
+
+func TestDownloadExecutable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/missing") {
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Write([]byte(testBinary))
+	}))
+	t.Cleanup(server.Close)
+
+	digest := sha256.Sum256([]byte(testBinary))
+	checksum := hex.EncodeToString(digest[:])
+
+	tests := []struct {
+		name     string
+		path     string
+		checksum string
+		wantErr  string
+	}{
+		{name: "correct checksum", path: "/releases/myapp-1.0", checksum: checksum},
+		{name: "upper case checksum", path: "/releases/myapp-1.0", checksum: strings.ToUpper(checksum)},
+		{name: "no checksum", path: "/releases/myapp-1.0"},
+		{name: "empty path", path: ""},
+		{name: "parent elements", path: "/releases/../../etc/.."},
+		{name: "checksum mismatch", path: "/releases/myapp-1.0", checksum: strings.Repeat("0", 64), wantErr: "checksum mismatch"},
+		{name: "not found", path: "/releases/missing", wantErr: "404 Not Found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{ExecFileName: server.URL + test.path, BundleExecutable: "MyApp"})
+			directory := t.TempDir()
+
+			downloadPath, err := downloadExecutable(server.URL+test.path, test.checksum, directory)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := filepath.Join(directory, "MyApp"); downloadPath != want {
+				t.Errorf("downloaded to %s, want %s", downloadPath, want)
+			}
+			content, err := os.ReadFile(downloadPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != testBinary {
+				t.Errorf("downloaded content = %q, want %q", content, testBinary)
+			}
+		})
+	}
+}
+
+func TestValidateExecutableURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		checksum string
+		wantErr  bool
+	}{
+		{"https://example.com/myapp", "", false},
+		{"https://example.com/myapp", strings.Repeat("ab", 32), false},
+		{"https://example.com/myapp", "abc", true},
+		{"https:///myapp", "", true},
+	}
+
+	for _, test := range tests {
+		if err := validateExecutableURL(test.url, test.checksum); (err != nil) != test.wantErr {
+			t.Errorf("validateExecutableURL(%q, %q) error = %v, wantErr %v", test.url, test.checksum, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package application: This file generates entitlements from named presets
+// (-entitlements-preset). The hardened runtime required for notarization blocks JIT code,
+// unsigned executable memory and libraries signed by other teams; applications needing
+// these (e.g. Java runtimes) must request them as entitlements. Presets generate the
+// entitlements plist, so it does not have to be written by hand.
+//
+// Every preset weakens the protection of the hardened runtime and should only be used
+// when the application does not work without it.
+package application
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// entitlementsPresets maps the preset names to the entitlements they enable
+var entitlementsPresets = map[string][]string{
+	// Code generated at runtime in memory mapped with MAP_JIT
+	"jit": {"com.apple.security.cs.allow-jit"},
+	// Writable and executable memory without MAP_JIT (e.g. older JITs and native code loaders)
+	"allow-unsigned-libraries": {"com.apple.security.cs.allow-unsigned-executable-memory"},
+	// Loading libraries and plug-ins signed by other teams or not signed at all
+	"disable-library-validation": {"com.apple.security.cs.disable-library-validation"},
+}
+
+// Entitlements generated from presets for the bundle (set via SetEntitlementsPresets)
+var presetEntitlements []string
+
+// entitlementsTemplate is the entitlements plist, %s is replaced by the entitlements
+const entitlementsTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+%s</dict>
+</plist>
+`
+
+// SetEntitlementsPresets selects the entitlements presets used when signing the bundle.
+// Multiple presets are combined; an entitlement enabled by several presets is added once.
+//
+// Parameters:
+//   - presets: Comma-separated preset names ("jit", "allow-unsigned-libraries",
+//     "disable-library-validation"), empty for none
+//
+// Returns an error if a preset is unknown.
+func SetEntitlementsPresets(presets string) error {
+	var entitlements []string
+
+	for _, preset := range strings.Split(presets, ",") {
+		preset = strings.TrimSpace(preset)
+		if preset == "" {
+			continue
+		}
+
+		keys, ok := entitlementsPresets[preset]
+		if !ok {
+			names := make([]string, 0, len(entitlementsPresets))
+			for name := range entitlementsPresets {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return fmt.Errorf("unknown entitlements preset %q (valid presets: %s)", preset, strings.Join(names, ", "))
+		}
+		entitlements = append(entitlements, keys...)
+	}
+
+	slices.Sort(entitlements)
+	presetEntitlements = slices.Compact(entitlements)
+	return nil
+}
+
+// renderEntitlements returns the entitlements plist enabling the given entitlements.
+func renderEntitlements(entitlements []string) string {
+	var keys strings.Builder
+	for _, entitlement := range entitlements {
+		keys.WriteString(fmt.Sprintf("    <key>%s</key>\n    <true/>\n", escapePlistText(entitlement)))
+	}
+
+	return fmt.Sprintf(entitlementsTemplate, keys.String())
+}
+
+// writePresetEntitlements writes the entitlements of the selected presets to a temporary
+// file for codesign --entitlements. The caller removes the file after signing.
+//
+// Returns the path of the file, or an error if it cannot be written.
+func writePresetEntitlements() (string, error) {
+	file, err := os.CreateTemp("", "appbundler-entitlements-*.plist")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(renderEntitlements(presetEntitlements)); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
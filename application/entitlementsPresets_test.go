@@ -0,0 +1,71 @@
+package application
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestEntitlementsPresets generates the entitlements plist of presets and checks that it
+// enables exactly the entitlements of the selected presets.
+func TestEntitlementsPresets(t *testing.T) {
+	tests := []struct {
+		name    string
+		presets string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{name: "jit", presets: "jit", want: map[string]interface{}{"com.apple.security.cs.allow-jit": true}},
+		{
+			name:    "allow-unsigned-libraries",
+			presets: "allow-unsigned-libraries",
+			want:    map[string]interface{}{"com.apple.security.cs.allow-unsigned-executable-memory": true},
+		},
+		{
+			name:    "combined presets",
+			presets: "jit, disable-library-validation,jit",
+			want: map[string]interface{}{
+				"com.apple.security.cs.allow-jit":                  true,
+				"com.apple.security.cs.disable-library-validation": true,
+			},
+		},
+		{name: "unknown preset", presets: "jit,allow-everything", wantErr: `unknown entitlements preset "allow-everything"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previousEntitlements := presetEntitlements
+			t.Cleanup(func() { presetEntitlements = previousEntitlements })
+
+			err := SetEntitlementsPresets(test.presets)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entitlementsFile, err := writePresetEntitlements()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(entitlementsFile)
+			content, err := os.ReadFile(entitlementsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := decodePlist(content)
+			if err != nil {
+				t.Fatalf("entitlements are not a valid property list: %v\n%s", err, content)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("entitlements = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package application: This file detects the type of the executable to bundle from its
+// content. The file name suffix alone is not reliable: a JAR without the .jar extension
+// would otherwise be copied as a binary, producing a bundle that does not launch.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Executable types detected by detectExecutableType
+const (
+	executableTypeUnknown = "unknown"
+	executableTypeJar     = "jar"
+	executableTypeMachO   = "mach-o"
+	executableTypeScript  = "script"
+)
+
+// shebangMagic is the start of scripts run by an interpreter ("#!/bin/bash")
+var shebangMagic = []byte("#!")
+
+// zipMagic is the signature at the start of zip archives (and therefore JAR files)
+var zipMagic = []byte{'P', 'K', 0x03, 0x04}
+
+// machOMagics lists the signatures of Mach-O binaries: 32 and 64 bit in both byte orders,
+// and universal (fat) binaries.
+var machOMagics = [][]byte{
+	{0xfe, 0xed, 0xfa, 0xce}, {0xce, 0xfa, 0xed, 0xfe}, // 32 bit
+	{0xfe, 0xed, 0xfa, 0xcf}, {0xcf, 0xfa, 0xed, 0xfe}, // 64 bit
+	{0xca, 0xfe, 0xba, 0xbe}, // universal binary
+}
+
+// foreignExecutableMagics maps the signatures of executables for other systems to a
+// description, to explain why such a file cannot run on macOS.
+var foreignExecutableMagics = []struct {
+	magic       []byte
+	description string
+}{
+	{[]byte{0x7f, 'E', 'L', 'F'}, "an ELF binary (Linux)"},
+	{[]byte{'M', 'Z'}, "a PE binary (Windows)"},
+}
+
+// describeNonMachO returns a description of a file that is not a Mach-O binary.
+func describeNonMachO(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return "an unreadable file"
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	count, _ := io.ReadFull(file, header)
+	for _, foreign := range foreignExecutableMagics {
+		if bytes.HasPrefix(header[:count], foreign.magic) {
+			return foreign.description
+		}
+	}
+
+	return "a file of unknown format"
+}
+
+// checkMachOExecutable verifies that a compiled executable is a Mach-O binary (thin or
+// universal). Anything else, e.g. an ELF binary built for Linux, is bundled but the
+// application would not launch. The mismatch is logged as a warning, or returned as an
+// error in strict mode.
+//
+// Returns an error only in strict mode, if the file is not a Mach-O binary or cannot be read.
+func checkMachOExecutable(path string) error {
+	executableType, err := detectExecutableType(path)
+	if err == nil && executableType == executableTypeMachO {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("%s is %s, not a Mach-O binary; the application will not launch on macOS",
+			path, describeNonMachO(path))
+	}
+
+	if strictMode {
+		return err
+	}
+
+	logger.Warn("Executable check: %v", err)
+	return nil
+}
+
+// detectExecutableType reads the first bytes of a file and returns its type
+// (executableTypeJar, executableTypeMachO, executableTypeScript or executableTypeUnknown).
+func detectExecutableType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return executableTypeUnknown, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(file, header); err != nil {
+		// Files shorter than the signature are neither JARs nor binaries
+		return executableTypeUnknown, nil
+	}
+
+	if bytes.Equal(header, zipMagic) {
+		return executableTypeJar, nil
+	}
+	if bytes.HasPrefix(header, shebangMagic) {
+		return executableTypeScript, nil
+	}
+	for _, magic := range machOMagics {
+		if bytes.Equal(header, magic) {
+			return executableTypeMachO, nil
+		}
+	}
+
+	return executableTypeUnknown, nil
+}
+
+// isJarExecutable decides whether the executable is handled as a JAR file. The content wins
+// over the file name: a warning is logged if the .jar suffix and the detected type disagree.
+// If the content is not recognized, the suffix decides.
+func isJarExecutable(path string) bool {
+	isJar, mismatch := classifyJarExecutable(path)
+	if mismatch != "" {
+		logger.Warn("%s", mismatch)
+	}
+
+	return isJar
+}
+
+// classifyJarExecutable implements isJarExecutable without the warning.
+//
+// Returns whether the file is handled as a JAR file, and a description of the disagreement
+// between the .jar suffix and the detected type ("" if they agree).
+func classifyJarExecutable(path string) (bool, string) {
+	hasJarSuffix := strings.HasSuffix(strings.ToLower(path), ".jar")
+
+	executableType, err := detectExecutableType(path)
+	if err != nil {
+		logger.Debug("Cannot detect the type of %s: %s", path, err)
+	}
+
+	switch executableType {
+	case executableTypeJar:
+		if !hasJarSuffix {
+			return true, fmt.Sprintf("%s has no .jar extension, but is a JAR file; bundling it as a Java application", path)
+		}
+		return true, ""
+	case executableTypeMachO:
+		if hasJarSuffix {
+			return false, fmt.Sprintf("%s has a .jar extension, but is a Mach-O binary; bundling it as a binary", path)
+		}
+		return false, ""
+	case executableTypeScript:
+		if hasJarSuffix {
+			return false, fmt.Sprintf("%s has a .jar extension, but is a script; bundling it as a script", path)
+		}
+		return false, ""
+	default:
+		return hasJarSuffix, ""
+	}
+}
+
+// isScriptExecutable reports whether the executable is a script starting with a shebang
+// line ("#!"), which macOS runs with the named interpreter.
+func isScriptExecutable(path string) bool {
+	executableType, err := detectExecutableType(path)
+	return err == nil && executableType == executableTypeScript
+}
@@ -0,0 +1,152 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Start of further test executables: Mach-O variants, foreign binaries and a script
+const (
+	testMachO32Content = "\xce\xfa\xed\xfe binary content"
+	testFatContent     = "\xca\xfe\xba\xbe universal binary content"
+	testELFContent     = "\x7fELF\x02\x01\x01 linux binary content"
+	testPEContent      = "MZ\x90\x00 windows binary content"
+	testScriptContent  = "#!/bin/sh\nexec java -jar MyApp.jar\n"
+)
+
+// setTestStrictMode sets the strict mode and restores it after the test.
+func setTestStrictMode(t *testing.T, strict bool) {
+	t.Helper()
+	previous := strictMode
+	t.Cleanup(func() { strictMode = previous })
+	SetStrict(strict)
+}
+
+func TestDetectExecutableType(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"JAR", testJarContent, executableTypeJar},
+		{"Mach-O 64 bit", testMachOContent, executableTypeMachO},
+		{"Mach-O 32 bit", testMachO32Content, executableTypeMachO},
+		{"universal binary", testFatContent, executableTypeMachO},
+		{"shebang script", testScriptContent, executableTypeScript},
+		{"ELF binary", testELFContent, executableTypeUnknown},
+		{"shorter than the signature", "PK", executableTypeUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := writeTestExecutable(t, "MyApp", test.content)
+
+			got, err := detectExecutableType(filepath.Join(directory, "MyApp"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("detectExecutableType() = %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := detectExecutableType(filepath.Join(t.TempDir(), "MyApp")); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
+
+func TestClassifyJarExecutable(t *testing.T) {
+	tests := []struct {
+		name         string
+		fileName     string
+		content      string
+		wantJar      bool
+		wantMismatch string
+	}{
+		{"JAR", "MyApp.jar", testJarContent, true, ""},
+		{"JAR without suffix", "MyApp", testJarContent, true, "has no .jar extension, but is a JAR file"},
+		{"Mach-O binary", "MyApp", testMachOContent, false, ""},
+		{"universal binary", "MyApp", testFatContent, false, ""},
+		{"Mach-O binary with JAR suffix", "MyApp.jar", testMachOContent, false, "has a .jar extension, but is a Mach-O binary"},
+		{"shebang script", "MyApp", testScriptContent, false, ""},
+		{"shebang script with JAR suffix", "MyApp.JAR", testScriptContent, false, "has a .jar extension, but is a script"},
+		{"unknown content with JAR suffix", "MyApp.jar", "text", true, ""},
+		{"unknown content", "MyApp", "text", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := writeTestExecutable(t, test.fileName, test.content)
+
+			isJar, mismatch := classifyJarExecutable(filepath.Join(directory, test.fileName))
+			if isJar != test.wantJar {
+				t.Errorf("isJar = %v, want %v", isJar, test.wantJar)
+			}
+			if test.wantMismatch == "" && mismatch != "" {
+				t.Errorf("unexpected mismatch %q", mismatch)
+			}
+			if !strings.Contains(mismatch, test.wantMismatch) {
+				t.Errorf("mismatch = %q, want it to contain %q", mismatch, test.wantMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckMachOExecutable(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		strict  bool
+		wantErr string
+	}{
+		{"Mach-O binary", testMachOContent, true, ""},
+		{"universal binary", testFatContent, true, ""},
+		{"ELF binary", testELFContent, false, ""},
+		{"ELF binary in strict mode", testELFContent, true, "is an ELF binary (Linux), not a Mach-O binary"},
+		{"PE binary in strict mode", testPEContent, true, "is a PE binary (Windows), not a Mach-O binary"},
+		{"shebang script in strict mode", testScriptContent, true, "is a file of unknown format, not a Mach-O binary"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestStrictMode(t, test.strict)
+			directory := writeTestExecutable(t, "MyApp", test.content)
+
+			err := checkMachOExecutable(filepath.Join(directory, "MyApp"))
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsScriptExecutable(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"shebang script", testScriptContent, true},
+		{"Mach-O binary", testMachOContent, false},
+		{"JAR", testJarContent, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := writeTestExecutable(t, "MyApp", test.content)
+			if got := isScriptExecutable(filepath.Join(directory, "MyApp")); got != test.want {
+				t.Errorf("isScriptExecutable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
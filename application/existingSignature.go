@@ -0,0 +1,59 @@
+// Package application: This file inspects the existing code signature of a bundle.
+// SignApplication always signs with --force, which silently replaces any existing signature.
+// To make this visible, the identity of an existing signature is logged before re-signing.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"bufio"
+	"strings"
+)
+
+// adhocSignature is reported as the signer of ad-hoc signed code (no certificate)
+const adhocSignature = "ad-hoc signature"
+
+// parseSigningAuthority extracts the signing identity from "codesign -dvv" output.
+// The first Authority= line is the leaf certificate that signed the code, e.g.:
+//
+//	Authority=Apple Development: John Doe (ABCD123456)
+//	Authority=Apple Worldwide Developer Relations Certification Authority
+//	Authority=Apple Root CA
+//
+// Returns "ad-hoc signature" for ad-hoc signed code, or "" if no signer is found.
+func parseSigningAuthority(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if authority, found := strings.CutPrefix(line, "Authority="); found {
+			return authority
+		}
+		if line == "Signature=adhoc" {
+			return adhocSignature
+		}
+	}
+
+	return ""
+}
+
+// logExistingSignature logs the signing identity of an already signed path at Info level,
+// so users notice when a signature from an unexpected identity is being replaced.
+// Unsigned code is only logged at Debug level; codesign reports it with a non-zero exit status.
+//
+// Parameters:
+//   - codeSignPath: Path of the codesign tool
+//   - path: Path of the bundle about to be signed
+func logExistingSignature(codeSignPath string, path string) {
+	// codesign -dvv writes the signature details to stderr
+	stdout, stderr, err := runQuery(codeSignPath, "-dvv", path)
+	if err != nil {
+		logger.Debug("No existing signature found for %s", path)
+		return
+	}
+
+	authority := parseSigningAuthority(stderr + stdout)
+	if authority == "" {
+		authority = "unknown identity"
+	}
+
+	logger.Info("Replacing existing signature of %s (signed by %s)", path, authority)
+}
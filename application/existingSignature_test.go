@@ -0,0 +1,79 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDeveloperIDSignature is the "codesign -dvv" output of a bundle signed with a Developer ID.
+const testDeveloperIDSignature = `Executable=/Applications/MyApp.app/Contents/MacOS/MyApp
+Identifier=com.example.myapp
+Format=app bundle with Mach-O thin (arm64)
+CodeDirectory v=20500 size=1234 flags=0x10000(runtime) hashes=27+7 location=embedded
+Signature size=9000
+Authority=Developer ID Application: Example Corp (ABCDE12345)
+Authority=Developer ID Certification Authority
+Authority=Apple Root CA
+Timestamp=Jan 1, 2026 at 12:00:00
+TeamIdentifier=ABCDE12345
+`
+
+func TestParseSigningAuthority(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"developer ID", testDeveloperIDSignature, "Developer ID Application: Example Corp (ABCDE12345)"},
+		{"ad-hoc", "Identifier=MyApp\nSignature=adhoc\nTeamIdentifier=not set\n", adhocSignature},
+		{"no authority", "Identifier=MyApp\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseSigningAuthority(test.output); got != test.want {
+				t.Errorf("parseSigningAuthority() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestLogExistingSignature runs a fake codesign printing sample "codesign -dvv" output: the
+// previous identity is logged at Info level, unsigned code is not reported as replaced.
+func TestLogExistingSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		status  int
+		wantLog string
+	}{
+		{"developer ID", testDeveloperIDSignature, 0, "(signed by Developer ID Application: Example Corp (ABCDE12345))"},
+		{"ad-hoc", "Identifier=MyApp\nSignature=adhoc\n", 0, "(signed by ad-hoc signature)"},
+		{"unsigned", "MyApp.app: code object is not signed at all\n", 1, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(directory, "output"), []byte(test.output), 0644); err != nil {
+				t.Fatal(err)
+			}
+			codeSignPath := filepath.Join(directory, "codesign")
+			script := fmt.Sprintf("#!/bin/sh\ncat \"$(dirname \"$0\")/output\" >&2\nexit %d\n", test.status)
+			if err := os.WriteFile(codeSignPath, []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			log := captureTestLog(t)
+
+			logExistingSignature(codeSignPath, "MyApp.app")
+
+			replaced := strings.Contains(log.String(), "Replacing existing signature of MyApp.app")
+			if replaced != (test.wantLog != "") || !strings.Contains(log.String(), test.wantLog) {
+				t.Errorf("log = %q, want %q", log.String(), test.wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Package application: This file expands an .icns icon into an .iconset directory of PNG
+// images (one per size and scale), so designers can inspect or edit the individual images.
+// The conversion is done by Apple's iconutil tool.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// iconsetDirectory returns the .iconset directory for an icon: the given output directory,
+// or <icon name>.iconset in the current directory. iconutil requires the .iconset extension.
+func iconsetDirectory(icnsPath string, outputDirectory string) string {
+	if outputDirectory == "" {
+		outputDirectory = strings.TrimSuffix(filepath.Base(icnsPath), filepath.Ext(icnsPath))
+	}
+	if filepath.Ext(outputDirectory) != ".iconset" {
+		outputDirectory += ".iconset"
+	}
+
+	return outputDirectory
+}
+
+// ExplodeIcon expands an .icns file into an .iconset directory by running:
+// iconutil -c iconset -o <outputDirectory> <icnsPath>
+//
+// Parameters:
+//   - icnsPath: Path to the .icns file
+//   - outputDirectory: Directory to create (".iconset" is appended if missing); empty for
+//     <icon name>.iconset in the current directory
+//
+// Returns the path of the created .iconset directory, or an error if the icon does not
+// exist, iconutil is not found or the conversion fails.
+func ExplodeIcon(icnsPath string, outputDirectory string) (string, error) {
+	if _, err := os.Stat(icnsPath); err != nil {
+		return "", fmt.Errorf("icon not found: %v", err)
+	}
+
+	iconutilPath, err := fileManagement.FindProgramPath("iconutil")
+	if err != nil {
+		return "", err
+	}
+
+	iconset := iconsetDirectory(icnsPath, outputDirectory)
+	logger.Info("Expanding %s into %s", icnsPath, iconset)
+
+	_, stderr, err := runCommand(iconutilPath, "-c", "iconset", "-o", iconset, icnsPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand icon %q: %v\n%s", icnsPath, err, stderr)
+	}
+
+	return iconset, nil
+}
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeIconutil is a stand-in for "iconutil -c iconset -o <directory> <icns>" that creates the
+// iconset directory with one PNG.
+const fakeIconutil = `#!/bin/sh
+mkdir -p "$4" && echo png > "$4/icon_16x16.png"
+`
+
+// TestExplodeIcon expands an icon with a fake iconutil: the iconset directory is produced at
+// the requested location.
+func TestExplodeIcon(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantIconset string
+		missingIcon bool
+		wantErr     string
+	}{
+		{name: "default", output: "", wantIconset: "MyApp.iconset"},
+		{name: "output directory", output: "edit/Design", wantIconset: "edit/Design.iconset"},
+		{name: "output with extension", output: "Design.iconset", wantIconset: "Design.iconset"},
+		{name: "missing icon", missingIcon: true, wantErr: "icon not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			toolDirectory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(toolDirectory, "iconutil"), []byte(fakeIconutil), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+			directory := setTestWorkingDirectory(t)
+			if !test.missingIcon {
+				writeBundleFile(t, directory, "MyApp.icns", "icon")
+			}
+
+			iconset, err := ExplodeIcon("MyApp.icns", test.output)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if iconset != filepath.FromSlash(test.wantIconset) {
+				t.Errorf("iconset = %s, want %s", iconset, test.wantIconset)
+			}
+			if got := readBundleFile(t, directory, test.wantIconset+"/icon_16x16.png"); got != "png\n" {
+				t.Errorf("icon_16x16.png = %q", got)
+			}
+		})
+	}
+}
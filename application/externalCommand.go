@@ -0,0 +1,275 @@
+// Package application: This file runs the external tools used to build, sign and notarize bundles.
+// All external commands (codesign, xcrun, security, xattr, strip, ...) are started through
+// runCommand, which captures their output for error messages and parsing. In verbose mode the
+// output is additionally streamed line by line to the logger while the command is running,
+// which helps to diagnose long running or hanging tools such as notarytool.
+// Every command runs with a timeout, so a stalled tool cannot block the build forever.
+//
+// With -print-commands, the commands that sign, package, submit or otherwise act on the bundle
+// are printed as shell command lines instead of being run, so they can be reviewed. Queries
+// (codesign -d, lipo -archs, security find-identity, ...) and steps producing files needed by
+// later steps (go build, ditto) still run.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// verboseCommands enables live streaming of external command output (set via SetVerbose).
+var verboseCommands bool
+
+// SetVerbose enables or disables streaming the output of external commands to the
+// logger at Debug level while they run.
+//
+// Parameters:
+//   - verbose: true to stream stdout/stderr of all external commands
+func SetVerbose(verbose bool) {
+	verboseCommands = verbose
+}
+
+// commandPrinter receives the command lines that are printed instead of run (set via SetPrintCommands)
+var commandPrinter io.Writer
+
+// SetPrintCommands enables printing the external commands instead of running them.
+//
+// Parameters:
+//   - writer: Destination of the printed command lines, nil to run the commands
+func SetPrintCommands(writer io.Writer) {
+	commandPrinter = writer
+}
+
+// printingCommands reports whether external commands are printed instead of run.
+func printingCommands() bool {
+	return commandPrinter != nil
+}
+
+// PrintingCommands reports whether external commands are printed instead of run
+// (-print-commands). Steps like signing and notarization then succeed without having
+// changed the bundle.
+func PrintingCommands() bool {
+	return printingCommands()
+}
+
+// safeArgumentPattern matches arguments that need no quoting in a shell command line
+var safeArgumentPattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quoteArgument quotes an argument for a POSIX shell, using single quotes when needed.
+func quoteArgument(argument string) string {
+	if safeArgumentPattern.MatchString(argument) {
+		return argument
+	}
+	return "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+}
+
+// commandLine formats a command as a shell command line, including its working directory
+// and additional environment variables.
+func commandLine(options commandOptions, path string, arguments []string) string {
+	var parts []string
+	if options.directory != "" {
+		parts = append(parts, "cd", quoteArgument(options.directory), "&&")
+	}
+	for _, variable := range options.environment {
+		parts = append(parts, quoteArgument(variable))
+	}
+	parts = append(parts, quoteArgument(path))
+	for _, argument := range arguments {
+		parts = append(parts, quoteArgument(argument))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Default timeouts for external commands (set via SetCommandTimeouts)
+const (
+	defaultCommandTimeout  = 10 * time.Minute // codesign, security, zip, ...
+	defaultNotarizeTimeout = 2 * time.Hour    // notarytool submit --wait
+)
+
+// Timeouts for external commands; zero disables the timeout
+var (
+	commandTimeout  = defaultCommandTimeout
+	notarizeTimeout = defaultNotarizeTimeout
+)
+
+// SetCommandTimeouts sets the maximum run time of external commands. A command running longer
+// is killed and reported as an error. Zero disables the timeout.
+//
+// Parameters:
+//   - command: Timeout for all commands except the notarization submission
+//   - notarize: Timeout for the notarization submission, which waits for Apple's service
+func SetCommandTimeouts(command time.Duration, notarize time.Duration) {
+	commandTimeout = command
+	notarizeTimeout = notarize
+}
+
+// notarizeProgressInterval is the time between the progress messages while waiting for notarization
+var notarizeProgressInterval = 15 * time.Second
+
+// logProgress logs "Still waiting for <activity>" with the elapsed time at every interval
+// until the returned stop function is called, so a long running command is not mistaken
+// for a hanging one.
+//
+// Parameters:
+//   - activity: What is being waited for (e.g. "notarization")
+//   - interval: Time between the messages
+//
+// Returns the function that stops the messages; it must be called once the command returned
+// and returns when no further message is logged.
+func logProgress(activity string, interval time.Duration) (stop func()) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logger.Info("Still waiting for %s, elapsed %s", activity, time.Since(start).Round(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Wait for the goroutine, so no message follows the output of the command
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// runCommand runs an external command with the default command timeout and waits for it
+// to finish. See runCommandWithTimeout.
+func runCommand(path string, arguments ...string) (string, string, error) {
+	return runCommandWithTimeout(commandTimeout, path, arguments...)
+}
+
+// runQuery runs an external command that only reads information, with the default command
+// timeout. Unlike runCommand, it also runs with -print-commands.
+func runQuery(path string, arguments ...string) (string, string, error) {
+	return runCommandWithOptions(commandOptions{timeout: commandTimeout, alwaysRun: true}, path, arguments...)
+}
+
+// runCommandWithTimeout runs an external command with the given timeout and waits for it
+// to finish. See runCommandWithOptions.
+func runCommandWithTimeout(timeout time.Duration, path string, arguments ...string) (string, string, error) {
+	return runCommandWithOptions(commandOptions{timeout: timeout}, path, arguments...)
+}
+
+// commandOptions holds the settings of an external command run by runCommandWithOptions.
+type commandOptions struct {
+	timeout     time.Duration // Maximum run time, the command is killed when it is exceeded (zero for no limit)
+	directory   string        // Working directory (empty for the current directory)
+	environment []string      // Additional environment variables ("KEY=value"), added to the current environment
+	alwaysRun   bool          // Run even with -print-commands (queries and steps producing inputs of later steps)
+}
+
+// runCommandWithOptions runs an external command and waits for it to finish.
+// The complete stdout and stderr are always captured and returned, so callers can parse the
+// output or add it to error messages, independent of the verbose setting.
+//
+// Parameters:
+//   - options: Timeout, working directory and environment of the command
+//   - path: Path of the program (as returned by fileManagement.FindProgramPath)
+//   - arguments: Command-line arguments
+//
+// Returns the captured stdout and stderr, and the error of the command (if any). A command
+// printed instead of run (-print-commands) returns empty output and no error.
+func runCommandWithOptions(options commandOptions, path string, arguments ...string) (string, string, error) {
+	if printingCommands() && !options.alwaysRun {
+		fmt.Fprintln(commandPrinter, commandLine(options, path, arguments))
+		return "", "", nil
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	timeout := options.timeout
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, path, arguments...)
+	cmd.Dir = options.directory
+	if len(options.environment) > 0 {
+		cmd.Env = append(os.Environ(), options.environment...)
+	}
+	// Don't wait forever for output pipes held open by child processes of a killed command
+	cmd.WaitDelay = 5 * time.Second
+
+	if !verboseCommands {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), timeoutError(ctx, timeout, path, arguments, err)
+	}
+
+	logger.Debug("Running: %s %s", path, strings.Join(arguments, " "))
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", "", err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", "", err
+	}
+
+	// Both pipes have to be read completely before calling Wait
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(2)
+	go streamOutput(filepath.Base(path), stdoutPipe, &stdout, &waitGroup)
+	go streamOutput(filepath.Base(path), stderrPipe, &stderr, &waitGroup)
+	waitGroup.Wait()
+
+	err = cmd.Wait()
+	return stdout.String(), stderr.String(), timeoutError(ctx, timeout, path, arguments, err)
+}
+
+// timeoutError replaces the error of a command killed because of its timeout with an error
+// naming the command. Other errors are returned unchanged.
+func timeoutError(ctx context.Context, timeout time.Duration, path string, arguments []string, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command timed out after %v and was killed: %s %s",
+			timeout, filepath.Base(path), strings.Join(arguments, " "))
+	}
+
+	return err
+}
+
+// streamOutput copies the output of a command line by line into the buffer and logs each line.
+func streamOutput(name string, reader io.Reader, buffer *bytes.Buffer, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		buffer.WriteString(line + "\n")
+		logger.Debug("[%s] %s", name, line)
+	}
+}
@@ -0,0 +1,221 @@
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestQuoteArgument(t *testing.T) {
+	tests := []struct {
+		argument string
+		want     string
+	}{
+		{"--force", "--force"},
+		{"build/MyApp.app", "build/MyApp.app"},
+		{"runtime,library", "runtime,library"},
+		{"", "''"},
+		{"My App.app", "'My App.app'"},
+		{"Developer ID Application: Example Inc (ABCDE12345)", "'Developer ID Application: Example Inc (ABCDE12345)'"},
+		{"John's App", `'John'\''s App'`},
+		{"$HOME", "'$HOME'"},
+	}
+
+	for _, test := range tests {
+		if got := quoteArgument(test.argument); got != test.want {
+			t.Errorf("quoteArgument(%q) = %s, want %s", test.argument, got, test.want)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   commandOptions
+		path      string
+		arguments []string
+		want      string
+	}{
+		{
+			name:      "plain",
+			path:      "/usr/bin/xcrun",
+			arguments: []string{"stapler", "staple", "MyApp.app"},
+			want:      "/usr/bin/xcrun stapler staple MyApp.app",
+		},
+		{
+			name:      "directory and environment",
+			options:   commandOptions{directory: "My Sources", environment: []string{"GOOS=darwin", "CGO_CFLAGS=-O2 -g"}},
+			path:      "/usr/local/go/bin/go",
+			arguments: []string{"build", "-o", "/tmp/My App"},
+			want:      "cd 'My Sources' && GOOS=darwin 'CGO_CFLAGS=-O2 -g' /usr/local/go/bin/go build -o '/tmp/My App'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commandLine(test.options, test.path, test.arguments); got != test.want {
+				t.Errorf("commandLine() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestPrintedCommandRunsSameArguments prints a signing command with -print-commands and runs
+// the printed line in a shell, which must pass exactly the arguments the command would get.
+func TestPrintedCommandRunsSameArguments(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no POSIX shell available")
+	}
+
+	// Stand-in for codesign that prints each of its arguments on a line
+	directory := t.TempDir()
+	codeSignPath := filepath.Join(directory, "code sign")
+	if err := os.WriteFile(codeSignPath, []byte("#!/bin/sh\nfor argument in \"$@\"; do printf '%s\\n' \"$argument\"; done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	arguments := []string{
+		"--sign", "Developer ID Application: John's Company (ABCDE12345)",
+		"--force", "--options", "runtime", "--timestamp",
+		"--entitlements", filepath.Join(directory, "My App.entitlements"),
+		filepath.Join(directory, "My App.app"),
+	}
+
+	var printed bytes.Buffer
+	SetPrintCommands(&printed)
+	t.Cleanup(func() { SetPrintCommands(nil) })
+
+	if _, _, err := runCommand(codeSignPath, arguments...); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command(shell, "-c", strings.TrimSuffix(printed.String(), "\n")).Output()
+	if err != nil {
+		t.Fatalf("printed command %q failed: %v", printed.String(), err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n"); !reflect.DeepEqual(got, arguments) {
+		t.Errorf("printed command passes %q, want %q", got, arguments)
+	}
+}
+
+// TestCommandTimeout runs a stand-in for a hanging tool, which must be killed once the
+// timeout is exceeded.
+func TestCommandTimeout(t *testing.T) {
+	directory := t.TempDir()
+	pidFile := filepath.Join(directory, "pid")
+	sleepPath := filepath.Join(directory, "sleep")
+	if err := os.WriteFile(sleepPath, []byte("#!/bin/sh\necho $$ > \""+pidFile+"\"\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verbose=%v", verbose), func(t *testing.T) {
+			SetVerbose(verbose)
+			t.Cleanup(func() { SetVerbose(false) })
+			os.Remove(pidFile)
+
+			start := time.Now()
+			_, _, err := runCommandWithOptions(commandOptions{timeout: 50 * time.Millisecond}, sleepPath, "30")
+			if err == nil || !strings.Contains(err.Error(), "timed out after 50ms and was killed: sleep 30") {
+				t.Fatalf("error = %v, want the timeout naming the command", err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("command returned after %v", elapsed)
+			}
+
+			// If the script got to record its PID, the killed process must be gone
+			content, err := os.ReadFile(pidFile)
+			if os.IsNotExist(err) {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+				t.Errorf("process %d still exists after the timeout (%v)", pid, err)
+			}
+		})
+	}
+}
+
+// captureTestLog writes the log messages into a buffer for the duration of a test.
+func captureTestLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var log bytes.Buffer
+	logger.SetOutput(&log)
+	t.Cleanup(func() { logger.SetOutput(os.Stdout) })
+	return &log
+}
+
+// TestVerboseCommandOutput runs a tool writing to stdout and stderr: with -verbose every line
+// is logged while the tool runs, and the captured output is the same in both modes.
+func TestVerboseCommandOutput(t *testing.T) {
+	toolPath := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(toolPath, []byte("#!/bin/sh\necho first\necho problem >&2\necho second\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verbose=%v", verbose), func(t *testing.T) {
+			SetVerbose(verbose)
+			t.Cleanup(func() { SetVerbose(false) })
+			log := captureTestLog(t)
+
+			stdout, stderr, err := runCommand(toolPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stdout != "first\nsecond\n" || stderr != "problem\n" {
+				t.Errorf("captured stdout %q and stderr %q", stdout, stderr)
+			}
+
+			for _, line := range []string{"[tool] first", "[tool] problem", "[tool] second"} {
+				if logged := strings.Contains(log.String(), line); logged != verbose {
+					t.Errorf("%q logged: %v, want %v", line, logged, verbose)
+				}
+			}
+		})
+	}
+}
+
+// TestLogProgress runs a slow fake command while the progress is logged: at least one
+// "still waiting" line is emitted, and none after the progress was stopped.
+func TestLogProgress(t *testing.T) {
+	toolPath := filepath.Join(t.TempDir(), "notarytool")
+	if err := os.WriteFile(toolPath, []byte("#!/bin/sh\nsleep 0.5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := captureTestLog(t)
+
+	stopProgress := logProgress("notarization", 50*time.Millisecond)
+	_, _, err := runCommand(toolPath)
+	stopProgress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged := log.String()
+	if !strings.Contains(logged, "Still waiting for notarization, elapsed") {
+		t.Errorf("log = %q, want a progress line", logged)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if log.String() != logged {
+		t.Errorf("progress logged after stop: %q", strings.TrimPrefix(log.String(), logged))
+	}
+}
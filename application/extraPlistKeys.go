@@ -0,0 +1,178 @@
+// Package application: This file handles additional Info.plist keys that have no dedicated
+// configuration field. They can be listed under extra_plist_keys, or written directly at the
+// top level of the configuration when the key looks like an Info.plist key (e.g.
+// NSMicrophoneUsageDescription). Other unknown top-level keys are reported and ignored.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// plistKeyPattern matches key names following Apple's Info.plist naming, which are picked up
+// automatically from the top level of the configuration (Cocoa, Core Foundation, Launch
+// Services, UIKit, GameKit, MapKit, WebKit, App Transport Security, Sparkle, ...).
+var plistKeyPattern = regexp.MustCompile(`^(NS|CF|LS|UI|GK|MK|WK|ATS|SU|IN)[A-Z][A-Za-z0-9]*$`)
+
+// managedPlistKeys lists the keys written from dedicated configuration fields.
+// They cannot be set as extra keys, as the plist would contain them twice.
+var managedPlistKeys = []string{
+	"CFBundleIdentifier", "CFBundleName", "CFBundleDevelopmentRegion", "CFBundleDisplayName",
+	"CFBundleVersion", "CFBundleShortVersionString", "CFBundleExecutable", "CFBundleSignature",
+	"LSMinimumSystemVersion", "CFBundleIconFile", "CFBundlePackageType", "NSHumanReadableCopyright",
+	"NSPrincipalClass", "NSMainNibFile", "CFBundleAllowMixedLocalizations", "CFBundleDocumentTypes",
+	"NSServices", "CFBundleInfoDictionaryVersion", "LSMultipleInstancesProhibited",
+	"CFBundleHelpBookFolder", "CFBundleHelpBookName", "CFBundleIconName", "LSMinimumSystemVersionByArchitecture",
+	"CFBundleSpokenName",
+}
+
+// knownConfigKeys returns the top-level keys of the configuration file, taken from the
+// yaml tags of packageParameter.
+func knownConfigKeys() map[string]bool {
+	keys := make(map[string]bool)
+
+	parameterType := reflect.TypeOf(packageParameter{})
+	for index := 0; index < parameterType.NumField(); index++ {
+		tag := strings.Split(parameterType.Field(index).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			keys[tag] = true
+		}
+	}
+
+	return keys
+}
+
+// collectUnknownKeys runs a second pass over the configuration data and handles the top-level
+// keys not modeled by packageParameter: keys looking like Info.plist keys are added to the
+// extra plist keys (an entry under extra_plist_keys takes precedence), others are reported.
+//
+// Parameters:
+//   - data: Content of the configuration file
+//   - parameters: The configuration parsed from data, receiving the extra plist keys
+//
+// Returns an error if the data cannot be parsed.
+func collectUnknownKeys(data []byte, parameters *packageParameter) error {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	knownKeys := knownConfigKeys()
+
+	var unknownKeys []string
+	for key := range document {
+		if !knownKeys[key] {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	sort.Strings(unknownKeys)
+
+	for _, key := range unknownKeys {
+		if !plistKeyPattern.MatchString(key) {
+			logger.Warn("Unknown configuration key %q is ignored", key)
+			continue
+		}
+
+		if parameters.ExtraPlistKeys == nil {
+			parameters.ExtraPlistKeys = make(map[string]interface{})
+		}
+		if _, found := parameters.ExtraPlistKeys[key]; found {
+			logger.Warn("Key %q is set at the top level and in extra_plist_keys, using extra_plist_keys", key)
+			continue
+		}
+
+		logger.Debug("Adding configuration key %s to Info.plist", key)
+		parameters.ExtraPlistKeys[key] = document[key]
+	}
+
+	return nil
+}
+
+// validateExtraPlistKeys checks that no extra key replaces a managed key and that every
+// value can be written to the plist.
+func validateExtraPlistKeys(extraKeys map[string]interface{}) error {
+	for _, key := range managedPlistKeys {
+		if _, found := extraKeys[key]; found {
+			return fmt.Errorf("extra plist key %q is set by the configuration, use the dedicated field instead", key)
+		}
+	}
+
+	for key, value := range extraKeys {
+		if _, err := renderPlistValue(value); err != nil {
+			return fmt.Errorf("invalid value for extra plist key %q: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// escapePlistText escapes a string for use as XML text content.
+func escapePlistText(text string) string {
+	var buffer bytes.Buffer
+	xml.EscapeText(&buffer, []byte(text))
+	return buffer.String()
+}
+
+// renderPlistValue converts a value decoded from YAML into its XML plist representation:
+// string -> <string>, bool -> <true/>/<false/>, integers -> <integer>, floats -> <real>,
+// timestamps -> <date>, lists -> <array> and maps -> <dict> (keys sorted).
+//
+// Returns an error for values without a plist representation (e.g. an empty YAML value).
+func renderPlistValue(value interface{}) (string, error) {
+	switch typedValue := value.(type) {
+	case string:
+		return "<string>" + escapePlistText(typedValue) + "</string>", nil
+	case bool:
+		if typedValue {
+			return "<true/>", nil
+		}
+		return "<false/>", nil
+	case int, int64, uint64:
+		return fmt.Sprintf("<integer>%d</integer>", typedValue), nil
+	case float64:
+		return fmt.Sprintf("<real>%v</real>", typedValue), nil
+	case time.Time:
+		return "<date>" + typedValue.UTC().Format(time.RFC3339) + "</date>", nil
+	case []interface{}:
+		var builder strings.Builder
+		builder.WriteString("<array>")
+		for _, element := range typedValue {
+			rendered, err := renderPlistValue(element)
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(rendered)
+		}
+		builder.WriteString("</array>")
+		return builder.String(), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var builder strings.Builder
+		builder.WriteString("<dict>")
+		for _, key := range keys {
+			rendered, err := renderPlistValue(typedValue[key])
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString("<key>" + escapePlistText(key) + "</key>" + rendered)
+		}
+		builder.WriteString("</dict>")
+		return builder.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v (%T)", value, value)
+	}
+}
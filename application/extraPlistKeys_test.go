@@ -0,0 +1,109 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCollectUnknownKeys reads configurations with top-level Info.plist keys and checks
+// which keys end up in the rendered Info.plist.
+func TestCollectUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantKeys    map[string]interface{}
+		wantMissing []string
+	}{
+		{
+			name:     "top-level usage description",
+			config:   "NSMicrophoneUsageDescription: Records voice notes\n",
+			wantKeys: map[string]interface{}{"NSMicrophoneUsageDescription": "Records voice notes"},
+		},
+		{
+			name:     "top-level and extra keys",
+			config:   "NSMicrophoneUsageDescription: Records voice notes\nextra_plist_keys:\n  NSCameraUsageDescription: Scans documents\n",
+			wantKeys: map[string]interface{}{"NSMicrophoneUsageDescription": "Records voice notes", "NSCameraUsageDescription": "Scans documents"},
+		},
+		{
+			name:     "extra_plist_keys takes precedence",
+			config:   "NSMicrophoneUsageDescription: top level\nextra_plist_keys:\n  NSMicrophoneUsageDescription: extra key\n",
+			wantKeys: map[string]interface{}{"NSMicrophoneUsageDescription": "extra key"},
+		},
+		{
+			name:     "non-string values",
+			config:   "LSUIElement: true\nNSSupportsAutomaticTermination: false\n",
+			wantKeys: map[string]interface{}{"LSUIElement": true, "NSSupportsAutomaticTermination": false},
+		},
+		{
+			name:        "unknown keys are ignored",
+			config:      "microphone_usage: Records voice notes\nNSmicrophone: lowercase\n",
+			wantMissing: []string{"microphone_usage", "NSmicrophone"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, testPlistConfig+test.config); err != nil {
+				t.Fatal(err)
+			}
+			if err := validateExtraPlistKeys(GetExtraPlistKeys()); err != nil {
+				t.Fatal(err)
+			}
+
+			dictionary := renderTestPlist(t, NewInfoPlistData())
+			for key, want := range test.wantKeys {
+				if got := dictionary[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			for _, key := range test.wantMissing {
+				if _, found := dictionary[key]; found {
+					t.Errorf("%s is written to Info.plist", key)
+				}
+			}
+		})
+	}
+}
+
+// TestManagedKeysNotDuplicated sets managed keys at the top level and in extra_plist_keys:
+// both are rejected, so a key written from a dedicated field never appears twice.
+func TestManagedKeysNotDuplicated(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		key    string
+	}{
+		{"top-level key", "CFBundleIdentifier: com.example.other\n", "CFBundleIdentifier"},
+		{"top-level icon", "CFBundleIconFile: Other.icns\n", "CFBundleIconFile"},
+		{"extra key", "extra_plist_keys:\n  LSMinimumSystemVersion: \"10.13\"\n", "LSMinimumSystemVersion"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, testPlistConfig+test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := validateExtraPlistKeys(GetExtraPlistKeys())
+			if err == nil || !strings.Contains(err.Error(), test.key) {
+				t.Errorf("error = %v, want it to contain %q", err, test.key)
+			}
+		})
+	}
+
+	t.Run("rendered keys", func(t *testing.T) {
+		if err := readTestConfig(t, testPlistConfig+"NSMicrophoneUsageDescription: Records voice notes\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := RenderPlist(NewInfoPlistData())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range append(managedPlistKeys, "NSMicrophoneUsageDescription") {
+			if count := strings.Count(content, "<key>"+key+"</key>"); count > 1 {
+				t.Errorf("%s is written %d times", key, count)
+			}
+		}
+	})
+}
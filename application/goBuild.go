@@ -0,0 +1,152 @@
+// Package application: This file builds the executable from a Go package.
+// Instead of pointing exec_file at a prebuilt binary, Go developers can set go_package:
+// the package is compiled with "go build" for macOS (GOOS/GOARCH) into a temporary
+// directory and the result is copied into the bundle like any other compiled binary.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// goArchitecture maps a Mach-O architecture name to the matching GOARCH value.
+func goArchitecture(architecture string) string {
+	if architecture == "x86_64" {
+		return "amd64"
+	}
+
+	return architecture
+}
+
+// goBuildArguments returns the arguments of the "go build" call writing the binary to output.
+//
+// Parameters:
+//   - output: Path of the binary to create
+//   - ldflags: Linker flags passed with -ldflags (empty for none)
+func goBuildArguments(output string, ldflags string) []string {
+	arguments := []string{"build"}
+	if ldflags != "" {
+		arguments = append(arguments, "-ldflags", ldflags)
+	}
+
+	// The package is built from its own directory, so "." is the package to build
+	return append(arguments, "-o", output, ".")
+}
+
+// buildGoPackage compiles the configured Go package and copies the binary into Contents/MacOS/.
+// The binary is named after the bundle executable (CFBundleExecutable). With go_architectures,
+// the package is built once per architecture and the binaries are combined with lipo into a
+// universal binary.
+//
+// Returns an error if the go tool is not found, the build fails or the copy fails.
+func buildGoPackage() error {
+	goPath, err := fileManagement.FindProgramPath("go")
+	if err != nil {
+		return err
+	}
+
+	buildDirectory, err := os.MkdirTemp("", "appbundler-go-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(buildDirectory)
+
+	output := filepath.Join(buildDirectory, GetBundleExecutable())
+
+	if architectures := GetGoArchitectures(); len(architectures) > 0 {
+		err = buildUniversalGoBinary(goPath, architectures, buildDirectory, output)
+	} else {
+		err = runGoBuild(goPath, GetGoArch(), output)
+	}
+	if err != nil {
+		return err
+	}
+
+	return copyCompExec(output)
+}
+
+// runGoBuild compiles the configured Go package for one GOARCH into output.
+func runGoBuild(goPath string, goArch string, output string) error {
+	packageDirectory := GetGoPackage()
+
+	logger.Info("Building Go package %s (GOOS=%s, GOARCH=%s)", packageDirectory, GetGoOS(), goArch)
+
+	options := commandOptions{
+		timeout:     commandTimeout,
+		directory:   packageDirectory,
+		environment: []string{"GOOS=" + GetGoOS(), "GOARCH=" + goArch},
+		alwaysRun:   true, // The binary is copied into the bundle
+	}
+	_, stderr, err := runCommandWithOptions(options, goPath, goBuildArguments(output, GetGoLdflags())...)
+	if err != nil {
+		return fmt.Errorf("failed to build Go package %q: %v\n%s", packageDirectory, err, stderr)
+	}
+
+	return nil
+}
+
+// buildUniversalGoBinary compiles the configured Go package for each GOARCH into its own
+// subdirectory of buildDirectory and combines the binaries into a universal binary by running:
+// lipo -create -output <output> <binaries...>
+//
+// Returns an error if the Go toolchain cannot build one of the targets, a build fails or
+// lipo fails.
+func buildUniversalGoBinary(goPath string, architectures []string, buildDirectory string, output string) error {
+	if err := checkGoTargets(goPath, architectures); err != nil {
+		return err
+	}
+
+	lipoPath, err := fileManagement.FindProgramPath("lipo")
+	if err != nil {
+		return err
+	}
+
+	lipoArguments := []string{"-create", "-output", output}
+	for _, goArch := range architectures {
+		architectureDirectory := filepath.Join(buildDirectory, goArch)
+		if err := os.Mkdir(architectureDirectory, 0755); err != nil {
+			return err
+		}
+
+		binary := filepath.Join(architectureDirectory, GetBundleExecutable())
+		if err := runGoBuild(goPath, goArch, binary); err != nil {
+			return err
+		}
+		lipoArguments = append(lipoArguments, binary)
+	}
+
+	logger.Info("Combining %s into a universal binary", strings.Join(architectures, ", "))
+
+	// The universal binary is copied into the bundle, so lipo also runs with -print-commands
+	options := commandOptions{timeout: commandTimeout, alwaysRun: true}
+	_, stderr, err := runCommandWithOptions(options, lipoPath, lipoArguments...)
+	if err != nil {
+		return fmt.Errorf("failed to create universal binary: %v\n%s", err, stderr)
+	}
+
+	return nil
+}
+
+// checkGoTargets makes sure the Go toolchain can build the package for every architecture,
+// using the platform list of "go tool dist list" (e.g. "darwin/arm64").
+func checkGoTargets(goPath string, architectures []string) error {
+	stdout, stderr, err := runQuery(goPath, "tool", "dist", "list")
+	if err != nil {
+		return fmt.Errorf("failed to list the targets of the Go toolchain: %v\n%s", err, stderr)
+	}
+
+	platforms := strings.Fields(stdout)
+	for _, goArch := range architectures {
+		platform := GetGoOS() + "/" + goArch
+		if !slices.Contains(platforms, platform) {
+			return fmt.Errorf("the Go toolchain %s cannot build for %s", goPath, platform)
+		}
+	}
+
+	return nil
+}
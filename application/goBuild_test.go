@@ -0,0 +1,123 @@
+package application
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testGoProgram is a trivial Go program whose version is set with -ldflags.
+const testGoProgram = `package main
+
+import "fmt"
+
+var version = "unset"
+
+func main() {
+	fmt.Println(version)
+}
+`
+
+// TestBuildGoPackage builds a trivial Go program into a bundle: the bundle executable is a
+// Mach-O binary built with the configured linker flags.
+func TestBuildGoPackage(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not available")
+	}
+	packageDirectory := t.TempDir()
+	writeBundleFile(t, packageDirectory, "go.mod", "module example.com/hello\n\ngo 1.23\n")
+	writeBundleFile(t, packageDirectory, "main.go", testGoProgram)
+
+	const version = "appbundler-test-version"
+	setTestBundle(t, packageParameter{
+		GoPackage: packageDirectory, BundleExecutable: "MyApp", GoArch: "arm64",
+		GoLdflags: "-X main.version=" + version,
+	})
+
+	if err := CopyExecutable(); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := readBundleFile(t, macosDir, "MyApp")
+	if executableType, err := detectExecutableType(filepath.Join(macosDir, "MyApp")); err != nil || executableType != executableTypeMachO {
+		t.Errorf("bundle executable is %s (%v), want %s", executableType, err, executableTypeMachO)
+	}
+	if !strings.Contains(binary, version) {
+		t.Errorf("bundle executable was not built with -ldflags %q", "-X main.version="+version)
+	}
+}
+
+// testFakeLipo stands in for lipo where it is not installed: -create records the slices,
+// named after the GOARCH directories of the binaries, and -info reports them.
+const testFakeLipo = `#!/bin/sh
+case "$1" in
+-create)
+	output=$3
+	shift 3
+	architectures=""
+	for binary in "$@"; do
+		architecture=$(basename "$(dirname "$binary")")
+		[ "$architecture" = amd64 ] && architecture=x86_64
+		architectures="$architectures $architecture"
+	done
+	echo "Architectures in the fat file: $output are:$architectures" > "$output"
+	;;
+-info)
+	cat "$2"
+	;;
+*)
+	exit 1
+	;;
+esac
+`
+
+// TestBuildUniversalGoPackage builds a trivial Go program for arm64 and amd64: the bundle
+// executable is a universal binary with both slices, and the temporary builds are removed.
+func TestBuildUniversalGoPackage(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not available")
+	}
+	if _, err := exec.LookPath("lipo"); err != nil {
+		directory := t.TempDir()
+		writeBundleFile(t, directory, "lipo", testFakeLipo)
+		if err := os.Chmod(filepath.Join(directory, "lipo"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", directory+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	temporaryDirectory := t.TempDir()
+	t.Setenv("TMPDIR", temporaryDirectory)
+
+	packageDirectory := t.TempDir()
+	writeBundleFile(t, packageDirectory, "go.mod", "module example.com/hello\n\ngo 1.23\n")
+	writeBundleFile(t, packageDirectory, "main.go", testGoProgram)
+	setTestBundle(t, packageParameter{
+		GoPackage: packageDirectory, BundleExecutable: "MyApp", GoArchitectures: []string{"arm64", "amd64"},
+	})
+
+	if err := CopyExecutable(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := exec.Command("lipo", "-info", filepath.Join(macosDir, "MyApp")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("lipo -info failed: %v\n%s", err, info)
+	}
+	for _, architecture := range []string{"x86_64", "arm64"} {
+		if !strings.Contains(string(info), architecture) {
+			t.Errorf("lipo -info = %q, want the %s slice", info, architecture)
+		}
+	}
+
+	entries, err := os.ReadDir(temporaryDirectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "appbundler-go-") {
+			t.Errorf("temporary build directory %s was not removed", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package application: This file reads the name and version of a Java application from the
+// manifest of its JAR (metadata_from_manifest). Build tools like Maven and Gradle usually
+// write Implementation-Title and Implementation-Version into META-INF/MANIFEST.MF, so the
+// values don't have to be repeated in the configuration.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// jarManifestPath is the location of the manifest inside a JAR
+const jarManifestPath = "META-INF/MANIFEST.MF"
+
+// readJarManifest returns the attributes of the main section of a JAR manifest.
+//
+// Parameters:
+//   - jarPath: Path to the JAR file
+//
+// Returns an error if the file is not a ZIP archive or has no manifest.
+func readJarManifest(jarPath string) (map[string]string, error) {
+	archive, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JAR %s: %v", jarPath, err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != jarManifestPath {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the manifest of %s: %v", jarPath, err)
+		}
+		defer reader.Close()
+
+		return parseJarManifest(bufio.NewScanner(reader))
+	}
+
+	return nil, fmt.Errorf("%s has no %s", jarPath, jarManifestPath)
+}
+
+// parseJarManifest parses the main section of a manifest, which ends at the first empty
+// line. Lines starting with a space continue the value of the previous line (manifest lines
+// are wrapped at 72 bytes).
+func parseJarManifest(scanner *bufio.Scanner) (map[string]string, error) {
+	attributes := map[string]string{}
+	lastName := ""
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+
+		if strings.HasPrefix(line, " ") {
+			if lastName != "" {
+				attributes[lastName] += line[1:]
+			}
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		lastName = strings.TrimSpace(name)
+		attributes[lastName] = strings.TrimPrefix(value, " ")
+	}
+
+	return attributes, scanner.Err()
+}
+
+// applyManifestMetadata fills an empty name (CFBundleName) and short_version_string
+// (CFBundleShortVersionString) with Implementation-Title and Implementation-Version of the
+// JAR manifest, if metadata_from_manifest is set. Configured values always win.
+//
+// Returns an error if the executable is not a local JAR or its manifest cannot be read.
+func applyManifestMetadata() error {
+	if !packageInfo.MetadataFromManifest {
+		return nil
+	}
+	if packageInfo.BundleName != "" && packageInfo.CFBundleShortVersionString != "" {
+		return nil
+	}
+
+	jarPath := GetExecutablePath()
+	if GetGoPackage() != "" || isExecutableURL(jarPath) {
+		return fmt.Errorf("metadata_from_manifest requires a local JAR as exec_file")
+	}
+
+	attributes, err := readJarManifest(jarPath)
+	if err != nil {
+		return err
+	}
+
+	if title := strings.TrimSpace(attributes["Implementation-Title"]); packageInfo.BundleName == "" && title != "" {
+		logger.Info("Using name %q from the manifest of %s", title, jarPath)
+		packageInfo.BundleName = title
+	}
+	if version := strings.TrimSpace(attributes["Implementation-Version"]); packageInfo.CFBundleShortVersionString == "" && version != "" {
+		logger.Info("Using short_version_string %q from the manifest of %s", version, jarPath)
+		packageInfo.CFBundleShortVersionString = version
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestJar writes a JAR with the given entries (name -> content) into a directory.
+func writeTestJar(t *testing.T, directory string, name string, entries map[string]string) {
+	t.Helper()
+	file, err := os.Create(filepath.Join(directory, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for entryName, content := range entries {
+		writer, err := archive.Create(entryName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMetadataFromManifest reads a configuration with metadata_from_manifest for a fixture
+// JAR: the manifest supplies the missing name and short version, configured values win.
+func TestMetadataFromManifest(t *testing.T) {
+	// The title is wrapped onto a continuation line (manifest lines are limited to 72 bytes);
+	// the first space of the continuation is dropped, the second belongs to the value
+	manifest := "Manifest-Version: 1.0\r\n" +
+		"Main-Class: com.example.Main\r\n" +
+		"Implementation-Title: Manifest\r\n" +
+		"  App\r\n" +
+		"Implementation-Version: 2.4.1\r\n" +
+		"\r\n" +
+		"Name: com/example/\r\n" +
+		"Implementation-Version: 9.9.9\r\n"
+
+	tests := []struct {
+		name        string
+		config      string
+		entries     map[string]string
+		wantName    string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "values from the manifest",
+			entries:     map[string]string{jarManifestPath: manifest},
+			wantName:    "Manifest App",
+			wantVersion: "2.4.1",
+		},
+		{
+			name:        "configured values win",
+			config:      "name: MyApp\nshort_version_string: 1.0.0\n",
+			entries:     map[string]string{jarManifestPath: manifest},
+			wantName:    "MyApp",
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "only the version from the manifest",
+			config:      "name: MyApp\n",
+			entries:     map[string]string{jarManifestPath: manifest},
+			wantName:    "MyApp",
+			wantVersion: "2.4.1",
+		},
+		{
+			name:    "JAR without manifest",
+			entries: map[string]string{"com/example/Main.class": "class"},
+			wantErr: "has no META-INF/MANIFEST.MF",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{})
+			previousBaseDirectory := configBaseDirectory
+			t.Cleanup(func() { configBaseDirectory = previousBaseDirectory })
+
+			directory := t.TempDir()
+			writeTestJar(t, directory, "MyApp.jar", test.entries)
+			config := "exec_file_directory: " + directory + "\nexec_file: MyApp.jar\nmetadata_from_manifest: true\n" + test.config
+			packageFileName := filepath.Join(directory, "application.yaml")
+			if err := os.WriteFile(packageFileName, []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Read(packageFileName)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := GetBundleName(); got != test.wantName {
+				t.Errorf("name = %q, want %q", got, test.wantName)
+			}
+			if got := GetCFBundleShortVersionString(); got != test.wantVersion {
+				t.Errorf("short version = %q, want %q", got, test.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package application: This file determines the Java modules a JAR application needs.
+// A runtime created with jlink only contains the modules passed with --add-modules; jdeps
+// analyzes the class files of the application and prints the required modules, so the
+// list does not have to be found by trial and error.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// jdepsCandidates returns the locations checked for jdeps, in order: the given Java home
+// (a JDK home or a .jdk bundle), then $JAVA_HOME.
+func jdepsCandidates(javaHome string) []string {
+	var candidates []string
+
+	for _, home := range []string{javaHome, os.Getenv("JAVA_HOME")} {
+		if home == "" {
+			continue
+		}
+		candidates = append(candidates,
+			filepath.Join(home, "bin", "jdeps"),
+			filepath.Join(home, "Contents", "Home", "bin", "jdeps"))
+	}
+
+	return candidates
+}
+
+// findJdeps returns the path of the jdeps tool, looked up under the Java home first and in
+// the PATH otherwise.
+func findJdeps(javaHome string) (string, error) {
+	for _, candidate := range jdepsCandidates(javaHome) {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return fileManagement.FindProgramPath("jdeps")
+}
+
+// jdepsArguments returns the jdeps arguments printing the modules needed by a JAR.
+func jdepsArguments(mainJar string, classPath []string) []string {
+	arguments := []string{"--print-module-deps", "--ignore-missing-deps"}
+	if len(classPath) > 0 {
+		arguments = append(arguments, "--class-path", strings.Join(classPath, string(os.PathListSeparator)))
+	}
+
+	return append(arguments, mainJar)
+}
+
+// parseModuleList extracts the module list from "jdeps --print-module-deps" output, which
+// ends with a comma-separated line like "java.base,java.desktop,java.logging". Warnings
+// printed before it are ignored.
+func parseModuleList(output string) []string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return nil
+	}
+
+	var modules []string
+	for _, module := range strings.Split(last, ",") {
+		if module = strings.TrimSpace(module); module != "" {
+			modules = append(modules, module)
+		}
+	}
+
+	return modules
+}
+
+// JavaModuleDependencies runs jdeps on a JAR and returns the Java modules it needs, ready
+// to be passed to jlink --add-modules.
+//
+// Parameters:
+//   - mainJar: Path to the application JAR
+//   - classPath: Additional JARs the application loads (may be empty)
+//   - javaHome: Java home to take jdeps from ("" to use $JAVA_HOME or the PATH)
+//
+// Returns the module names, or an error if a JAR does not exist, jdeps is not found or
+// the analysis fails.
+func JavaModuleDependencies(mainJar string, classPath []string, javaHome string) ([]string, error) {
+	for _, jar := range append([]string{mainJar}, classPath...) {
+		if _, err := os.Stat(jar); err != nil {
+			return nil, fmt.Errorf("JAR not found: %v", err)
+		}
+	}
+
+	jdepsPath, err := findJdeps(javaHome)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Analyzing %s with %s", mainJar, jdepsPath)
+
+	stdout, stderr, err := runQuery(jdepsPath, jdepsArguments(mainJar, classPath)...)
+	if err != nil {
+		return nil, fmt.Errorf("jdeps failed for %q: %v\n%s", mainJar, err, stderr)
+	}
+
+	modules := parseModuleList(stdout)
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("jdeps reported no modules for %q", mainJar)
+	}
+
+	return modules, nil
+}
@@ -0,0 +1,71 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeJdeps records its arguments in the file "calls" next to it and prints the output of
+// "jdeps --print-module-deps" with a warning before the module list.
+const fakeJdeps = `#!/bin/sh
+echo "$*" > "$(dirname "$0")/calls"
+echo "Warning: split package: javax.annotation"
+echo "java.base,java.desktop,java.logging"
+`
+
+// TestJavaModuleDependencies analyzes a fixture JAR with a fake jdeps in the Java home: the
+// module list of the jdeps output is reported.
+func TestJavaModuleDependencies(t *testing.T) {
+	t.Setenv("JAVA_HOME", "")
+	javaHome := t.TempDir()
+	writeBundleFile(t, javaHome, "bin/jdeps", fakeJdeps)
+	if err := os.Chmod(filepath.Join(javaHome, "bin", "jdeps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	jarDirectory := t.TempDir()
+	for _, name := range []string{"MyApp.jar", "lib1.jar", "lib2.jar"} {
+		writeBundleFile(t, jarDirectory, name, testJarContent)
+	}
+	mainJar := filepath.Join(jarDirectory, "MyApp.jar")
+	library1, library2 := filepath.Join(jarDirectory, "lib1.jar"), filepath.Join(jarDirectory, "lib2.jar")
+
+	tests := []struct {
+		name      string
+		classPath []string
+		wantCall  string
+		wantErr   string
+	}{
+		{name: "main JAR", wantCall: "--print-module-deps --ignore-missing-deps " + mainJar},
+		{
+			name:      "class path",
+			classPath: []string{library1, library2},
+			wantCall:  "--print-module-deps --ignore-missing-deps --class-path " + library1 + string(os.PathListSeparator) + library2 + " " + mainJar,
+		},
+		{name: "missing JAR", classPath: []string{filepath.Join(jarDirectory, "missing.jar")}, wantErr: "JAR not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modules, err := JavaModuleDependencies(mainJar, test.classPath, javaHome)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := []string{"java.base", "java.desktop", "java.logging"}; !reflect.DeepEqual(modules, want) {
+				t.Errorf("modules = %q, want %q", modules, want)
+			}
+			if calls := readTestCalls(t, filepath.Join(javaHome, "bin", "calls")); len(calls) != 1 || calls[0] != test.wantCall {
+				t.Errorf("jdeps calls = %q, want %q", calls, test.wantCall)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package application: This file places a launchd property list into the bundle.
+// Background agents are started by launchd instead of a double-click. Since macOS 13 an
+// application registers the agents it ships in Contents/Library/LaunchAgents/ with
+// SMAppService; the plist references the executable relative to the bundle (BundleProgram),
+// so the bundle keeps working wherever it is installed.
+//
+// Example configuration:
+//
+//	launch_agent:
+//	  label: com.example.myapp.agent
+//	  arguments: [--background]
+//	  run_at_load: true
+//	  keep_alive: true
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// LaunchAgent describes the launchd property list written into Contents/Library/LaunchAgents/.
+type LaunchAgent struct {
+	Label      string   `yaml:"label"`       // launchd label and plist file name, defaults to the bundle identifier
+	Executable string   `yaml:"executable"`  // Program relative to the bundle, defaults to Contents/MacOS/<executable>
+	Arguments  []string `yaml:"arguments"`   // Arguments passed to the program (ProgramArguments after the program)
+	RunAtLoad  bool     `yaml:"run_at_load"` // true to start the agent when it is loaded (RunAtLoad)
+	KeepAlive  bool     `yaml:"keep_alive"`  // true to restart the agent when it exits (KeepAlive)
+}
+
+// launchAgentTemplate is an XML template for the launchd property list of the agent.
+const launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>{{escape .Agent.Label}}</string>
+    <key>BundleProgram</key>
+    <string>{{escape .Agent.Executable}}</string>
+    <key>ProgramArguments</key>
+    <array>
+        <string>{{escape .Agent.Executable}}</string>{{range .Agent.Arguments}}
+        <string>{{escape .}}</string>{{end}}
+    </array>
+    <key>AssociatedBundleIdentifiers</key>
+    <array>
+        <string>{{escape .BundleIdentifier}}</string>
+    </array>
+    <key>RunAtLoad</key>
+    {{if .Agent.RunAtLoad}}<true/>{{else}}<false/>{{end}}
+    <key>KeepAlive</key>
+    {{if .Agent.KeepAlive}}<true/>{{else}}<false/>{{end}}
+</dict>
+</plist>
+`
+
+// GetLaunchAgent returns the configured launch agent with defaults applied, or nil if the
+// configuration has no launch_agent section.
+func GetLaunchAgent() *LaunchAgent {
+	if packageInfo.LaunchAgent == nil {
+		return nil
+	}
+
+	agent := *packageInfo.LaunchAgent
+	if agent.Label == "" {
+		agent.Label = GetBundleIdentifier()
+	}
+	if agent.Executable == "" {
+		agent.Executable = path.Join("Contents", "MacOS", GetBundleExecutable())
+	}
+
+	return &agent
+}
+
+// validateLaunchAgent checks the launch agent configuration.
+func validateLaunchAgent(agent *LaunchAgent) error {
+	if agent.Label == "" || strings.ContainsAny(agent.Label, "/\\") {
+		return fmt.Errorf("invalid launch_agent label %q: must be a non-empty name without path separators", agent.Label)
+	}
+	if path.IsAbs(agent.Executable) || strings.HasPrefix(path.Clean(agent.Executable), "..") {
+		return fmt.Errorf("invalid launch_agent executable %q: must be a path inside the bundle", agent.Executable)
+	}
+
+	return nil
+}
+
+// renderLaunchAgent renders the launchd property list of the agent.
+func renderLaunchAgent(agent *LaunchAgent) (string, error) {
+	tmpl, err := template.New("launchAgent").Funcs(template.FuncMap{
+		"escape": escapePlistText,
+	}).Parse(launchAgentTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Agent            *LaunchAgent
+		BundleIdentifier string
+	}{agent, GetBundleIdentifier()}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// CreateLaunchAgent writes Contents/Library/LaunchAgents/<label>.plist if a launch agent is
+// configured. Nothing is done otherwise.
+//
+// Returns an error if the configuration is invalid or the file cannot be written.
+func CreateLaunchAgent() error {
+	agent := GetLaunchAgent()
+	if agent == nil {
+		return nil
+	}
+
+	content, err := renderLaunchAgent(agent)
+	if err != nil {
+		return err
+	}
+
+	launchAgentsDir := filepath.Join(contentsDir, "Library", "LaunchAgents")
+	if err := createDir(launchAgentsDir); err != nil {
+		return cleanAfterError(err)
+	}
+
+	plistFileName := filepath.Join(launchAgentsDir, agent.Label+".plist")
+	if err := tracedWriteFile(plistFileName, []byte(content), 0644); err != nil {
+		return cleanAfterError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestCreateLaunchAgent writes the launchd plist of a configured agent and checks that it
+// references the program inside the bundle.
+func TestCreateLaunchAgent(t *testing.T) {
+	tests := []struct {
+		name          string
+		agent         *LaunchAgent
+		wantFile      string
+		wantProgram   string
+		wantArguments []interface{}
+	}{
+		{
+			name:          "defaults",
+			agent:         &LaunchAgent{},
+			wantFile:      "com.example.myapp.plist",
+			wantProgram:   "Contents/MacOS/MyApp",
+			wantArguments: []interface{}{"Contents/MacOS/MyApp"},
+		},
+		{
+			name:          "helper with arguments",
+			agent:         &LaunchAgent{Label: "com.example.myapp.agent", Executable: "Contents/Resources/helper", Arguments: []string{"--background"}, RunAtLoad: true},
+			wantFile:      "com.example.myapp.agent.plist",
+			wantProgram:   "Contents/Resources/helper",
+			wantArguments: []interface{}{"Contents/Resources/helper", "--background"},
+		},
+		{name: "no launch agent"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestBundle(t, packageParameter{BundleIdentifier: "com.example.myapp", BundleExecutable: "MyApp", LaunchAgent: test.agent})
+
+			if err := CreateLaunchAgent(); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.agent == nil {
+				if _, err := os.Stat(filepath.Join(contentsDir, "Library")); !os.IsNotExist(err) {
+					t.Errorf("Contents/Library exists without a launch agent: %v", err)
+				}
+				return
+			}
+
+			root, err := decodePlist([]byte(readBundleFile(t, contentsDir, "Library/LaunchAgents/"+test.wantFile)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dictionary, _ := root.(map[string]interface{})
+			if dictionary["BundleProgram"] != test.wantProgram {
+				t.Errorf("BundleProgram = %v, want %s", dictionary["BundleProgram"], test.wantProgram)
+			}
+			if !reflect.DeepEqual(dictionary["ProgramArguments"], test.wantArguments) {
+				t.Errorf("ProgramArguments = %v, want %v", dictionary["ProgramArguments"], test.wantArguments)
+			}
+			if dictionary["RunAtLoad"] != test.agent.RunAtLoad {
+				t.Errorf("RunAtLoad = %v, want %v", dictionary["RunAtLoad"], test.agent.RunAtLoad)
+			}
+		})
+	}
+}
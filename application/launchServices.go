@@ -0,0 +1,139 @@
+// Package application: This file reports the document types LaunchServices registered for
+// a bundle (-list-document-types). Finder only opens documents with an application after
+// LaunchServices picked up its CFBundleDocumentTypes; "lsregister -dump" shows the
+// registration database, so the associations can be checked without logging out.
+// Nothing is registered or changed.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// lsregisterPath is the location of lsregister, which is not in the PATH
+const lsregisterPath = "/System/Library/Frameworks/CoreServices.framework/Frameworks/LaunchServices.framework/Support/lsregister"
+
+// lsregisterReference matches the reference suffix of lsregister values, e.g. " (0x1a2b)"
+var lsregisterReference = regexp.MustCompile(`\s*\([0-9a-fx]+\)$`)
+
+// RegisteredDocumentType is a document type (claim) registered in LaunchServices.
+type RegisteredDocumentType struct {
+	Name     string   // Name of the document type (CFBundleTypeName)
+	Role     string   // Editor, Viewer, Shell or None
+	Bindings []string // Content types and extensions (".ext") the claim applies to
+}
+
+// LaunchServicesRegistration is one registered copy of a bundle with its document types.
+type LaunchServicesRegistration struct {
+	Path          string // Path of the registered bundle
+	DocumentTypes []RegisteredDocumentType
+}
+
+// findLsregister returns the path of lsregister: from the PATH if it is there, otherwise
+// from its location in the CoreServices framework.
+func findLsregister() (string, error) {
+	if path, err := fileManagement.FindProgramPath("lsregister"); err == nil {
+		return path, nil
+	}
+	if _, err := os.Stat(lsregisterPath); err != nil {
+		return "", fmt.Errorf("lsregister not found at %s (LaunchServices is only available on macOS)", lsregisterPath)
+	}
+
+	return lsregisterPath, nil
+}
+
+// RegisteredDocumentTypes returns the document types LaunchServices registered for a bundle
+// identifier, per registered copy of the bundle (e.g. the build output and /Applications).
+//
+// Parameters:
+//   - bundleIdentifier: CFBundleIdentifier of the application
+//
+// Returns the registrations (empty if the bundle is not registered), or an error if
+// lsregister is not found or fails.
+func RegisteredDocumentTypes(bundleIdentifier string) ([]LaunchServicesRegistration, error) {
+	path, err := findLsregister()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr, err := runQuery(path, "-dump")
+	if err != nil {
+		return nil, fmt.Errorf("lsregister -dump failed: %v\n%s", err, stderr)
+	}
+
+	return parseLaunchServicesDump(stdout, bundleIdentifier), nil
+}
+
+// lsregisterRecord holds the "key: value" lines of one record of the lsregister dump.
+type lsregisterRecord map[string]string
+
+// splitLsregisterDump splits the lsregister dump into its records, which are separated by
+// lines of dashes. Continuation lines without a key are ignored.
+func splitLsregisterDump(output string) []lsregisterRecord {
+	var records []lsregisterRecord
+	record := lsregisterRecord{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "-----") {
+			if len(record) > 0 {
+				records = append(records, record)
+			}
+			record = lsregisterRecord{}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found || key == "" || strings.HasPrefix(key, " ") || strings.HasPrefix(key, "\t") {
+			continue
+		}
+		if _, exists := record[key]; !exists {
+			record[key] = strings.TrimSpace(value)
+		}
+	}
+	if len(record) > 0 {
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// parseLaunchServicesDump extracts the registrations of a bundle identifier from the output
+// of "lsregister -dump". Bundle records carry the identifier and a "bundle id" reference;
+// the claim records of the document types point back to it with their "bundle" key.
+func parseLaunchServicesDump(output string, bundleIdentifier string) []LaunchServicesRegistration {
+	records := splitLsregisterDump(output)
+
+	var registrations []LaunchServicesRegistration
+	bundles := map[string]int{} // "bundle id" reference -> index in registrations
+	for _, record := range records {
+		identifier := lsregisterReference.ReplaceAllString(record["identifier"], "")
+		if identifier != bundleIdentifier || record["bundle id"] == "" {
+			continue
+		}
+		bundles[record["bundle id"]] = len(registrations)
+		registrations = append(registrations, LaunchServicesRegistration{Path: record["path"]})
+	}
+
+	for _, record := range records {
+		index, ok := bundles[record["bundle"]]
+		if !ok || record["claim id"] == "" {
+			continue
+		}
+
+		documentType := RegisteredDocumentType{
+			Name: lsregisterReference.ReplaceAllString(record["claim id"], ""),
+			Role: lsregisterReference.ReplaceAllString(record["roles"], ""),
+		}
+		for _, binding := range strings.Split(record["bindings"], ",") {
+			if binding = strings.TrimSpace(binding); binding != "" {
+				documentType.Bindings = append(documentType.Bindings, binding)
+			}
+		}
+		registrations[index].DocumentTypes = append(registrations[index].DocumentTypes, documentType)
+	}
+
+	return registrations
+}
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testLsregisterDump is an excerpt of "lsregister -dump" with two registered copies of
+// com.example.myapp, their claims, and a claim of another bundle.
+const testLsregisterDump = `Checking data integrity......done.
+Status: Database is seeded.
+--------------------------------------------------------------------------------
+bundle id:                  1234 (0x4d2)
+path:                       /Users/dev/build/MyApp.app
+name:                       MyApp
+identifier:                 com.example.myapp (0x1a2b)
+version:                    1.0
+claims:                     MyApp Document (0x5678)
+--------------------------------------------------------------------------------
+claim id:                   MyApp Document (0x5678)
+localizedNames:             "LSDefaultLocalizedValue" = "MyApp Document"
+rank:                       Default
+bundle:                     1234 (0x4d2)
+flags:                      apple-internal  relative-icon-path
+roles:                      Editor (0x0002)
+bindings:                   com.example.myapp.document, .mydoc
+--------------------------------------------------------------------------------
+bundle id:                  2345 (0x929)
+path:                       /Applications/MyApp.app
+identifier:                 com.example.myapp (0x1a2b)
+--------------------------------------------------------------------------------
+claim id:                   MyApp Document (0x6789)
+bundle:                     2345 (0x929)
+roles:                      Editor (0x0002)
+bindings:                   com.example.myapp.document, .mydoc
+--------------------------------------------------------------------------------
+claim id:                   Plain Text (0x789a)
+bundle:                     2345 (0x929)
+roles:                      Viewer (0x0004)
+bindings:                   public.plain-text
+--------------------------------------------------------------------------------
+bundle id:                  3456 (0xd80)
+path:                       /Applications/Other.app
+identifier:                 com.example.other (0x2b3c)
+--------------------------------------------------------------------------------
+claim id:                   Other Document (0x89ab)
+bundle:                     3456 (0xd80)
+roles:                      Viewer (0x0004)
+bindings:                   .other
+`
+
+// TestParseLaunchServicesDump parses the sample lsregister output: the claims are
+// assigned to the registered copies of the requested bundle identifier only.
+func TestParseLaunchServicesDump(t *testing.T) {
+	myDocument := RegisteredDocumentType{
+		Name:     "MyApp Document",
+		Role:     "Editor",
+		Bindings: []string{"com.example.myapp.document", ".mydoc"},
+	}
+
+	tests := []struct {
+		name             string
+		bundleIdentifier string
+		want             []LaunchServicesRegistration
+	}{
+		{
+			name:             "two registered copies",
+			bundleIdentifier: "com.example.myapp",
+			want: []LaunchServicesRegistration{
+				{Path: "/Users/dev/build/MyApp.app", DocumentTypes: []RegisteredDocumentType{myDocument}},
+				{Path: "/Applications/MyApp.app", DocumentTypes: []RegisteredDocumentType{
+					myDocument,
+					{Name: "Plain Text", Role: "Viewer", Bindings: []string{"public.plain-text"}},
+				}},
+			},
+		},
+		{
+			name:             "other bundle",
+			bundleIdentifier: "com.example.other",
+			want: []LaunchServicesRegistration{
+				{Path: "/Applications/Other.app", DocumentTypes: []RegisteredDocumentType{
+					{Name: "Other Document", Role: "Viewer", Bindings: []string{".other"}},
+				}},
+			},
+		},
+		{
+			name:             "not registered",
+			bundleIdentifier: "com.example.missing",
+			want:             nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLaunchServicesDump(testLsregisterDump, tt.bundleIdentifier)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLaunchServicesDump() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
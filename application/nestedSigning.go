@@ -0,0 +1,154 @@
+// Package application: This file handles signing of nested code components.
+// Bundles can contain frameworks, helper applications, XPC services and plug-ins,
+// each of which has to be signed before the bundle containing it ("inside-out").
+// Components on the same nesting level don't depend on each other, so they are
+// signed concurrently with a bounded number of workers.
+package application
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// nestedCodeExtensions lists the extensions of nested components that contain code
+// and must be signed separately.
+var nestedCodeExtensions = map[string]bool{
+	".app":       true,
+	".appex":     true,
+	".bundle":    true,
+	".dylib":     true,
+	".framework": true,
+	".plugin":    true,
+	".xpc":       true,
+}
+
+// signWorkers is the maximum number of components signed concurrently.
+var signWorkers = 1
+
+// SetSignWorkers sets the maximum number of nested components signed concurrently.
+// Values below 1 are treated as 1 (sequential signing).
+//
+// Parameters:
+//   - workers: Number of concurrent codesign processes
+func SetSignWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	signWorkers = workers
+}
+
+// nestedComponent is a code component found inside the bundle.
+type nestedComponent struct {
+	path  string // Path of the component
+	depth int    // Number of components containing it (1 = directly inside the app)
+}
+
+// findNestedComponents walks the Contents/ directory of a bundle and returns all nested
+// code components. Symbolic links are not followed, so framework version links
+// (e.g. Versions/Current) don't produce duplicates.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the list of components or an error if the directory cannot be read.
+func findNestedComponents(appPath string) ([]nestedComponent, error) {
+	var components []nestedComponent
+
+	contents := filepath.Join(appPath, "Contents")
+	err := filepath.WalkDir(contents, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == contents || entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if !nestedCodeExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		// The depth is the number of code components on the path, including this one
+		relativePath, err := filepath.Rel(contents, path)
+		if err != nil {
+			return err
+		}
+		depth := 0
+		for _, element := range strings.Split(relativePath, string(filepath.Separator)) {
+			if nestedCodeExtensions[strings.ToLower(filepath.Ext(element))] {
+				depth++
+			}
+		}
+
+		components = append(components, nestedComponent{path: path, depth: depth})
+		return nil
+	})
+
+	return components, err
+}
+
+// signingLevels groups components by nesting depth, deepest level first.
+// All components of a level can be signed independently of each other, but only
+// after every level before it has been signed.
+func signingLevels(components []nestedComponent) [][]string {
+	byDepth := make(map[int][]string)
+	var depths []int
+
+	for _, component := range components {
+		if _, found := byDepth[component.depth]; !found {
+			depths = append(depths, component.depth)
+		}
+		byDepth[component.depth] = append(byDepth[component.depth], component.path)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(depths)))
+
+	var levels [][]string
+	for _, depth := range depths {
+		paths := byDepth[depth]
+		sort.Strings(paths)
+		levels = append(levels, paths)
+	}
+
+	return levels
+}
+
+// signNestedComponents signs all components level by level (leaves first). Components
+// of the same level are signed concurrently, with at most signWorkers at a time.
+//
+// Parameters:
+//   - components: Components found by findNestedComponents
+//   - sign: Function signing a single component path
+//
+// Returns the first error encountered. A level is always finished before the error is
+// returned, and no further levels are started.
+func signNestedComponents(components []nestedComponent, sign func(path string) error) error {
+	for _, level := range signingLevels(components) {
+		var waitGroup sync.WaitGroup
+		var errorOnce sync.Once
+		var firstError error
+
+		semaphore := make(chan struct{}, signWorkers)
+		for _, path := range level {
+			waitGroup.Add(1)
+			semaphore <- struct{}{}
+
+			go func(path string) {
+				defer waitGroup.Done()
+				defer func() { <-semaphore }()
+
+				if err := sign(path); err != nil {
+					errorOnce.Do(func() { firstError = err })
+				}
+			}(path)
+		}
+		waitGroup.Wait()
+
+		if firstError != nil {
+			return firstError
+		}
+	}
+
+	return nil
+}
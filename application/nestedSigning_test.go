@@ -0,0 +1,168 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setTestSignWorkers sets the number of signing workers for the duration of a test.
+func setTestSignWorkers(tb testing.TB, workers int) {
+	tb.Helper()
+	previous := signWorkers
+	SetSignWorkers(workers)
+	tb.Cleanup(func() { signWorkers = previous })
+}
+
+func TestFindNestedComponents(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "MyApp.app")
+	framework := filepath.Join(appPath, "Contents", "Frameworks", "Core.framework")
+
+	for _, directory := range []string{
+		filepath.Join(framework, "Versions", "A", "Helpers", "Helper.app", "Contents", "MacOS"),
+		filepath.Join(appPath, "Contents", "PlugIns", "Export.plugin"),
+		filepath.Join(appPath, "Contents", "Resources"),
+	} {
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{
+		filepath.Join(appPath, "Contents", "Frameworks", "libz.dylib"),
+		filepath.Join(appPath, "Contents", "Resources", "readme.txt"),
+	} {
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Version links must not list the framework content twice
+	if err := os.Symlink("A", filepath.Join(framework, "Versions", "Current")); err != nil {
+		t.Fatal(err)
+	}
+
+	components, err := findNestedComponents(appPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, component := range components {
+		relativePath, err := filepath.Rel(appPath, component.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[filepath.ToSlash(relativePath)] = component.depth
+	}
+	want := map[string]int{
+		"Contents/Frameworks/Core.framework":                               1,
+		"Contents/Frameworks/Core.framework/Versions/A/Helpers/Helper.app": 2,
+		"Contents/Frameworks/libz.dylib":                                   1,
+		"Contents/PlugIns/Export.plugin":                                   1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findNestedComponents() = %v, want %v", got, want)
+	}
+}
+
+func TestSignNestedComponents(t *testing.T) {
+	components := []nestedComponent{
+		{path: "A.framework", depth: 1},
+		{path: "A.framework/Helper.app", depth: 2},
+		{path: "A.framework/Helper.app/Tool.xpc", depth: 3},
+		{path: "B.framework", depth: 1},
+		{path: "B.framework/Helper.app", depth: 2},
+		{path: "C.plugin", depth: 1},
+		{path: "D.plugin", depth: 1},
+	}
+
+	for _, workers := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			setTestSignWorkers(t, workers)
+
+			var mutex sync.Mutex
+			var signed []string
+			var running, maximum int32
+
+			err := signNestedComponents(components, func(path string) error {
+				current := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					previous := atomic.LoadInt32(&maximum)
+					if current <= previous || atomic.CompareAndSwapInt32(&maximum, previous, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				// Everything nested inside the component must already be signed
+				for _, component := range components {
+					if strings.HasPrefix(component.path, path+"/") && !slices.Contains(signed, component.path) {
+						return fmt.Errorf("%s signed before %s", path, component.path)
+					}
+				}
+				signed = append(signed, path)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(signed) != len(components) {
+				t.Errorf("signed %d components, want %d", len(signed), len(components))
+			}
+			if maximum > int32(workers) {
+				t.Errorf("%d components signed concurrently, limit is %d", maximum, workers)
+			}
+		})
+	}
+}
+
+func TestSignNestedComponentsStopsAfterError(t *testing.T) {
+	setTestSignWorkers(t, 2)
+	components := []nestedComponent{
+		{path: "A.framework", depth: 1},
+		{path: "A.framework/Helper.app", depth: 2},
+	}
+	signError := errors.New("codesign failed")
+
+	var signed []string
+	err := signNestedComponents(components, func(path string) error {
+		signed = append(signed, path)
+		return signError
+	})
+	if !errors.Is(err, signError) {
+		t.Errorf("error = %v, want %v", err, signError)
+	}
+	if want := []string{"A.framework/Helper.app"}; !reflect.DeepEqual(signed, want) {
+		t.Errorf("signed %q, want %q", signed, want)
+	}
+}
+
+// BenchmarkSignNestedComponents signs 16 independent components that take a millisecond each.
+func BenchmarkSignNestedComponents(b *testing.B) {
+	var components []nestedComponent
+	for index := 0; index < 16; index++ {
+		components = append(components, nestedComponent{path: fmt.Sprintf("Plugin%d.plugin", index), depth: 1})
+	}
+
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("%d workers", workers), func(b *testing.B) {
+			setTestSignWorkers(b, workers)
+			for iteration := 0; iteration < b.N; iteration++ {
+				_ = signNestedComponents(components, func(path string) error {
+					time.Sleep(time.Millisecond)
+					return nil
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package application: This file checks the result of a notarization before the ticket is
+// stapled. "notarytool submit --wait" also exits successfully when Apple rejects the upload
+// (status Invalid); stapling it then fails with "Record not found", which says nothing about
+// the cause. The status is therefore read from the submission result, and for a rejected
+// upload the developer log with the reasons (unsigned binaries, missing hardened runtime,
+// ...) is fetched and logged instead.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// notarizationAccepted is the status of a successful notarization
+const notarizationAccepted = "Accepted"
+
+// notarySubmission is the result of "notarytool submit --wait --output-format json"
+type notarySubmission struct {
+	ID      string `json:"id"`      // Submission ID, used to fetch the developer log
+	Status  string `json:"status"`  // Accepted, Invalid, Rejected or In Progress
+	Message string `json:"message"` // Summary of the result
+}
+
+// notaryLog is the developer log of a submission ("notarytool log <id>")
+type notaryLog struct {
+	StatusSummary string `json:"statusSummary"` // Summary of the result, e.g. "Archive contains critical validation errors"
+	Issues        []struct {
+		Severity string `json:"severity"` // error or warning
+		Path     string `json:"path"`     // File in the submitted archive
+		Message  string `json:"message"`  // Description of the issue
+	} `json:"issues"`
+}
+
+// parseNotarySubmission reads the JSON result of a notarytool submission.
+func parseNotarySubmission(output string) (notarySubmission, error) {
+	var submission notarySubmission
+	if err := json.Unmarshal([]byte(output), &submission); err != nil {
+		return submission, fmt.Errorf("cannot read the notarization result: %v\n%s", err, output)
+	}
+	if submission.Status == "" {
+		return submission, fmt.Errorf("the notarization result has no status:\n%s", output)
+	}
+	return submission, nil
+}
+
+// summarizeNotaryLog returns the summary and the issues of a developer log, one per line.
+// Output that is not a JSON developer log is returned unchanged.
+func summarizeNotaryLog(output string) []string {
+	var log notaryLog
+	if err := json.Unmarshal([]byte(output), &log); err != nil {
+		return []string{strings.TrimSpace(output)}
+	}
+
+	var lines []string
+	if log.StatusSummary != "" {
+		lines = append(lines, log.StatusSummary)
+	}
+	for _, issue := range log.Issues {
+		lines = append(lines, fmt.Sprintf("%s: %s: %s", issue.Severity, issue.Path, issue.Message))
+	}
+	return lines
+}
+
+// checkNotarizationStatus verifies that a submission was accepted. For any other status the
+// developer log is fetched and logged, so the caller doesn't staple a ticket that doesn't exist.
+//
+// Parameters:
+//   - xcrunPath: Path of xcrun
+//   - output: JSON output of "notarytool submit --wait --output-format json"
+//   - appleIDProfile: Keychain profile name containing Apple ID credentials
+//
+// Returns an error if the result cannot be read or the status is not Accepted.
+func checkNotarizationStatus(xcrunPath string, output string, appleIDProfile string) error {
+	submission, err := parseNotarySubmission(output)
+	if err != nil {
+		return err
+	}
+	if submission.Status == notarizationAccepted {
+		logger.Debug("Notarization %s accepted", submission.ID)
+		return nil
+	}
+
+	// The developer log explains why the submission was not accepted
+	logOutput, stderr, err := runQuery(xcrunPath, "notarytool", "log", submission.ID, "--keychain-profile", appleIDProfile)
+	if err != nil {
+		logger.Warn("Failed to fetch the notarization log of %s: %v\n%s", submission.ID, err, stderr)
+	} else {
+		for _, line := range summarizeNotaryLog(logOutput) {
+			logger.Warn("Notarization log: %s", line)
+		}
+	}
+
+	return fmt.Errorf("notarization %s finished with status %s (%s), the ticket is not stapled", submission.ID, submission.Status, submission.Message)
+}
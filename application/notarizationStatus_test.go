@@ -0,0 +1,127 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNotarySubmission(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    notarySubmission
+		wantErr string
+	}{
+		{
+			name:   "accepted",
+			output: `{"id":"2efe2717-52ef-43a5-96dc-0797e4ca1041","status":"Accepted","message":"Processing complete"}`,
+			want:   notarySubmission{ID: "2efe2717-52ef-43a5-96dc-0797e4ca1041", Status: "Accepted", Message: "Processing complete"},
+		},
+		{
+			name:   "invalid",
+			output: `{"id":"6b4a3bd5-d2fb-4a42-b7c3-2c0e2ab5c7e1","status":"Invalid","message":"Processing complete"}`,
+			want:   notarySubmission{ID: "6b4a3bd5-d2fb-4a42-b7c3-2c0e2ab5c7e1", Status: "Invalid", Message: "Processing complete"},
+		},
+		{name: "no status", output: `{"id":"6b4a3bd5"}`, wantErr: "has no status"},
+		{name: "not JSON", output: "Conducting pre-submission checks...", wantErr: "cannot read the notarization result"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseNotarySubmission(test.output)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("parseNotarySubmission() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeNotaryLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name: "issues",
+			output: `{"statusSummary":"Archive contains critical validation errors","issues":[
+				{"severity":"error","path":"MyApp.zip/MyApp.app/Contents/MacOS/MyApp","message":"The binary is not signed."},
+				{"severity":"error","path":"MyApp.zip/MyApp.app/Contents/MacOS/MyApp","message":"The executable does not have the hardened runtime enabled."}]}`,
+			want: []string{
+				"Archive contains critical validation errors",
+				"error: MyApp.zip/MyApp.app/Contents/MacOS/MyApp: The binary is not signed.",
+				"error: MyApp.zip/MyApp.app/Contents/MacOS/MyApp: The executable does not have the hardened runtime enabled.",
+			},
+		},
+		{name: "no issues", output: `{"statusSummary":"Ready for distribution","issues":null}`, want: []string{"Ready for distribution"}},
+		{name: "not JSON", output: "  Submission not found\n", want: []string{"Submission not found"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := summarizeNotaryLog(test.output); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("summarizeNotaryLog() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// fakeXcrun is a stand-in for xcrun that records its arguments in the file "calls" next to
+// it. The notary service rejects every submission.
+const fakeXcrun = `#!/bin/sh
+echo "$*" >> "$(dirname "$0")/calls"
+case "$1 $2" in
+"notarytool submit")
+	echo '{"id":"6b4a3bd5-d2fb-4a42-b7c3-2c0e2ab5c7e1","status":"Invalid","message":"Processing complete"}'
+	;;
+"notarytool log")
+	echo '{"statusSummary":"Archive contains critical validation errors","issues":[]}'
+	;;
+esac
+`
+
+// TestInvalidNotarizationIsNotStapled notarizes a disk image that the notary service rejects:
+// the developer log must be fetched and nothing stapled.
+func TestInvalidNotarizationIsNotStapled(t *testing.T) {
+	hdiutilPath := writeFakeHdiutil(t, false)
+	toolDirectory := filepath.Dir(hdiutilPath)
+	if err := os.WriteFile(filepath.Join(toolDirectory, "xcrun"), []byte(fakeXcrun), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(toolDirectory, "codesign"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	previousIdentity := signingIdentity
+	signingIdentity = "Developer ID Application: Example Inc (ABCDE12345)"
+	t.Cleanup(func() { signingIdentity = previousIdentity })
+
+	_, err := CreateStapledDiskImage(filepath.Join(t.TempDir(), "MyApp.app"), "notary-profile")
+	if err == nil || !strings.Contains(err.Error(), "status Invalid") {
+		t.Fatalf("error = %v, want the rejected status", err)
+	}
+
+	calls, err := os.ReadFile(filepath.Join(toolDirectory, "calls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(calls), "notarytool log 6b4a3bd5-d2fb-4a42-b7c3-2c0e2ab5c7e1 --keychain-profile notary-profile") {
+		t.Errorf("developer log was not fetched:\n%s", calls)
+	}
+	if strings.Contains(string(calls), "stapler") {
+		t.Errorf("rejected submission was stapled:\n%s", calls)
+	}
+}
@@ -0,0 +1,157 @@
+// Package application: This file checks a signed bundle for common notarization blockers.
+// Apple's notary service rejects submissions without hardened runtime or a secure timestamp,
+// with unsigned Mach-O files anywhere in the bundle, or with a quarantined archive. A failed
+// submission only reports this after the upload and a wait of several minutes, so the
+// bundle is checked locally before it is submitted.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// skipNotarizePreflight disables the checks before the notarization submission (set via SetSkipNotarizePreflight)
+var skipNotarizePreflight bool
+
+// SetSkipNotarizePreflight enables or disables skipping the notarization preflight checks.
+//
+// Parameters:
+//   - skip: true to submit the bundle without running NotarizePreflight first
+func SetSkipNotarizePreflight(skip bool) {
+	skipNotarizePreflight = skip
+}
+
+// signatureDetails holds the notarization relevant parts of "codesign -dvv" output.
+type signatureDetails struct {
+	hardenedRuntime bool // The CodeDirectory flags include "runtime"
+	secureTimestamp bool // The signature carries a timestamp from a timestamp authority
+}
+
+// parseSignatureDetails extracts the hardened runtime flag and the secure timestamp from
+// "codesign -dvv" output, e.g.:
+//
+//	CodeDirectory v=20500 size=1234 flags=0x10000(runtime) hashes=30+7 location=embedded
+//	Timestamp=12 Mar 2025 at 10:15:00
+//
+// Code signed without a secure timestamp reports "Signed Time=" instead of "Timestamp=".
+func parseSignatureDetails(output string) signatureDetails {
+	var details signatureDetails
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "CodeDirectory ") {
+			for _, field := range strings.Fields(line) {
+				if flags, found := strings.CutPrefix(field, "flags="); found {
+					details.hardenedRuntime = strings.Contains(flags, "runtime")
+				}
+			}
+		}
+		if strings.HasPrefix(line, "Timestamp=") {
+			details.secureTimestamp = true
+		}
+	}
+
+	return details
+}
+
+// findUnsignedMachOFiles returns the Mach-O files inside the bundle that carry no signature.
+// Symbolic links are not followed, so framework version links are checked only once.
+func findUnsignedMachOFiles(appPath string) ([]string, error) {
+	var unsigned []string
+
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		fileType, err := detectExecutableType(path)
+		if err != nil {
+			return err
+		}
+		if fileType == executableTypeMachO && !isSigned(path) {
+			unsigned = append(unsigned, path)
+		}
+
+		return nil
+	})
+
+	return unsigned, err
+}
+
+// isQuarantined reports whether the file carries the com.apple.quarantine attribute.
+// If the xattr tool is not available the file is treated as not quarantined.
+func isQuarantined(path string) bool {
+	xattrPath, err := fileManagement.FindProgramPath("xattr")
+	if err != nil {
+		logger.Debug("xattr not found, skipping the quarantine check of %s", path)
+		return false
+	}
+
+	// "xattr -p" exits with a non-zero status if the attribute is not set
+	_, _, err = runQuery(xattrPath, "-p", quarantineAttribute, path)
+	return err == nil
+}
+
+// NotarizePreflight checks a signed bundle for common notarization blockers:
+//   - The hardened runtime is enabled
+//   - The signature has a secure timestamp
+//   - No nested Mach-O file is unsigned
+//   - The zip archive next to the bundle (<name>.zip, if present) is not quarantined
+//
+// Parameters:
+//   - appPath: Path to the signed .app bundle
+//
+// Returns an error listing all issues found, or nil if the bundle passes every check.
+func NotarizePreflight(appPath string) error {
+	codeSignPath, err := fileManagement.FindProgramPath("codesign")
+	if err != nil {
+		return err
+	}
+
+	var issues []string
+
+	// codesign -dvv writes the signature details to stderr and fails for unsigned code
+	stdout, stderr, err := runQuery(codeSignPath, "-dvv", appPath)
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("%s is not signed", appPath))
+	} else {
+		details := parseSignatureDetails(stderr + stdout)
+		if !details.hardenedRuntime {
+			issues = append(issues, "hardened runtime is not enabled (sign with --options runtime)")
+		}
+		if !details.secureTimestamp {
+			issues = append(issues, "signature has no secure timestamp (do not use -no-timestamp)")
+		}
+	}
+
+	unsigned, err := findUnsignedMachOFiles(appPath)
+	if err != nil {
+		return err
+	}
+	for _, path := range unsigned {
+		issues = append(issues, fmt.Sprintf("nested Mach-O file is not signed: %s", path))
+	}
+
+	zipPath := strings.TrimSuffix(appPath, ".app") + ".zip"
+	if _, err := os.Stat(zipPath); err == nil && isQuarantined(zipPath) {
+		issues = append(issues, fmt.Sprintf("%s has the %s attribute", zipPath, quarantineAttribute))
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("notarization preflight found %d issue(s) (use -skip-preflight to submit anyway):\n  - %s",
+			len(issues), strings.Join(issues, "\n  - "))
+	}
+
+	logger.Debug("Notarization preflight passed for %s", appPath)
+	return nil
+}
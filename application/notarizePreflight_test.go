@@ -0,0 +1,100 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testNotarizableSignature is the "codesign -dvv" output of code signed for notarization:
+// hardened runtime, secure timestamp and a Developer ID certificate.
+const testNotarizableSignature = `Identifier=com.example.myapp
+CodeDirectory v=20500 size=1234 flags=0x10000(runtime) hashes=30+7 location=embedded
+Authority=Developer ID Application: Example Corp (ABCDE12345)
+Timestamp=12 Mar 2025 at 10:15:00
+TeamIdentifier=ABCDE12345
+`
+
+// Fake codesign and xattr for the preflight checks: codesign reports the signature in the file
+// "signature" next to it for every path except files named Unsigned, xattr reports the
+// quarantine attribute if the file "quarantined" exists next to it.
+const (
+	fakePreflightCodesign = `#!/bin/sh
+case "$2" in */Unsigned) exit 1 ;; esac
+cat "$(dirname "$0")/signature" >&2
+`
+	fakePreflightXattr = `#!/bin/sh
+test -f "$(dirname "$0")/quarantined"
+`
+)
+
+// TestNotarizePreflight checks bundles that each fail one notarization check.
+func TestNotarizePreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		signature   string
+		files       []string
+		quarantined bool
+		wantErr     string
+	}{
+		{name: "notarizable", signature: testNotarizableSignature},
+		{
+			name:      "no hardened runtime",
+			signature: strings.Replace(testNotarizableSignature, "flags=0x10000(runtime)", "flags=0x0(none)", 1),
+			wantErr:   "hardened runtime is not enabled",
+		},
+		{
+			name:      "no secure timestamp",
+			signature: strings.Replace(testNotarizableSignature, "Timestamp=", "Signed Time=", 1),
+			wantErr:   "signature has no secure timestamp",
+		},
+		{
+			name:      "unsigned nested Mach-O file",
+			signature: testNotarizableSignature,
+			files:     []string{"Contents/Frameworks/Unsigned"},
+			wantErr:   "nested Mach-O file is not signed: ",
+		},
+		{
+			name:        "quarantined zip",
+			signature:   testNotarizableSignature,
+			quarantined: true,
+			wantErr:     "MyApp.zip has the com.apple.quarantine attribute",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			toolDirectory := t.TempDir()
+			tools := map[string]string{"codesign": fakePreflightCodesign, "xattr": fakePreflightXattr}
+			for name, content := range tools {
+				if err := os.WriteFile(filepath.Join(toolDirectory, name), []byte(content), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeBundleFile(t, toolDirectory, "signature", test.signature)
+			if test.quarantined {
+				writeBundleFile(t, toolDirectory, "quarantined", "")
+			}
+			t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			appPath := filepath.Join(t.TempDir(), "MyApp.app")
+			writeBundleFile(t, appPath, "Contents/MacOS/MyApp", testMachOContent)
+			for _, name := range test.files {
+				writeBundleFile(t, appPath, name, testMachOContent)
+			}
+			writeBundleFile(t, filepath.Dir(appPath), "MyApp.zip", "archive")
+
+			err := NotarizePreflight(appPath)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) || !strings.Contains(err.Error(), "found 1 issue(s)") {
+				t.Errorf("error = %v, want one issue containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package application: This file determines the keychain profile used for notarization.
+// notarytool reads the Apple ID credentials from a keychain profile stored with
+// "xcrun notarytool store-credentials <profile>". Many teams name the profile after the
+// bundle identifier, so without -profile the name is derived from it: by default the
+// identifier itself, or the notary_profile template with {id} replaced by the identifier.
+package application
+
+import (
+	"fmt"
+	"strings"
+)
+
+// notaryProfileIdentifierPlaceholder is replaced by the bundle identifier in notary_profile
+const notaryProfileIdentifierPlaceholder = "{id}"
+
+// NotaryProfile returns the keychain profile for notarization: the -profile value if given,
+// otherwise the notary_profile template of the configuration (default "{id}") with {id}
+// replaced by the bundle identifier.
+//
+// Parameters:
+//   - flagProfile: Value of -profile (empty if not given)
+//
+// Returns the profile and where it came from (for logging), or an error if no profile can be
+// determined (no -profile, and the template needs a bundle identifier that is not set).
+func NotaryProfile(flagProfile string) (string, string, error) {
+	if flagProfile != "" {
+		return flagProfile, "-profile flag", nil
+	}
+
+	template := GetNotaryProfile()
+	source := "notary_profile " + template
+	if template == "" {
+		template = notaryProfileIdentifierPlaceholder
+		source = "bundle identifier"
+	}
+
+	if strings.Contains(template, notaryProfileIdentifierPlaceholder) && GetBundleIdentifier() == "" {
+		return "", "", fmt.Errorf("notarization requires an Apple ID profile (use -profile <name>, or set id to derive it)")
+	}
+
+	return strings.ReplaceAll(template, notaryProfileIdentifierPlaceholder, GetBundleIdentifier()), source, nil
+}
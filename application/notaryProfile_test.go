@@ -0,0 +1,69 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNotaryProfile checks that the profile is derived from the bundle identifier when
+// -profile is absent, and that -profile always wins.
+func TestNotaryProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		flagProfile string
+		parameter   packageParameter
+		want        string
+		wantSource  string
+		wantErr     string
+	}{
+		{
+			name:        "flag",
+			flagProfile: "release",
+			parameter:   packageParameter{BundleIdentifier: "com.example.myapp", NotaryProfile: "notary-{id}"},
+			want:        "release",
+			wantSource:  "-profile flag",
+		},
+		{
+			name:       "bundle identifier",
+			parameter:  packageParameter{BundleIdentifier: "com.example.myapp"},
+			want:       "com.example.myapp",
+			wantSource: "bundle identifier",
+		},
+		{
+			name:       "template",
+			parameter:  packageParameter{BundleIdentifier: "com.example.myapp", NotaryProfile: "notary-{id}"},
+			want:       "notary-com.example.myapp",
+			wantSource: "notary_profile notary-{id}",
+		},
+		{
+			name:       "template without identifier",
+			parameter:  packageParameter{NotaryProfile: "team-profile"},
+			want:       "team-profile",
+			wantSource: "notary_profile team-profile",
+		},
+		{
+			name:    "no identifier",
+			wantErr: "notarization requires an Apple ID profile",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, test.parameter)
+
+			got, source, err := NotaryProfile(test.flagProfile)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want || source != test.wantSource {
+				t.Errorf("NotaryProfile(%q) = %q, %q, want %q, %q", test.flagProfile, got, source, test.want, test.wantSource)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@
 package application
 
 import (
+	"appbundler/utilities/logger"
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -19,12 +21,18 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
 <dict>
+    <key>CFBundleInfoDictionaryVersion</key>
+    <string>{{escape .InfoDictionaryVersion}}</string>
     <key>CFBundleIdentifier</key>
     <string>{{.BundleIdentifier}}</string>
     <key>CFBundleName</key>
     <string>{{.BundleName}}</string>
+    <key>CFBundleDevelopmentRegion</key>
+    <string>{{.DevelopmentRegion}}</string>
     <key>CFBundleDisplayName</key>
     <string>{{.BundleDisplayName}}</string>
+    {{if .BundleSpokenName}}<key>CFBundleSpokenName</key>
+    <string>{{escape .BundleSpokenName}}</string>{{end}}
     <key>CFBundleVersion</key>
     <string>{{.BundleVersion}}</string>
     <key>CFBundleShortVersionString</key>
@@ -35,8 +43,15 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <string>{{.Signature}}</string>
     <key>LSMinimumSystemVersion</key>
     <string>{{.MinSystemVersion}}</string>
-    <key>CFBundleIconFile</key>
-    <string>{{.IconFile}}</string>
+    {{if .MinSystemVersionByArchitecture}}<key>LSMinimumSystemVersionByArchitecture</key>
+    <dict>{{range $architecture, $version := .MinSystemVersionByArchitecture}}
+        <key>{{$architecture}}</key>
+        <string>{{$version}}</string>{{end}}
+    </dict>{{end}}
+    {{if .IconFile}}<key>CFBundleIconFile</key>
+    <string>{{.IconFile}}</string>{{end}}
+    {{if .IconName}}<key>CFBundleIconName</key>
+    <string>{{escape .IconName}}</string>{{end}}
     <key>CFBundlePackageType</key>
     <string>{{.PackageType}}</string>
     <key>NSHumanReadableCopyright</key>
@@ -45,110 +60,246 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <string>{{.PrincipalClass}}</string>{{end}}
     {{if .MainNibFile}}<key>NSMainNibFile</key>
     <string>{{.MainNibFile}}</string>{{end}}
+    {{if .MultipleInstancesProhibited}}<key>LSMultipleInstancesProhibited</key>
+    <true/>{{end}}
+    {{if .HelpBookFolder}}<key>CFBundleHelpBookFolder</key>
+    <string>{{escape .HelpBookFolder}}</string>
+    <key>CFBundleHelpBookName</key>
+    <string>{{escape .HelpBookName}}</string>{{end}}
+    {{if .AllowMixedLocalizations}}<key>CFBundleAllowMixedLocalizations</key>
+    <true/>{{end}}
+    {{if .DocumentTypes}}<key>CFBundleDocumentTypes</key>
+    <array>{{range .DocumentTypes}}
+        <dict>
+            <key>CFBundleTypeName</key>
+            <string>{{escape .Name}}</string>
+            <key>CFBundleTypeRole</key>
+            <string>{{escape .Role}}</string>{{if .ContentTypes}}
+            <key>LSItemContentTypes</key>
+            <array>{{range .ContentTypes}}
+                <string>{{escape .}}</string>{{end}}
+            </array>{{end}}{{if .Extensions}}
+            <key>CFBundleTypeExtensions</key>
+            <array>{{range .Extensions}}
+                <string>{{escape .}}</string>{{end}}
+            </array>{{end}}{{if .IconFile}}
+            <key>CFBundleTypeIconFile</key>
+            <string>{{escape .IconFile}}</string>{{end}}
+        </dict>{{end}}
+    </array>{{end}}
+    {{if .Services}}<key>NSServices</key>
+    <array>{{range .Services}}
+        <dict>
+            <key>NSMenuItem</key>
+            <dict>
+                <key>default</key>
+                <string>{{escape .MenuItem}}</string>
+            </dict>
+            <key>NSMessage</key>
+            <string>{{escape .Message}}</string>
+            <key>NSPortName</key>
+            <string>{{escape .PortName}}</string>{{if .SendTypes}}
+            <key>NSSendTypes</key>
+            <array>{{range .SendTypes}}
+                <string>{{escape .}}</string>{{end}}
+            </array>{{end}}{{if .ReturnTypes}}
+            <key>NSReturnTypes</key>
+            <array>{{range .ReturnTypes}}
+                <string>{{escape .}}</string>{{end}}
+            </array>{{end}}
+        </dict>{{end}}
+    </array>{{end}}
+    {{range $key, $value := .ExtraKeys}}<key>{{escape $key}}</key>
+    {{plistValue $value}}
+    {{end}}
 </dict>
 </plist>`
 
+// skipPkgInfo disables the creation of the legacy PkgInfo file (set via SetSkipPkgInfo).
+var skipPkgInfo bool
+
+// SetSkipPkgInfo enables or disables skipping the legacy PkgInfo file.
+// Modern macOS versions ignore PkgInfo, so some users prefer a bundle without it.
+// The file is also skipped if the configuration sets skip_pkginfo.
+//
+// Parameters:
+//   - skip: true to not create Contents/PkgInfo
+func SetSkipPkgInfo(skip bool) {
+	skipPkgInfo = skip
+}
+
 // InfoPlistData holds the data that will be inserted into the Info.plist template.
 // Each field corresponds to a key in the macOS bundle metadata system:
+//   - InfoDictionaryVersion: Version of the Info.plist format (CFBundleInfoDictionaryVersion)
 //   - BundleIdentifier: Unique reverse-DNS identifier (e.g., com.example.myapp)
 //   - BundleName: Short name of the application
 //   - BundleDisplayName: User-visible name
+//   - BundleSpokenName: Name spoken by VoiceOver (CFBundleSpokenName)
 //   - BundleVersion: Build version number (monotonically increasing)
 //   - ShortVersionString: User-visible version (e.g., "1.0.0")
 //   - ExecutableName: Name of the file to execute when app launches
 //   - Signature: Build signature
 //   - MinSystemVersion: Minimum macOS version required (e.g., "10.13.0")
+//   - MinSystemVersionByArchitecture: Minimum macOS version per architecture (LSMinimumSystemVersionByArchitecture)
 //   - IconFile: Name of the icon file in Resources/ directory
+//   - IconName: Name of the icon in the asset catalog (CFBundleIconName)
+//   - SkipIcon: Build without an icon (-no-icon), so neither IconFile nor IconName is required
 //   - PackageType: Usually "APPL" for applications
 //   - Copyright: Copyright notice
 //   - PrincipalClass: Principal class (usually NSApplication)
 //   - MainNibFile: Main NIB file
+//   - DevelopmentRegion: Default language of the bundle (CFBundleDevelopmentRegion)
+//   - MultipleInstancesProhibited: Allow only one running instance (LSMultipleInstancesProhibited)
+//   - AllowMixedLocalizations: Let frameworks use the user's language (CFBundleAllowMixedLocalizations)
+//   - HelpBookFolder: Name of the help bundle in Resources/ (CFBundleHelpBookFolder)
+//   - HelpBookName: Name of the help book (CFBundleHelpBookName)
+//   - DocumentTypes: Document types the application can open (CFBundleDocumentTypes)
+//   - Services: System Services provided by the application (NSServices)
+//   - ExtraKeys: Additional keys without a dedicated field (written in key order)
 type InfoPlistData struct {
-	BundleIdentifier   string
-	BundleName         string
-	BundleDisplayName  string
-	BundleVersion      string
-	ShortVersionString string
-	ExecutableName     string
-	Signature          string
-	MinSystemVersion   string
-	IconFile           string
-	PackageType        string
-	Copyright          string
-	PrincipalClass     string
-	MainNibFile        string
+	InfoDictionaryVersion          string
+	BundleIdentifier               string
+	BundleName                     string
+	BundleDisplayName              string
+	BundleSpokenName               string
+	BundleVersion                  string
+	ShortVersionString             string
+	ExecutableName                 string
+	Signature                      string
+	MinSystemVersion               string
+	MinSystemVersionByArchitecture map[string]string
+	IconFile                       string
+	IconName                       string
+	SkipIcon                       bool
+	PackageType                    string
+	Copyright                      string
+	PrincipalClass                 string
+	MainNibFile                    string
+	DevelopmentRegion              string
+	MultipleInstancesProhibited    bool
+	AllowMixedLocalizations        bool
+	HelpBookFolder                 string
+	HelpBookName                   string
+	DocumentTypes                  []DocumentType
+	Services                       []Service
+	ExtraKeys                      map[string]interface{}
 }
 
 // CreatePlist generates the Info.plist file in Contents/ directory.
 // This file is required by macOS to identify and launch the application.
 // The function:
 //  1. Reads configuration values from the YAML file
-//  2. Validates that all required fields are present
-//  3. Uses Go's template engine to generate the XML file
+//  2. Renders the XML content via RenderPlist (which validates the mandatory fields)
+//  3. Writes the result to Contents/Info.plist
 //
 // Returns an error if:
 //   - Required fields are missing from configuration
 //   - File creation fails
 //   - Template parsing or execution fails
 func CreatePlist() error {
-	// Initialize the structure that will hold Info.plist data
+	// Populate the structure with values from the configuration file
+	plistStructure := NewInfoPlistData()
+
+	// Info.plist must be in Contents/ directory (required by macOS)
+	plistFileName := filepath.Join(contentsDir, "Info.plist")
+
+	// Create PkgInfo file as well (required by some older macOS versions),
+	// unless it was disabled on the command line or in the configuration
+	if skipPkgInfo || GetSkipPkgInfo() {
+		logger.Debug("Skipping the legacy PkgInfo file")
+	} else if err := CreatePkgInfo(); err != nil {
+		return err
+	}
+
+	// Render the XML content before touching the file system, so that a
+	// configuration error does not leave an empty Info.plist behind
+	content, err := RenderPlist(plistStructure)
+	if err != nil {
+		return err
+	}
+
+	// Create the Info.plist file and write the rendered content
+	err = tracedWriteFile(plistFileName, []byte(content), 0644)
+	if err != nil {
+		return cleanAfterError(err)
+	}
+
+	return nil
+}
+
+// NewInfoPlistData builds the Info.plist data structure from the loaded configuration.
+// These getter functions read from the packageInfo variable set by Read().
+func NewInfoPlistData() InfoPlistData {
 	var plistStructure InfoPlistData
 
-	// Populate the structure with values from the configuration file
-	// These getter functions read from the packageInfo variable set by Read()
+	plistStructure.InfoDictionaryVersion = GetInfoDictionaryVersion()
 	plistStructure.BundleIdentifier = GetBundleIdentifier()
 	plistStructure.BundleVersion = GetBundleVersion()
 	plistStructure.BundleName = GetBundleName()
 	plistStructure.BundleDisplayName = GetBundleDisplayName()
+	plistStructure.BundleSpokenName = GetBundleSpokenName()
 	plistStructure.ShortVersionString = GetCFBundleShortVersionString()
 	plistStructure.ExecutableName = GetBundleExecutable()
 	plistStructure.Signature = GetBundleSignature()
 	plistStructure.MinSystemVersion = GetMinimumMacOSVersion()
-	plistStructure.IconFile = GetIconFileName()
+	plistStructure.MinSystemVersionByArchitecture = GetMinimumMacOSVersionByArchitecture()
+	plistStructure.SkipIcon = skipIcon
+	if !skipIcon {
+		plistStructure.IconFile = GetIconFileName()
+		plistStructure.IconName = GetIconName()
+	}
 	plistStructure.PackageType = GetPackageType()
 	plistStructure.Copyright = GetNSHumanReadableCopyright()
 	plistStructure.PrincipalClass = GetNSPrincipalClass()
 	plistStructure.MainNibFile = GetNSMainNibFile()
-
-	// Info.plist must be in Contents/ directory (required by macOS)
-	plistFileName := filepath.Join(contentsDir, "Info.plist")
-
-	// Create PkgInfo file as well (required by some older macOS versions)
-	if err := CreatePkgInfo(); err != nil {
-		return err
+	plistStructure.DevelopmentRegion = GetDevelopmentRegion()
+	plistStructure.MultipleInstancesProhibited = GetMultipleInstancesProhibited()
+	plistStructure.AllowMixedLocalizations = GetAllowMixedLocalizations()
+	if helpBookFolder := GetHelpBookFolder(); helpBookFolder != "" {
+		plistStructure.HelpBookFolder = filepath.Base(helpBookFolder)
+		plistStructure.HelpBookName = GetHelpBookName()
 	}
+	plistStructure.DocumentTypes = GetCFBundleDocumentTypes()
+	plistStructure.Services = GetServices()
+	plistStructure.ExtraKeys = GetExtraPlistKeys()
+
+	return plistStructure
+}
 
+// RenderPlist renders the Info.plist XML content for the given data without writing a file.
+// This is used by CreatePlist and allows callers to preview or inspect the generated XML.
+//
+// Returns an error if:
+//   - Required fields are missing from the data
+//   - Template parsing or execution fails
+func RenderPlist(data InfoPlistData) (string, error) {
 	// Validate that all mandatory fields are present
 	// macOS requires these fields to be non-empty for the bundle to work correctly
-	// Note: fmt.Sprintf here doesn't do anything useful - could be removed or used for logging
-	fmt.Sprintf("pList:", plistStructure.BundleIdentifier, plistStructure.BundleVersion, plistStructure.BundleName, plistStructure.ExecutableName, plistStructure.MinSystemVersion, plistStructure.IconFile)
-	if plistStructure.BundleIdentifier == "" || plistStructure.BundleVersion == "" || plistStructure.BundleName == "" ||
-		plistStructure.ExecutableName == "" || plistStructure.MinSystemVersion == "" || plistStructure.IconFile == "" {
-		return errors.New("Info.plist <mandatory fields missing>")
-	}
-
-	// Create the Info.plist file
-	file, err := os.Create(plistFileName)
-	if err != nil {
-		return cleanAfterError(err)
+	// The icon is either an .icns file or an icon of the asset catalog (not required with -no-icon)
+	if data.BundleIdentifier == "" || data.BundleVersion == "" || data.BundleName == "" ||
+		data.ExecutableName == "" || data.MinSystemVersion == "" || (data.IconFile == "" && data.IconName == "" && !data.SkipIcon) {
+		return "", errors.New("Info.plist <mandatory fields missing>")
 	}
-	defer file.Close() // Ensure file is closed when function exits
 
 	// Parse the XML template
 	// The template contains placeholders like {{.BundleIdentifier}} that will be replaced
-	tmpl, err := template.New("plist").Parse(plistTemplate)
+	// escape and plistValue write the additional keys, whose values can be of any plist type
+	tmpl, err := template.New("plist").Funcs(template.FuncMap{
+		"escape":     escapePlistText,
+		"plistValue": renderPlistValue,
+	}).Parse(plistTemplate)
 	if err != nil {
-		return cleanAfterError(err)
+		return "", err
 	}
 
-	// Execute the template: replace placeholders with actual values and write to file
-	// This generates the final XML content
-	err = tmpl.Execute(file, plistStructure)
+	// Execute the template: replace placeholders with actual values and write to a buffer
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, data)
 	if err != nil {
-		return cleanAfterError(err)
+		return "", err
 	}
 
-	return nil
+	return buffer.String(), nil
 }
 
 // CreatePkgInfo generates the PkgInfo file in Contents/ directory.
@@ -157,12 +308,18 @@ func CreatePlist() error {
 func CreatePkgInfo() error {
 	pkgInfoFileName := filepath.Join(contentsDir, "PkgInfo")
 
-	file, err := os.Create(pkgInfoFileName)
+	file, err := tracedCreate(pkgInfoFileName)
 	if err != nil {
 		return cleanAfterError(err)
 	}
 	defer file.Close()
 
+	_, err = file.WriteString(pkgInfoContent())
+	return err
+}
+
+// pkgInfoContent returns the content of the PkgInfo file for the loaded configuration.
+func pkgInfoContent() string {
 	// PkgInfo content: 4 bytes for type (APPL) + 4 bytes for signature (default ????)
 	// The signature can be customized, but ???? is the standard default for generic apps
 	packageType := GetPackageType()
@@ -175,15 +332,59 @@ func CreatePkgInfo() error {
 		signature = "????"
 	}
 
-	_, err = file.WriteString(packageType + signature)
-	return err
+	return packageType + signature
+}
+
+// UpdatePlist regenerates Contents/Info.plist (and PkgInfo, unless disabled) of an existing
+// bundle from the loaded configuration. All other files of the bundle are left untouched,
+// so a metadata change does not need a full rebuild. The existing signature becomes invalid.
+//
+// Parameters:
+//   - appPath: Path to the existing .app bundle
+//
+// Returns an error if the path is not a bundle, the configuration lacks mandatory fields
+// or a file cannot be written. The bundle is never deleted on error.
+func UpdatePlist(appPath string) error {
+	bundleContents := filepath.Join(appPath, "Contents")
+	if info, err := os.Stat(bundleContents); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not an application bundle (Contents directory not found)", appPath)
+	}
+
+	content, err := RenderPlist(NewInfoPlistData())
+	if err != nil {
+		return err
+	}
+
+	err = tracedWriteFile(filepath.Join(bundleContents, "Info.plist"), []byte(content), 0644)
+	if err != nil {
+		return err
+	}
+
+	if !skipPkgInfo && !GetSkipPkgInfo() {
+		err = tracedWriteFile(filepath.Join(bundleContents, "PkgInfo"), []byte(pkgInfoContent()), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Modifying Info.plist breaks the seal of a signed bundle
+	if _, err := os.Stat(filepath.Join(bundleContents, "_CodeSignature")); err == nil {
+		logger.Warn("%s was signed, the signature is now invalid: sign it again (e.g. with -sign-only %s)", appPath, appPath)
+	}
+
+	logger.Info("Updated Info.plist of %s", appPath)
+	return nil
 }
 
 // cleanAfterError handles cleanup of the directory structure when an error occurs.
-// This prevents leaving partial or broken bundles on disk.
+// This prevents leaving partial or broken bundles on disk, unless -no-clean-on-error is set.
 func cleanAfterError(err error) error {
 	if applicationDirectory != "" {
-		DeleteAll()
+		if keepFailedBundle {
+			DiscardFailedBundle()
+		} else {
+			DeleteAll()
+		}
 	}
 	return err
 }
@@ -0,0 +1,333 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPlistText contains the characters that must be escaped in XML
+const testPlistText = `Text & <Markdown> "Notes"`
+
+// testPlistConfig contains the configuration keys needed to render an Info.plist
+const testPlistConfig = "id: com.example.myapp\nname: MyApp\nversion: \"1\"\nexec_file: MyApp\nexecutable: MyApp\nicon_file: MyApp.icns\n"
+
+// testPlistData returns Info.plist data with all mandatory fields set.
+func testPlistData() InfoPlistData {
+	return InfoPlistData{
+		InfoDictionaryVersion: "6.0",
+		BundleIdentifier:      "com.example.myapp",
+		BundleName:            "MyApp",
+		BundleVersion:         "1",
+		ShortVersionString:    "1.0",
+		ExecutableName:        "MyApp",
+		MinSystemVersion:      "11.0",
+		IconFile:              "MyApp.icns",
+		PackageType:           "APPL",
+	}
+}
+
+// renderTestPlist renders Info.plist data and decodes the result, failing the test if the
+// rendered XML is not a valid property list.
+func renderTestPlist(t *testing.T, data InfoPlistData) map[string]interface{} {
+	t.Helper()
+	content, err := RenderPlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := decodePlist([]byte(content))
+	if err != nil {
+		t.Fatalf("rendered Info.plist is not valid: %v\n%s", err, content)
+	}
+	dictionary, ok := root.(map[string]interface{})
+	if !ok {
+		t.Fatalf("rendered Info.plist has no dictionary:\n%s", content)
+	}
+	return dictionary
+}
+
+// TestRenderPlistEscapesText renders values containing XML special characters and reads the
+// result back, which fails if a value is written unescaped.
+func TestRenderPlistEscapesText(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   func(data *InfoPlistData)
+		value func(data InfoPlistData) string
+	}{
+		{
+			name:  "spoken name",
+			set:   func(data *InfoPlistData) { data.BundleSpokenName = testPlistText },
+			value: func(data InfoPlistData) string { return data.BundleSpokenName },
+		},
+		{
+			name:  "document type name",
+			set:   func(data *InfoPlistData) { data.DocumentTypes = []DocumentType{{Name: testPlistText, Role: "Editor"}} },
+			value: func(data InfoPlistData) string { return data.DocumentTypes[0].Name },
+		},
+		{
+			name:  "document type role",
+			set:   func(data *InfoPlistData) { data.DocumentTypes = []DocumentType{{Name: "Text", Role: testPlistText}} },
+			value: func(data InfoPlistData) string { return data.DocumentTypes[0].Role },
+		},
+		{
+			name: "document content type",
+			set: func(data *InfoPlistData) {
+				data.DocumentTypes = []DocumentType{{Name: "Text", Role: "Editor", ContentTypes: []string{testPlistText}}}
+			},
+			value: func(data InfoPlistData) string { return data.DocumentTypes[0].ContentTypes[0] },
+		},
+		{
+			name: "document extension",
+			set: func(data *InfoPlistData) {
+				data.DocumentTypes = []DocumentType{{Name: "Text", Role: "Editor", Extensions: []string{testPlistText}}}
+			},
+			value: func(data InfoPlistData) string { return data.DocumentTypes[0].Extensions[0] },
+		},
+		{
+			name: "document icon",
+			set: func(data *InfoPlistData) {
+				data.DocumentTypes = []DocumentType{{Name: "Text", Role: "Editor", IconFile: testPlistText}}
+			},
+			value: func(data InfoPlistData) string { return data.DocumentTypes[0].IconFile },
+		},
+		{
+			name:  "service menu item",
+			set:   func(data *InfoPlistData) { data.Services = []Service{{MenuItem: testPlistText, Message: "open"}} },
+			value: func(data InfoPlistData) string { return data.Services[0].MenuItem },
+		},
+		{
+			name:  "service message",
+			set:   func(data *InfoPlistData) { data.Services = []Service{{MenuItem: "Open", Message: testPlistText}} },
+			value: func(data InfoPlistData) string { return data.Services[0].Message },
+		},
+		{
+			name:  "service port name",
+			set:   func(data *InfoPlistData) { data.Services = []Service{{MenuItem: "Open", PortName: testPlistText}} },
+			value: func(data InfoPlistData) string { return data.Services[0].PortName },
+		},
+		{
+			name: "service send type",
+			set: func(data *InfoPlistData) {
+				data.Services = []Service{{MenuItem: "Open", SendTypes: []string{testPlistText}}}
+			},
+			value: func(data InfoPlistData) string { return data.Services[0].SendTypes[0] },
+		},
+		{
+			name: "service return type",
+			set: func(data *InfoPlistData) {
+				data.Services = []Service{{MenuItem: "Open", ReturnTypes: []string{testPlistText}}}
+			},
+			value: func(data InfoPlistData) string { return data.Services[0].ReturnTypes[0] },
+		},
+		{
+			name:  "info dictionary version",
+			set:   func(data *InfoPlistData) { data.InfoDictionaryVersion = testPlistText },
+			value: func(data InfoPlistData) string { return data.InfoDictionaryVersion },
+		},
+		{
+			name:  "icon name",
+			set:   func(data *InfoPlistData) { data.IconFile, data.IconName = "", testPlistText },
+			value: func(data InfoPlistData) string { return data.IconName },
+		},
+		{
+			name: "help book folder",
+			set: func(data *InfoPlistData) {
+				data.HelpBookFolder, data.HelpBookName = testPlistText, "Help"
+			},
+			value: func(data InfoPlistData) string { return data.HelpBookFolder },
+		},
+		{
+			name: "help book name",
+			set: func(data *InfoPlistData) {
+				data.HelpBookFolder, data.HelpBookName = "MyApp.help", testPlistText
+			},
+			value: func(data InfoPlistData) string { return data.HelpBookName },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := testPlistData()
+			test.set(&data)
+
+			dictionary := renderTestPlist(t, data)
+			if got := test.value(plistDataFromDictionary(dictionary)); got != testPlistText {
+				t.Errorf("value read back = %q, want %q", got, testPlistText)
+			}
+		})
+	}
+}
+
+func TestRenderPlistMandatoryFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		clear   func(data *InfoPlistData)
+		wantErr bool
+	}{
+		{"complete", func(data *InfoPlistData) {}, false},
+		{"asset catalog icon", func(data *InfoPlistData) { data.IconFile, data.IconName = "", "AppIcon" }, false},
+		{"bundle identifier", func(data *InfoPlistData) { data.BundleIdentifier = "" }, true},
+		{"bundle version", func(data *InfoPlistData) { data.BundleVersion = "" }, true},
+		{"bundle name", func(data *InfoPlistData) { data.BundleName = "" }, true},
+		{"executable", func(data *InfoPlistData) { data.ExecutableName = "" }, true},
+		{"minimum system version", func(data *InfoPlistData) { data.MinSystemVersion = "" }, true},
+		{"icon", func(data *InfoPlistData) { data.IconFile = "" }, true},
+		{"icon with -no-icon", func(data *InfoPlistData) { data.IconFile, data.SkipIcon = "", true }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := testPlistData()
+			test.clear(&data)
+
+			content, err := RenderPlist(data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error for a missing mandatory field")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(content, "<?xml") || !strings.Contains(content, "<string>com.example.myapp</string>") {
+				t.Errorf("unexpected Info.plist:\n%s", content)
+			}
+		})
+	}
+}
+
+// TestRenderPlistBooleanKeys checks that optional boolean keys are only written when enabled
+// in the configuration.
+func TestRenderPlistBooleanKeys(t *testing.T) {
+	tests := []struct {
+		key    string
+		config string
+	}{
+		{"LSMultipleInstancesProhibited", "multiple_instances_prohibited: true\n"},
+		{"CFBundleAllowMixedLocalizations", "allow_mixed_localizations: true\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			if err := readTestConfig(t, testPlistConfig); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := renderTestPlist(t, NewInfoPlistData())[test.key]; ok {
+				t.Errorf("%s is written by default", test.key)
+			}
+
+			if err := readTestConfig(t, testPlistConfig+test.config); err != nil {
+				t.Fatal(err)
+			}
+			if got := renderTestPlist(t, NewInfoPlistData())[test.key]; got != true {
+				t.Errorf("%s = %v, want true", test.key, got)
+			}
+		})
+	}
+}
+
+// TestCreatePlistPkgInfo checks that the legacy PkgInfo file is created unless it is disabled
+// with -no-pkginfo or skip_pkginfo.
+func TestCreatePlistPkgInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        bool
+		config      bool
+		wantPkgInfo bool
+	}{
+		{name: "default", wantPkgInfo: true},
+		{name: "-no-pkginfo", flag: true},
+		{name: "skip_pkginfo", config: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previousSkipPkgInfo := skipPkgInfo
+			t.Cleanup(func() { skipPkgInfo = previousSkipPkgInfo })
+			SetSkipPkgInfo(test.flag)
+
+			setTestBundle(t, packageParameter{
+				BundleIdentifier: "com.example.myapp", BundleName: "MyApp", BundleVersion: "1",
+				BundleExecutable: "MyApp", IconFileName: "MyApp.icns", MinimumMacOSVersion: "11.0",
+				SkipPkgInfo: test.config,
+			})
+
+			if err := CreatePlist(); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(contentsDir, "PkgInfo"))
+			if test.wantPkgInfo && (err != nil || string(content) != "APPL????") {
+				t.Errorf("PkgInfo = %q (%v), want APPL????", content, err)
+			}
+			if !test.wantPkgInfo && !os.IsNotExist(err) {
+				t.Errorf("PkgInfo exists: %v", err)
+			}
+		})
+	}
+}
+
+// TestUpdatePlist regenerates the Info.plist of an existing bundle from a configuration with a
+// new version: Info.plist and PkgInfo are rewritten, every other file is left untouched.
+func TestUpdatePlist(t *testing.T) {
+	tests := []struct {
+		name        string
+		signed      bool
+		wantWarning bool
+	}{
+		{"unsigned bundle", false, false},
+		{"signed bundle", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appPath := writeTestApp(t, "Contents/MacOS/MyApp")
+			untouched := map[string]string{"Contents/Resources/data.txt": "data"}
+			if test.signed {
+				untouched["Contents/_CodeSignature/CodeResources"] = "seal"
+			}
+			for name, content := range untouched {
+				writeBundleFile(t, appPath, name, content)
+			}
+			untouched["Contents/MacOS/MyApp"] = "#!/bin/sh\n"
+
+			if err := readTestConfig(t, strings.Replace(testPlistConfig, `version: "1"`, `version: "2"`, 1)); err != nil {
+				t.Fatal(err)
+			}
+			log := captureTestLog(t)
+
+			if err := UpdatePlist(appPath); err != nil {
+				t.Fatal(err)
+			}
+
+			dictionary, err := readPlistDictionary(appPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dictionary["CFBundleVersion"] != "2" {
+				t.Errorf("CFBundleVersion = %v, want 2", dictionary["CFBundleVersion"])
+			}
+			if got := readBundleFile(t, appPath, "Contents/PkgInfo"); got != "APPL????" {
+				t.Errorf("PkgInfo = %q, want APPL????", got)
+			}
+			for name, content := range untouched {
+				if got := readBundleFile(t, appPath, name); got != content {
+					t.Errorf("%s = %q, want %q", name, got, content)
+				}
+			}
+			if warned := strings.Contains(log.String(), "the signature is now invalid"); warned != test.wantWarning {
+				t.Errorf("signature warning logged: %v, want %v", warned, test.wantWarning)
+			}
+		})
+	}
+}
+
+func TestUpdatePlistRequiresBundle(t *testing.T) {
+	err := UpdatePlist(t.TempDir())
+	if want := "is not an application bundle"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want it to contain %q", err, want)
+	}
+}
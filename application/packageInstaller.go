@@ -0,0 +1,69 @@
+// Package application: This file builds a flat installer package (.pkg) for the bundle.
+// Installed applications in /Applications must be owned by root:wheel. A non-root build
+// cannot chown the staged files to root, so pkgbuild is asked to set the recommended
+// ownership ("--ownership recommended") for the payload instead: files are installed
+// as root:wheel regardless of who built the package.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"strings"
+)
+
+// defaultInstallLocation is the directory the application is installed into by the package.
+const defaultInstallLocation = "/Applications"
+
+// pkgbuildArguments returns the pkgbuild arguments for a component package of the bundle.
+//
+//	--component: Build the package from the given .app bundle
+//	--identifier: Package identifier (the bundle identifier)
+//	--version: Package version (the short version string or bundle version)
+//	--install-location: Directory the bundle is installed into
+//	--ownership recommended: Install the payload as root:wheel
+func pkgbuildArguments(appPath string, packagePath string) []string {
+	arguments := []string{"--component", appPath}
+
+	if GetBundleIdentifier() != "" {
+		arguments = append(arguments, "--identifier", GetBundleIdentifier())
+	}
+
+	version := GetCFBundleShortVersionString()
+	if version == "" {
+		version = GetBundleVersion()
+	}
+	if version != "" {
+		arguments = append(arguments, "--version", version)
+	}
+
+	arguments = append(arguments, "--install-location", defaultInstallLocation,
+		"--ownership", "recommended", packagePath)
+
+	return arguments
+}
+
+// BuildPackage creates an installer package for the application bundle using pkgbuild.
+// The package installs the bundle into /Applications, owned by root:wheel.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the path of the created package (<name>.pkg next to the bundle), or an error if
+// pkgbuild is not found or fails.
+func BuildPackage(appPath string) (string, error) {
+	pkgbuildPath, err := fileManagement.FindProgramPath("pkgbuild")
+	if err != nil {
+		return "", err
+	}
+
+	packagePath := strings.TrimSuffix(appPath, ".app") + ".pkg"
+	logger.Info("Building installer package %s", packagePath)
+
+	_, stderr, err := runCommand(pkgbuildPath, pkgbuildArguments(appPath, packagePath)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to build installer package %q: %v\n%s", packagePath, err, stderr)
+	}
+
+	return packagePath, nil
+}
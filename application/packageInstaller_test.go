@@ -0,0 +1,50 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuildPackage builds an installer package with a fake pkgbuild: the payload is always
+// installed into /Applications with the recommended (root:wheel) ownership.
+func TestBuildPackage(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter packageParameter
+		want      string
+	}{
+		{
+			name:      "identifier and version",
+			parameter: packageParameter{BundleIdentifier: "com.example.myapp", CFBundleShortVersionString: "1.2.0", BundleVersion: "42"},
+			want:      "--identifier com.example.myapp --version 1.2.0 --install-location /Applications --ownership recommended",
+		},
+		{
+			name:      "bundle version only",
+			parameter: packageParameter{BundleVersion: "42"},
+			want:      "--version 42 --install-location /Applications --ownership recommended",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callsFile := setTestTool(t, "pkgbuild")
+			setTestPackageInfo(t, test.parameter)
+			appPath := filepath.Join(t.TempDir(), "MyApp.app")
+
+			packagePath, err := BuildPackage(appPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.TrimSuffix(appPath, ".app") + ".pkg"; packagePath != want {
+				t.Errorf("package path = %s, want %s", packagePath, want)
+			}
+
+			calls := readTestCalls(t, callsFile)
+			want := "--component " + appPath + " " + test.want + " " + packagePath
+			if len(calls) != 1 || calls[0] != want {
+				t.Errorf("pkgbuild calls = %q, want %q", calls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package application: This file checks the Info.plist of a built bundle for semantic mistakes.
+// plutil only verifies that the property list is well-formed; values that are valid XML but
+// unusual or inconsistent with the bundle (an icon that is not in Resources, a non-numeric build
+// number) are only noticed later by LaunchServices, the App Store or Sparkle. The findings are
+// warnings: the bundle still works, but most of them are mistakes.
+package application
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lintMinimumMacOSVersion is the oldest LSMinimumSystemVersion considered realistic: it is
+// the oldest deployment target current Xcode versions can build for.
+const lintMinimumMacOSVersion = "10.13"
+
+// numericBundleVersionPattern matches numeric build numbers: up to three period-separated
+// integers (e.g., "42" or "1.2.3"), as expected for CFBundleVersion.
+var numericBundleVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// LintPlist checks Contents/Info.plist of a bundle for semantic mistakes.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the findings (empty if there are none), or an error if Info.plist cannot be read.
+func LintPlist(appPath string) ([]string, error) {
+	data, err := ReadPlist(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return lintPlistData(data, filepath.Join(appPath, "Contents", "Resources")), nil
+}
+
+// lintPlistData checks the values of an Info.plist:
+//
+//	CFBundleIdentifier: Uppercase letters are allowed, but identifiers are compared
+//	  case-insensitively in some places and reverse-DNS names are conventionally lowercase
+//	CFBundleVersion: Should be numeric, the App Store and Sparkle compare it as numbers
+//	LSMinimumSystemVersion: Should not be older than lintMinimumMacOSVersion
+//	CFBundleIconFile: The icon must exist in Contents/Resources/ (the extension may be omitted)
+func lintPlistData(data InfoPlistData, resourcesDirectory string) []string {
+	var findings []string
+
+	if data.BundleIdentifier != strings.ToLower(data.BundleIdentifier) {
+		findings = append(findings, fmt.Sprintf("CFBundleIdentifier %q contains uppercase letters; lowercase identifiers are recommended", data.BundleIdentifier))
+	}
+
+	if data.BundleVersion != "" && !numericBundleVersionPattern.MatchString(data.BundleVersion) {
+		findings = append(findings, fmt.Sprintf("CFBundleVersion %q is not numeric; use up to three period-separated integers (e.g. \"42\" or \"1.2.3\")", data.BundleVersion))
+	}
+
+	if data.MinSystemVersion != "" && macOSVersionPattern.MatchString(data.MinSystemVersion) && macOSVersionOlder(data.MinSystemVersion, lintMinimumMacOSVersion) {
+		findings = append(findings, fmt.Sprintf("LSMinimumSystemVersion %s is unrealistically low; current toolchains build for %s or later", data.MinSystemVersion, lintMinimumMacOSVersion))
+	}
+
+	if data.IconFile != "" {
+		iconFile := data.IconFile
+		if filepath.Ext(iconFile) == "" {
+			iconFile += ".icns"
+		}
+		if _, err := os.Stat(filepath.Join(resourcesDirectory, iconFile)); err != nil {
+			findings = append(findings, fmt.Sprintf("CFBundleIconFile %q is not present in Contents/Resources/", data.IconFile))
+		}
+	}
+
+	return findings
+}
+
+// macOSVersionOlder reports whether version is older than reference. Both are X.Y or X.Y.Z;
+// missing components count as 0.
+func macOSVersionOlder(version string, reference string) bool {
+	versionParts := strings.Split(version, ".")
+	referenceParts := strings.Split(reference, ".")
+
+	for index := 0; index < max(len(versionParts), len(referenceParts)); index++ {
+		versionPart, referencePart := 0, 0
+		if index < len(versionParts) {
+			versionPart, _ = strconv.Atoi(versionParts[index])
+		}
+		if index < len(referenceParts) {
+			referencePart, _ = strconv.Atoi(referenceParts[index])
+		}
+		if versionPart != referencePart {
+			return versionPart < referencePart
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package application
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLintPlist writes bundles with one semantic Info.plist mistake each and checks that
+// exactly the matching warning is reported.
+func TestLintPlist(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(data *InfoPlistData)
+		icon   string // File written to Contents/Resources/
+		want   []string
+	}{
+		{
+			name: "correct",
+			icon: "MyApp.icns",
+		},
+		{
+			name:   "icon without extension",
+			modify: func(data *InfoPlistData) { data.IconFile = "MyApp" },
+			icon:   "MyApp.icns",
+		},
+		{
+			name:   "uppercase identifier",
+			modify: func(data *InfoPlistData) { data.BundleIdentifier = "com.Example.MyApp" },
+			icon:   "MyApp.icns",
+			want:   []string{`CFBundleIdentifier "com.Example.MyApp" contains uppercase letters; lowercase identifiers are recommended`},
+		},
+		{
+			name:   "non-numeric version",
+			modify: func(data *InfoPlistData) { data.BundleVersion = "1.0-beta" },
+			icon:   "MyApp.icns",
+			want:   []string{`CFBundleVersion "1.0-beta" is not numeric; use up to three period-separated integers (e.g. "42" or "1.2.3")`},
+		},
+		{
+			name:   "low minimum system version",
+			modify: func(data *InfoPlistData) { data.MinSystemVersion = "10.9.5" },
+			icon:   "MyApp.icns",
+			want:   []string{"LSMinimumSystemVersion 10.9.5 is unrealistically low; current toolchains build for 10.13 or later"},
+		},
+		{
+			name: "missing icon",
+			icon: "Other.icns",
+			want: []string{`CFBundleIconFile "MyApp.icns" is not present in Contents/Resources/`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := testPlistData()
+			if test.modify != nil {
+				test.modify(&data)
+			}
+			content, err := RenderPlist(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appPath := filepath.Join(t.TempDir(), "MyApp.app")
+			writeBundleFile(t, appPath, "Contents/Info.plist", content)
+			writeBundleFile(t, appPath, "Contents/Resources/"+test.icon, "icon")
+
+			got, err := LintPlist(appPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LintPlist() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
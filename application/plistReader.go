@@ -0,0 +1,258 @@
+// Package application: This file reads the Info.plist of an existing bundle back into the
+// InfoPlistData structure, the inverse of RenderPlist. XML property lists are decoded in
+// pure Go; binary property lists are converted to XML with plutil first.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// binaryPlistHeader is the magic number at the start of binary property lists.
+const binaryPlistHeader = "bplist00"
+
+// ReadPlist parses Contents/Info.plist of an existing application bundle.
+// Keys without a dedicated InfoPlistData field are not read into ExtraKeys and are ignored.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the parsed data, or an error if:
+//   - The file cannot be read
+//   - The file is a binary plist and plutil is not available
+//   - The file is not a valid property list with a dictionary at the top level
+func ReadPlist(appPath string) (InfoPlistData, error) {
+	dictionary, err := readPlistDictionary(appPath)
+	if err != nil {
+		return InfoPlistData{}, err
+	}
+
+	return plistDataFromDictionary(dictionary), nil
+}
+
+// readPlistDictionary parses Contents/Info.plist of an existing application bundle into
+// its top-level dictionary (see decodePlist for the value types).
+func readPlistDictionary(appPath string) (map[string]interface{}, error) {
+	plistPath := filepath.Join(appPath, "Contents", "Info.plist")
+
+	content, err := os.ReadFile(plistPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary plists are converted to XML first: plutil -convert xml1 -o - <file>
+	if bytes.HasPrefix(content, []byte(binaryPlistHeader)) {
+		plutilPath, err := fileManagement.FindProgramPath("plutil")
+		if err != nil {
+			return nil, fmt.Errorf("cannot read binary plist %s: %v", plistPath, err)
+		}
+
+		out, stderr, err := runQuery(plutilPath, "-convert", "xml1", "-o", "-", plistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert binary plist %s: %v\n%s", plistPath, err, stderr)
+		}
+		content = []byte(out)
+	}
+
+	root, err := decodePlist(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", plistPath, err)
+	}
+
+	dictionary, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: top level element is not a dictionary", plistPath)
+	}
+
+	return dictionary, nil
+}
+
+// plistDataFromDictionary maps the decoded Info.plist dictionary to InfoPlistData.
+func plistDataFromDictionary(dictionary map[string]interface{}) InfoPlistData {
+	var data InfoPlistData
+
+	data.InfoDictionaryVersion = plistString(dictionary, "CFBundleInfoDictionaryVersion")
+	data.BundleIdentifier = plistString(dictionary, "CFBundleIdentifier")
+	data.BundleName = plistString(dictionary, "CFBundleName")
+	data.BundleDisplayName = plistString(dictionary, "CFBundleDisplayName")
+	data.BundleSpokenName = plistString(dictionary, "CFBundleSpokenName")
+	data.BundleVersion = plistString(dictionary, "CFBundleVersion")
+	data.ShortVersionString = plistString(dictionary, "CFBundleShortVersionString")
+	data.ExecutableName = plistString(dictionary, "CFBundleExecutable")
+	data.Signature = plistString(dictionary, "CFBundleSignature")
+	data.MinSystemVersion = plistString(dictionary, "LSMinimumSystemVersion")
+	if versions, ok := dictionary["LSMinimumSystemVersionByArchitecture"].(map[string]interface{}); ok {
+		data.MinSystemVersionByArchitecture = make(map[string]string)
+		for architecture := range versions {
+			data.MinSystemVersionByArchitecture[architecture] = plistString(versions, architecture)
+		}
+	}
+	data.IconFile = plistString(dictionary, "CFBundleIconFile")
+	data.IconName = plistString(dictionary, "CFBundleIconName")
+	data.PackageType = plistString(dictionary, "CFBundlePackageType")
+	data.Copyright = plistString(dictionary, "NSHumanReadableCopyright")
+	data.PrincipalClass = plistString(dictionary, "NSPrincipalClass")
+	data.MainNibFile = plistString(dictionary, "NSMainNibFile")
+	data.DevelopmentRegion = plistString(dictionary, "CFBundleDevelopmentRegion")
+	data.MultipleInstancesProhibited, _ = dictionary["LSMultipleInstancesProhibited"].(bool)
+	data.AllowMixedLocalizations, _ = dictionary["CFBundleAllowMixedLocalizations"].(bool)
+	data.HelpBookFolder = plistString(dictionary, "CFBundleHelpBookFolder")
+	data.HelpBookName = plistString(dictionary, "CFBundleHelpBookName")
+
+	for _, entry := range plistArray(dictionary, "CFBundleDocumentTypes") {
+		documentType, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		data.DocumentTypes = append(data.DocumentTypes, DocumentType{
+			Name:         plistString(documentType, "CFBundleTypeName"),
+			Role:         plistString(documentType, "CFBundleTypeRole"),
+			ContentTypes: plistStrings(documentType, "LSItemContentTypes"),
+			Extensions:   plistStrings(documentType, "CFBundleTypeExtensions"),
+			IconFile:     plistString(documentType, "CFBundleTypeIconFile"),
+		})
+	}
+
+	for _, entry := range plistArray(dictionary, "NSServices") {
+		service, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// NSMenuItem is a dictionary with the localized titles, "default" is the fallback title
+		menuItem := ""
+		if titles, ok := service["NSMenuItem"].(map[string]interface{}); ok {
+			menuItem = plistString(titles, "default")
+		}
+
+		data.Services = append(data.Services, Service{
+			MenuItem:    menuItem,
+			Message:     plistString(service, "NSMessage"),
+			PortName:    plistString(service, "NSPortName"),
+			SendTypes:   plistStrings(service, "NSSendTypes"),
+			ReturnTypes: plistStrings(service, "NSReturnTypes"),
+		})
+	}
+
+	return data
+}
+
+// plistString returns a string value of a dictionary, or "" if it is missing or not a string.
+func plistString(dictionary map[string]interface{}, key string) string {
+	value, _ := dictionary[key].(string)
+	return value
+}
+
+// plistArray returns an array value of a dictionary, or nil if it is missing or not an array.
+func plistArray(dictionary map[string]interface{}, key string) []interface{} {
+	value, _ := dictionary[key].([]interface{})
+	return value
+}
+
+// plistStrings returns the string elements of an array value of a dictionary.
+func plistStrings(dictionary map[string]interface{}, key string) []string {
+	var values []string
+	for _, element := range plistArray(dictionary, key) {
+		if value, ok := element.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// decodePlist decodes an XML property list into Go values:
+// dict -> map[string]interface{}, array -> []interface{}, true/false -> bool,
+// and string, integer, real, date and data -> string (their text content).
+func decodePlist(content []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+
+	// Find the root value inside <plist>
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no property list found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(decoder, start)
+		}
+	}
+}
+
+// decodePlistValue decodes the value starting with the given element, including its end tag.
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dictionary := make(map[string]interface{})
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch element := token.(type) {
+			case xml.EndElement:
+				return dictionary, nil
+			case xml.StartElement:
+				if element.Name.Local == "key" {
+					var text string
+					if err := decoder.DecodeElement(&text, &element); err != nil {
+						return nil, err
+					}
+					key = strings.TrimSpace(text)
+					continue
+				}
+
+				value, err := decodePlistValue(decoder, element)
+				if err != nil {
+					return nil, err
+				}
+				dictionary[key] = value
+			}
+		}
+
+	case "array":
+		array := []interface{}{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch element := token.(type) {
+			case xml.EndElement:
+				return array, nil
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, element)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+		}
+
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+
+	default:
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return text, nil
+	}
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTestPlist writes Contents/Info.plist of a bundle in a temporary directory and returns
+// the path of the bundle.
+func writeTestPlist(t *testing.T, content string) string {
+	t.Helper()
+	appPath := filepath.Join(t.TempDir(), "MyApp.app")
+	if err := os.MkdirAll(filepath.Join(appPath, "Contents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return appPath
+}
+
+func TestReadPlistRoundTrip(t *testing.T) {
+	data := testPlistData()
+	data.BundleDisplayName = "My App"
+	data.BundleSpokenName = "My App"
+	data.Signature = "MYAP"
+	data.MinSystemVersionByArchitecture = map[string]string{"arm64": "11.0", "x86_64": "10.15"}
+	data.Copyright = "Copyright 2026 Example Inc"
+	data.PrincipalClass = "NSApplication"
+	data.MainNibFile = "MainMenu"
+	data.DevelopmentRegion = "en"
+	data.MultipleInstancesProhibited = true
+	data.AllowMixedLocalizations = true
+	data.HelpBookFolder = "MyApp.help"
+	data.HelpBookName = "com.example.myapp.help"
+	data.DocumentTypes = []DocumentType{{
+		Name:         "Markdown",
+		Role:         "Editor",
+		ContentTypes: []string{"net.daringfireball.markdown"},
+		Extensions:   []string{"md", "markdown"},
+		IconFile:     "Document.icns",
+	}}
+	data.Services = []Service{{
+		MenuItem:    "MyApp/Open Selection",
+		Message:     "openSelection",
+		PortName:    "MyApp",
+		SendTypes:   []string{"NSStringPboardType"},
+		ReturnTypes: []string{"NSStringPboardType"},
+	}}
+
+	content, err := RenderPlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadPlist(writeTestPlist(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf("ReadPlist() =\n%+v\nwant\n%+v", got, data)
+	}
+}
+
+func TestReadPlistErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not a property list", "CFBundleIdentifier = com.example.myapp"},
+		{"array at the top level", `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><array><string>MyApp</string></array></plist>`},
+		{"truncated", `<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><dict><key>CFBundleName</key>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadPlist(writeTestPlist(t, test.content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := ReadPlist(filepath.Join(t.TempDir(), "Missing.app")); err == nil {
+		t.Error("expected an error for a missing Info.plist")
+	}
+}
@@ -0,0 +1,96 @@
+// Package application: This file implements the tool availability check run before a build.
+// A build can take a long time (e.g. when bundling a Java runtime), so all external
+// tools needed for the requested steps are checked up front, and all missing tools
+// are reported at once instead of failing at the first step that needs one.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PreflightOptions describes the optional build steps requested on the command line.
+// Each enabled step adds the external tools it needs to the preflight check.
+type PreflightOptions struct {
+	Sign         bool // Code signing (codesign, security)
+	Notarize     bool // Notarization (zip, xcrun)
+	Dequarantine bool // Quarantine attribute removal (xattr)
+	Package      bool // Installer package (pkgbuild)
+}
+
+// requiredTools returns the external tools needed for the requested steps and the
+// loaded configuration, without duplicates and in a stable order.
+func requiredTools(opts PreflightOptions) []string {
+	var tools []string
+	seen := make(map[string]bool)
+
+	add := func(names ...string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				tools = append(tools, name)
+			}
+		}
+	}
+
+	if opts.Sign {
+		add("codesign", "security")
+	}
+	if opts.Notarize {
+		add("zip", "xcrun")
+	}
+	if opts.Dequarantine {
+		add("xattr")
+	}
+	if opts.Package {
+		add("pkgbuild")
+	}
+	if GetStripBinary() {
+		add("strip")
+	}
+	if GetPreserveXattrs() {
+		add("ditto")
+	}
+	if GetGoPackage() != "" {
+		add("go")
+	}
+
+	return tools
+}
+
+// PreflightCheck verifies that every external tool needed for the requested build steps
+// can be found in the PATH.
+//
+// Parameters:
+//   - opts: The optional build steps requested on the command line
+//
+// Returns an error listing all missing tools, or nil if everything is available.
+func PreflightCheck(opts PreflightOptions) error {
+	var missing []string
+	var hints []string
+
+	for _, tool := range requiredTools(opts) {
+		if _, err := fileManagement.FindProgramPath(tool); err != nil {
+			missing = append(missing, tool)
+
+			// Several tools share a hint (e.g. the Xcode Command Line Tools), list it once
+			hint := fileManagement.InstallHint(tool)
+			if hint != "" && !slices.Contains(hints, hint) {
+				hints = append(hints, hint)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		message := fmt.Sprintf("required tools not found in PATH: %s", strings.Join(missing, ", "))
+		for _, hint := range hints {
+			message += "\n  - " + hint
+		}
+		return errors.New(message)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequiredTools(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      PreflightOptions
+		parameter packageParameter
+		want      []string
+	}{
+		{name: "no optional steps"},
+		{name: "sign", opts: PreflightOptions{Sign: true}, want: []string{"codesign", "security"}},
+		{
+			name: "all steps",
+			opts: PreflightOptions{Sign: true, Notarize: true, Dequarantine: true, Package: true},
+			want: []string{"codesign", "security", "zip", "xcrun", "xattr", "pkgbuild"},
+		},
+		{
+			name:      "configuration",
+			parameter: packageParameter{StripBinary: true, PreserveXattrs: true, GoPackage: "./cmd/myapp"},
+			want:      []string{"strip", "ditto", "go"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, test.parameter)
+			if got := requiredTools(test.opts); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("requiredTools() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestPreflightCheck runs the check with a PATH containing only some of the tools: all missing
+// tools are reported in one error.
+func TestPreflightCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		opts      PreflightOptions
+		wantErr   string
+	}{
+		{name: "no optional steps"},
+		{name: "all tools installed", installed: []string{"codesign", "security"}, opts: PreflightOptions{Sign: true}},
+		{
+			name:      "missing tools",
+			installed: []string{"codesign"},
+			opts:      PreflightOptions{Sign: true, Notarize: true},
+			wantErr:   "required tools not found in PATH: security, zip, xcrun",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{})
+			directory := t.TempDir()
+			for _, tool := range test.installed {
+				if err := os.WriteFile(filepath.Join(directory, tool), []byte(fakeTool), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PATH", directory)
+
+			err := PreflightCheck(test.opts)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
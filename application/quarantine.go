@@ -0,0 +1,46 @@
+// Package application: This file handles removal of the quarantine attribute from a bundle.
+// Files downloaded or copied by some tools carry the "com.apple.quarantine" extended
+// attribute, which makes Gatekeeper report locally built applications as "damaged".
+//
+// Removing the attribute is a convenience for local builds and testing only. It is not
+// a substitute for signing and notarization when distributing the application.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+)
+
+// quarantineAttribute is the extended attribute set by macOS on quarantined files.
+const quarantineAttribute = "com.apple.quarantine"
+
+// dequarantineArguments returns the xattr arguments used to recursively remove
+// the quarantine attribute from the given bundle path.
+func dequarantineArguments(appPath string) []string {
+	return []string{"-dr", quarantineAttribute, appPath}
+}
+
+// RemoveQuarantine recursively removes the quarantine attribute from an application bundle.
+// It runs: xattr -dr com.apple.quarantine <appPath>
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns an error if the xattr tool is not found or the command fails.
+func RemoveQuarantine(appPath string) error {
+	logger.Warn("Removing the quarantine attribute is for local testing only and is not a substitute for signing and notarization")
+
+	xattrPath, err := fileManagement.FindProgramPath("xattr")
+	if err != nil {
+		return err
+	}
+
+	_, stderr, err := runCommand(xattrPath, dequarantineArguments(appPath)...)
+	if err != nil {
+		return fmt.Errorf("failed to remove quarantine attribute from %q: %v\n%s", appPath, err, stderr)
+	}
+
+	logger.Debug("Quarantine attribute removed from %s", appPath)
+	return nil
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRemoveQuarantine checks that xattr removes the quarantine attribute recursively from the
+// bundle path.
+func TestRemoveQuarantine(t *testing.T) {
+	callsFile := setTestTool(t, "xattr")
+	appPath := filepath.Join(t.TempDir(), "My App.app")
+
+	if err := RemoveQuarantine(appPath); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"-dr com.apple.quarantine " + appPath}
+	if got := readTestCalls(t, callsFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("xattr calls = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveQuarantineWithoutXattr(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	err := RemoveQuarantine(filepath.Join(t.TempDir(), "MyApp.app"))
+	if err == nil || !strings.Contains(err.Error(), `"xattr" not found`) {
+		t.Errorf("error = %v, want it to contain %q", err, `"xattr" not found`)
+	}
+}
@@ -7,14 +7,62 @@ package application
 import (
 	"appbundler/utilities/logger"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// defaultMinimumMacOSVersion is used for LSMinimumSystemVersion when the configuration
+// does not define system_minimal_os_version.
+const defaultMinimumMacOSVersion = "10.13.0"
+
+// minimumVersionArchitectures lists the architecture keys of LSMinimumSystemVersionByArchitecture
+var minimumVersionArchitectures = map[string]bool{"arm64": true, "x86_64": true}
+
+// macOSVersionPattern matches macOS version numbers in the form X.Y or X.Y.Z (e.g., "10.13" or "11.0.1").
+var macOSVersionPattern = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// shortVersionPattern matches the format required for CFBundleShortVersionString: a period-separated
+// list of at most three non-negative integers (e.g., "1", "1.2" or "1.2.3").
+var shortVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// infoDictionaryVersionPattern matches the format of CFBundleInfoDictionaryVersion: a plain
+// version number like "6.0".
+var infoDictionaryVersionPattern = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// defaultExecutableMode is the permission of the bundle executable when executable_mode is not set.
+// 0755 = rwxr-xr-x: owner can read/write/execute, others can read/execute
+const defaultExecutableMode os.FileMode = 0755
+
+// defaultDirectoryMode is the permission of the bundle directories when directory_mode is not set.
+const defaultDirectoryMode os.FileMode = 0755
+
+// defaultInfoDictionaryVersion is the version of the Info.plist format (CFBundleInfoDictionaryVersion).
+// "6.0" is the only version in use.
+const defaultInfoDictionaryVersion = "6.0"
+
+// defaultDevelopmentRegion is used for CFBundleDevelopmentRegion when the configuration
+// does not define development_region.
+const defaultDevelopmentRegion = "en"
+
+// developmentRegionPattern matches language codes with an optional script or region
+// (e.g., "en", "de", "pt-BR", "zh_CN" or "zh-Hans").
+var developmentRegionPattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,4})?$`)
+
+// configBaseDirectory is the directory containing the (base) configuration file read by Read().
+// It is used for paths that were not set by a configuration file.
+var configBaseDirectory string
+
+// resolveRelativeToConfig enables resolving relative paths of the configuration against
+// configBaseDirectory instead of the current working directory.
+var resolveRelativeToConfig bool
+
 // packageInfo is a package-level variable that stores the parsed configuration.
 // It's populated by the Read() function and accessed by getter functions.
 var packageInfo packageParameter
@@ -23,35 +71,112 @@ var packageInfo packageParameter
 // The `yaml:"tag"` annotations map YAML keys to struct fields.
 // This struct holds all the information needed to create a macOS application bundle.
 type packageParameter struct {
+	// Shared configuration fragments, merged before the keys of this file
+	Include []string `yaml:"include"` // Files merged first (paths relative to this file), e.g. [signing.yaml]
+
 	// Bundle metadata (required for Info.plist)
-	BundleIdentifier  string `yaml:"id"`           // Unique reverse-DNS identifier (e.g., com.example.myapp)
-	BundleName        string `yaml:"name"`         // Short name of the bundle (e.g., MyApp)
-	BundleVersion     string `yaml:"version"`      // Build version number (e.g., "1" or "1.0.0")
-	BundleDisplayName string `yaml:"display_name"` // User-visible name (can be localized)
-	BundlePackageType string `yaml:"type"`         // Package type, default is "APPL"
-	BundleExecutable  string `yaml:"executable"`   // Name of the main executable file (CFBundleExecutable)
-	BundleSignature   string `yaml:"signature"`    // Build signature (monotonically increasing version string)
+	BundleIdentifier  string `yaml:"id"`             // Unique reverse-DNS identifier (e.g., com.example.myapp)
+	BundleName        string `yaml:"name"`           // Short name of the bundle (e.g., MyApp)
+	BundleVersion     string `yaml:"version"`        // Build version number (e.g., "1" or "1.0.0")
+	VersionFile       string `yaml:"version_file"`   // Text file whose content is used for version and short_version_string
+	VersionScheme     string `yaml:"version_scheme"` // "calver" to generate version from the build time (YYYYMMDD.HHMMSS)
+	BundleDisplayName string `yaml:"display_name"`   // User-visible name (can be localized)
+	BundleSpokenName  string `yaml:"spoken_name"`    // Name spoken by VoiceOver, if it mispronounces the name (CFBundleSpokenName)
+	BundlePackageType string `yaml:"type"`           // Package type, default is "APPL"
+	BundleExecutable  string `yaml:"executable"`     // Name of the main executable file (CFBundleExecutable)
+	BundleSignature   string `yaml:"signature"`      // Build signature (monotonically increasing version string)
 
 	// Executable file location
-	ExecFileName      string `yaml:"exec_file"`           // Name of the executable/JAR file to package
+	ExecFileName      string `yaml:"exec_file"`           // Name of the executable/JAR file to package (or an HTTP(S) URL to download it from)
 	ExecFileDirectory string `yaml:"exec_file_directory"` // Directory containing the executable/JAR
+	ExecFileSHA256    string `yaml:"exec_file_sha256"`    // Expected SHA-256 checksum of a downloaded executable (optional)
 
 	// Icon file location
 	IconFileName      string `yaml:"icon_file"`           // Name of the icon file (typically .icns)
 	IconFileDirectory string `yaml:"icon_file_directory"` // Directory containing the icon file
+	IconName          string `yaml:"icon_name"`           // Name of the icon in the asset catalog (CFBundleIconName)
+	AssetCatalog      string `yaml:"asset_catalog"`       // Path of a compiled asset catalog (Assets.car) copied into Resources
 
 	// Additional macOS bundle properties (optional)
-	MinimumMacOSVersion        string `yaml:"system_minimal_os_version"` // Minimum macOS version (e.g., "10.13.0")
-	CFBundleDocumentTypes      string `yaml:"document_types"`            // Document types this app can open
-	CFBundleShortVersionString string `yaml:"short_version_string"`      // User-visible version (e.g., "1.0.0")
-	NSHumanReadableCopyright   string `yaml:"readable_copyright"`        // Copyright notice
-	NSMainNibFile              string `yaml:"main_nib_file"`             // Main NIB file (for Cocoa apps)
-	NSPrincipalClass           string `yaml:"principle_class"`           // Principal class (usually NSApplication)
+	MinimumMacOSVersion         string            `yaml:"system_minimal_os_version"`     // Minimum macOS version (e.g., "10.13.0")
+	MinimumMacOSByArchitecture  map[string]string `yaml:"min_os_by_arch"`                // Minimum macOS version per architecture (arm64, x86_64)
+	CFBundleDocumentTypes       documentTypes     `yaml:"document_types"`                // Document types this app can open
+	CFBundleShortVersionString  string            `yaml:"short_version_string"`          // User-visible version (e.g., "1.0.0")
+	NSHumanReadableCopyright    string            `yaml:"readable_copyright"`            // Copyright notice
+	NSMainNibFile               string            `yaml:"main_nib_file"`                 // Main NIB file (for Cocoa apps)
+	NSPrincipalClass            string            `yaml:"principle_class"`               // Principal class (usually NSApplication)
+	SkipPkgInfo                 bool              `yaml:"skip_pkginfo"`                  // true to not create the legacy PkgInfo file
+	AllowMixedLocalizations     bool              `yaml:"allow_mixed_localizations"`     // true to let frameworks use the user's language (CFBundleAllowMixedLocalizations)
+	DevelopmentRegion           string            `yaml:"development_region"`            // Default language of the bundle (e.g., "en", "de"), default is "en"
+	InfoDictionaryVersion       string            `yaml:"info_dictionary_version"`       // Version of the Info.plist format, default is "6.0"
+	MultipleInstancesProhibited bool              `yaml:"multiple_instances_prohibited"` // true to allow only one running instance (LSMultipleInstancesProhibited)
 
 	// Java-specific settings (for JAR-based applications)
-	LocalJava          string `yaml:"local_java"`           // "true" to bundle Java runtime, "false" to use system Java
-	LocalJavaHome      string `yaml:"local_java_home"`      // Path to Java installation to bundle (if local_java is true)
-	LocalExecDirectory string `yaml:"local_exec_directory"` // Alternative executable directory
+	LocalJava            string `yaml:"local_java"`             // "true" to bundle Java runtime, "false" to use system Java
+	LocalJavaHome        string `yaml:"local_java_home"`        // Path to Java installation to bundle (if local_java is true)
+	JavaHomeArm64        string `yaml:"java_home_arm64"`        // Java installation for Apple silicon (used with java_home_amd64 instead of local_java_home)
+	JavaHomeAmd64        string `yaml:"java_home_amd64"`        // Java installation for Intel Macs (used with java_home_arm64 instead of local_java_home)
+	LocalExecDirectory   string `yaml:"local_exec_directory"`   // Alternative executable directory
+	RuntimeLayout        string `yaml:"runtime_layout"`         // "java" (Contents/Java/runtime, default) or "jpackage" (Contents/runtime)
+	JavaRuntimeMode      string `yaml:"java_runtime_mode"`      // "copy" (default) or "symlink" to link the Java installation (local builds only)
+	TargetArchitecture   string `yaml:"target_arch"`            // Architecture the bundled runtime must support (e.g. arm64), default is the host
+	SplashImage          string `yaml:"splash_image"`           // Image shown by the JVM while the application starts (-splash:)
+	MetadataFromManifest bool   `yaml:"metadata_from_manifest"` // true to default name and short_version_string to the JAR manifest
+
+	// Compiled executable settings
+	StripBinary      bool   `yaml:"strip_binary"`      // true to strip debug symbols from the copied binary
+	PreserveXattrs   bool   `yaml:"preserve_xattrs"`   // true to keep the extended attributes of the binary (copied with ditto)
+	ExecutableMode   string `yaml:"executable_mode"`   // Octal permissions of the bundle executable (e.g. "0750"), default is "0755"
+	DirectoryMode    string `yaml:"directory_mode"`    // Octal permissions of the created bundle directories (e.g. "0700"), default is "0755"
+	ArgumentLauncher bool   `yaml:"argument_launcher"` // true to start the binary through a launcher forwarding all arguments
+
+	// Go package settings (the executable is built with "go build" instead of using exec_file)
+	GoPackage       string   `yaml:"go_package"`       // Directory of the Go package to build
+	GoOS            string   `yaml:"go_os"`            // GOOS of the build, default is "darwin"
+	GoArch          string   `yaml:"go_arch"`          // GOARCH of the build, default matches target_arch
+	GoArchitectures []string `yaml:"go_architectures"` // GOARCHs combined into a universal binary (e.g. [arm64, amd64]), instead of go_arch
+	GoLdflags       string   `yaml:"go_ldflags"`       // Linker flags passed with -ldflags (e.g. "-s -w")
+
+	// Additional files and directories copied into Contents/Resources/
+	Resources []string `yaml:"resources"`
+
+	// Free disk space required before building: size of the sources times this factor (default 2)
+	DiskSpaceFactor float64 `yaml:"disk_space_factor"`
+
+	// Apple Help book (a .help bundle copied into Contents/Resources/)
+	HelpBookFolder string `yaml:"help_book_folder"` // Path of the .help bundle (CFBundleHelpBookFolder is its name)
+	HelpBookName   string `yaml:"help_book_name"`   // Name of the help book (CFBundleHelpBookName, e.g. com.example.myapp.help)
+
+	// System Services provided by the application (NSServices)
+	Services []Service `yaml:"services"`
+
+	// launchd agent shipped in Contents/Library/LaunchAgents/ (optional)
+	LaunchAgent *LaunchAgent `yaml:"launch_agent"`
+
+	// Notarization settings
+	NotaryProfile string `yaml:"notary_profile"` // Keychain profile used without -profile, {id} is the bundle identifier (default "{id}")
+
+	// Signing settings
+	SigningExcludes    []string `yaml:"signing_excludes"`    // Not supported, rejected by ValidateConfiguration (codesign seals every file of the bundle)
+	SigningRequirement string   `yaml:"signing_requirement"` // Designated requirement (text, or path of a .csreq file) passed to codesign --requirements
+
+	// Additional Info.plist keys without a dedicated field (e.g. NSMicrophoneUsageDescription).
+	// Top-level keys looking like Info.plist keys are added here automatically.
+	ExtraPlistKeys map[string]interface{} `yaml:"extra_plist_keys"`
+
+	// Directory of the configuration file that set each field (by field name), so relative
+	// paths of included and overlay files resolve against the file declaring them
+	configDirectories map[string]string
+}
+
+// Service describes one entry of the NSServices array in Info.plist.
+// A service appears in the Services menu and calls a method of the application.
+type Service struct {
+	MenuItem    string   `yaml:"menu_item"`    // Title of the Services menu item (NSMenuItem)
+	Message     string   `yaml:"message"`      // Name of the method invoked on the service provider (NSMessage)
+	PortName    string   `yaml:"port_name"`    // Port of the providing instance, defaults to the bundle name (NSPortName)
+	SendTypes   []string `yaml:"send_types"`   // Data types the service accepts (NSSendTypes)
+	ReturnTypes []string `yaml:"return_types"` // Data types the service returns (NSReturnTypes)
 }
 
 // Read parses the YAML configuration file and populates the packageInfo variable.
@@ -59,45 +184,273 @@ type packageParameter struct {
 // configuration data (like GetBundleName(), GetExecutableName(), etc.).
 //
 // Parameters:
-//   - packageFileName: Path to the YAML configuration file (defaults to "application.yaml")
+//   - packageFileName: Path to the YAML configuration file (defaults to "application.yaml").
+//     Several comma-separated files are loaded in order; non-empty values of later files
+//     override earlier ones (e.g. "application.yaml,local.yaml").
 //
 // Returns an error if:
 //   - File cannot be opened
 //   - File cannot be read
 //   - YAML parsing fails
 func Read(packageFileName string) error {
-	var err error
-
 	// Default to "application.yaml" if no filename is provided
 	if packageFileName == "" {
 		packageFileName = "application.yaml"
 	}
 
-	// Open the YAML configuration file
-	file, err := os.Open(packageFileName)
-	if err != nil {
-		logger.Error(err)
+	// Several files can be given separated by commas: a base file followed by overlays
+	packageFiles := SplitConfigFiles(packageFileName)
+
+	// Remember the directory of the (base) configuration file to resolve relative paths against it
+	configBaseDirectory = filepath.Dir(packageFiles[0])
+
+	// The struct is reset first, so values of a previously read file don't leak into this one
+	packageInfo = packageParameter{}
+
+	// Load the files in order, each one overriding the values set by the files before
+	for _, packageFile := range packageFiles {
+		layer, err := readConfigFile(packageFile)
+		if err != nil {
+			return err
+		}
+		mergeParameters(&packageInfo, layer)
+	}
+
+	// Take the version from the version file, if configured
+	if err := applyVersionFile(); err != nil {
+		return err
+	}
+
+	// Generate the build version if a version scheme is configured
+	if err := applyVersionScheme(); err != nil {
+		return err
+	}
+
+	// Take a missing name and short version from the JAR manifest, if configured
+	if err := applyManifestMetadata(); err != nil {
 		return err
 	}
 
+	// Fill in defaults for optional values that are missing from the configuration
+	applyDefaults()
+
+	return nil
+}
+
+// SplitConfigFiles splits a comma-separated list of configuration files, ignoring empty entries.
+// Returns "application.yaml" if the list is empty.
+func SplitConfigFiles(packageFileNames string) []string {
+	var packageFiles []string
+	for _, packageFile := range strings.Split(packageFileNames, ",") {
+		if packageFile = strings.TrimSpace(packageFile); packageFile != "" {
+			packageFiles = append(packageFiles, packageFile)
+		}
+	}
+
+	if len(packageFiles) == 0 {
+		packageFiles = []string{"application.yaml"}
+	}
+	return packageFiles
+}
+
+// readConfigFile reads and parses a YAML configuration file together with the files it
+// includes (include: [...]). The included files are merged in order, then the keys of the
+// file itself override them. Included files may include further files.
+//
+// Parameters:
+//   - packageFileName: Path to the YAML configuration file
+//
+// Returns the parsed configuration, or an error if a file cannot be read or parsed, or the
+// includes form a cycle.
+func readConfigFile(packageFileName string) (packageParameter, error) {
+	return readIncludingConfigFile(packageFileName, nil)
+}
+
+// readIncludingConfigFile reads a configuration file and resolves its includes.
+//
+// Parameters:
+//   - packageFileName: Path to the YAML configuration file
+//   - including: Absolute paths of the files including this one (to detect cycles)
+func readIncludingConfigFile(packageFileName string, including []string) (packageParameter, error) {
+	absolutePath, err := filepath.Abs(packageFileName)
+	if err != nil {
+		return packageParameter{}, err
+	}
+	if slices.Contains(including, absolutePath) {
+		return packageParameter{}, fmt.Errorf("include cycle: %s", strings.Join(append(including, absolutePath), " -> "))
+	}
+
+	parameters, err := parseConfigFile(packageFileName)
+	if err != nil || len(parameters.Include) == 0 {
+		return parameters, err
+	}
+
+	// Included paths are relative to the including file
+	var merged packageParameter
+	for _, include := range parameters.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(packageFileName), include)
+		}
+
+		if _, err := os.Stat(include); err != nil {
+			return packageParameter{}, fmt.Errorf("cannot include %s in %s: %v", include, packageFileName, err)
+		}
+
+		logger.Debug("Including configuration file %s", include)
+		layer, err := readIncludingConfigFile(include, append(slices.Clone(including), absolutePath))
+		if err != nil {
+			return packageParameter{}, err
+		}
+		mergeParameters(&merged, layer)
+	}
+
+	parameters.Include = nil
+	mergeParameters(&merged, parameters)
+	return merged, nil
+}
+
+// parseConfigFile reads and parses a single YAML configuration file, without its includes.
+func parseConfigFile(packageFileName string) (packageParameter, error) {
+	var parameters packageParameter
+
 	// Read the entire file contents into memory
-	// For large files, streaming might be better, but YAML files are typically small
-	data, err := io.ReadAll(file)
+	// YAML configuration files are typically small
+	data, err := os.ReadFile(packageFileName)
 	if err != nil {
-		logger.Error(err)
-		return err
+		return parameters, err
 	}
 
-	// Parse the YAML data into the packageInfo struct
+	// Parse the YAML data into the struct
 	// yaml.Unmarshal uses the struct field tags (yaml:"key") to map YAML keys to fields
-	if err := yaml.Unmarshal(data, &packageInfo); err != nil {
-		logger.Error(err)
-		return err
+	if err := yaml.Unmarshal(data, &parameters); err != nil {
+		return parameters, fmt.Errorf("failed to parse %s: %v", packageFileName, err)
+	}
+
+	// Second pass: pick up Info.plist keys that have no dedicated field
+	if err := collectUnknownKeys(data, &parameters); err != nil {
+		return parameters, fmt.Errorf("failed to parse %s: %v", packageFileName, err)
+	}
+
+	// Remember which fields this file sets, to resolve its relative paths against its directory
+	value := reflect.ValueOf(parameters)
+	for index := 0; index < value.NumField(); index++ {
+		if field := value.Type().Field(index); field.IsExported() && !value.Field(index).IsZero() {
+			if parameters.configDirectories == nil {
+				parameters.configDirectories = make(map[string]string)
+			}
+			parameters.configDirectories[field.Name] = filepath.Dir(packageFileName)
+		}
 	}
 
-	// Close the file (though defer would be better practice)
-	err = file.Close()
-	return err
+	return parameters, nil
+}
+
+// mergeParameters copies every non-empty field of overlay over the corresponding field of base.
+// Empty values (empty strings, false, empty lists) in the overlay keep the base value.
+// Extra plist keys are merged key by key. The configuration directory of each copied field
+// is taken over from the overlay.
+func mergeParameters(base *packageParameter, overlay packageParameter) {
+	baseValue := reflect.ValueOf(base).Elem()
+	overlayValue := reflect.ValueOf(overlay)
+
+	for index := 0; index < overlayValue.NumField(); index++ {
+		field := overlayValue.Field(index)
+		name := overlayValue.Type().Field(index).Name
+		if !overlayValue.Type().Field(index).IsExported() || field.IsZero() || (field.Kind() == reflect.Slice && field.Len() == 0) {
+			continue
+		}
+
+		if directory, ok := overlay.configDirectories[name]; ok {
+			if base.configDirectories == nil {
+				base.configDirectories = make(map[string]string)
+			}
+			base.configDirectories[name] = directory
+		}
+
+		if field.Kind() == reflect.Map {
+			target := baseValue.Field(index)
+			if target.IsNil() {
+				target.Set(reflect.MakeMap(field.Type()))
+			}
+			for _, key := range field.MapKeys() {
+				target.SetMapIndex(key, field.MapIndex(key))
+			}
+			continue
+		}
+
+		baseValue.Field(index).Set(field)
+	}
+}
+
+// SetResolveRelativeToConfig enables or disables resolving relative paths in the configuration
+// (exec_file_directory, icon_file_directory, local_java_home, resources, ...) relative to the
+// directory containing the configuration file. When disabled, relative paths are interpreted
+// relative to the current working directory.
+//
+// Parameters:
+//   - enabled: true to resolve paths relative to the configuration file
+func SetResolveRelativeToConfig(enabled bool) {
+	resolveRelativeToConfig = enabled
+}
+
+// resolveConfigPath resolves a path from the configuration against the directory of the
+// configuration file that set the field. Empty and absolute paths are returned unchanged,
+// as are all paths if resolving relative to the configuration is disabled.
+//
+// Parameters:
+//   - field: Name of the packageParameter field holding the path (e.g. "IconFileDirectory")
+//   - path: Path to resolve
+func resolveConfigPath(field string, path string) string {
+	if !resolveRelativeToConfig || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	if directory, ok := packageInfo.configDirectories[field]; ok {
+		return filepath.Join(directory, path)
+	}
+	return filepath.Join(configBaseDirectory, path)
+}
+
+// applyDefaults sets default values for configuration fields that were left empty.
+// A warning is logged for each default applied so users know the value wasn't configured.
+func applyDefaults() {
+	if packageInfo.MinimumMacOSVersion == "" {
+		logger.Warn("system_minimal_os_version is not set, defaulting to %s", defaultMinimumMacOSVersion)
+		packageInfo.MinimumMacOSVersion = defaultMinimumMacOSVersion
+	}
+}
+
+// applyVersionFile sets the build version (CFBundleVersion) and the user-visible version
+// (CFBundleShortVersionString) to the content of the version file, trimmed of whitespace.
+// Values set in the configuration are overridden with a warning.
+//
+// Returns an error if the version file cannot be read or is empty.
+func applyVersionFile() error {
+	if packageInfo.VersionFile == "" {
+		return nil
+	}
+
+	versionFile := resolveConfigPath("VersionFile", packageInfo.VersionFile)
+	content, err := os.ReadFile(versionFile)
+	if err != nil {
+		return fmt.Errorf("failed to read version file: %v", err)
+	}
+
+	version := strings.TrimSpace(string(content))
+	if version == "" {
+		return fmt.Errorf("version file %s is empty", versionFile)
+	}
+
+	if packageInfo.BundleVersion != "" {
+		logger.Warn("version %q is overridden by version_file %s (%s)", packageInfo.BundleVersion, versionFile, version)
+	}
+	if packageInfo.CFBundleShortVersionString != "" {
+		logger.Warn("short_version_string %q is overridden by version_file %s (%s)", packageInfo.CFBundleShortVersionString, versionFile, version)
+	}
+
+	packageInfo.BundleVersion = version
+	packageInfo.CFBundleShortVersionString = version
+	return nil
 }
 
 // The following functions are getters that provide access to configuration values.
@@ -107,34 +460,160 @@ func Read(packageFileName string) error {
 // ValidateConfiguration ensures that all required files and directories exist
 // before the bundling process begins. This prevents partial builds.
 func ValidateConfiguration() error {
-	// 1. Check executable
-	execFile := GetExecutableName()
-	execDir := GetExecutableDirectory()
-	if GetLocalExecDirectory() != "" {
-		execDir = GetLocalExecDirectory()
-	}
-
-	fullExecPath := filepath.Join(execDir, execFile)
-	if _, err := os.Stat(fullExecPath); os.IsNotExist(err) {
-		return fmt.Errorf("executable file not found: %s", fullExecPath)
+	// 1. Check executable (or the Go package it is built from)
+	if goPackage := GetGoPackage(); goPackage != "" {
+		if info, err := os.Stat(goPackage); err != nil || !info.IsDir() {
+			return fmt.Errorf("go package directory not found: %s", goPackage)
+		}
+		if err := validateGoArchitectures(); err != nil {
+			return err
+		}
+	} else if fullExecPath := GetExecutablePath(); isExecutableURL(fullExecPath) {
+		if err := validateExecutableURL(fullExecPath, GetExecutableChecksum()); err != nil {
+			return err
+		}
+	} else {
+		if _, err := os.Stat(fullExecPath); os.IsNotExist(err) {
+			return fmt.Errorf("executable file not found: %s", fullExecPath)
+		}
 	}
 
-	// 2. Check icon file
+	// 2. Check icon file and asset catalog (not copied with -no-icon)
 	iconFile := GetIconFileName()
-	if iconFile != "" {
-		iconDir := GetIconFileDirectory()
-		fullIconPath := filepath.Join(iconDir, iconFile)
+	if iconFile != "" && !skipIcon {
+		fullIconPath := GetIconFilePath()
 		if _, err := os.Stat(fullIconPath); os.IsNotExist(err) {
 			return fmt.Errorf("icon file not found: %s", fullIconPath)
 		}
 	}
+	if assetCatalog := GetAssetCatalog(); assetCatalog != "" && !skipIcon {
+		if _, err := os.Stat(assetCatalog); os.IsNotExist(err) {
+			return fmt.Errorf("asset catalog not found: %s", assetCatalog)
+		}
+	}
 
-	// 3. Check Java Home if local Java is enabled
+	// 3. Check Java Home if local Java is enabled (one home per architecture, or a single one)
 	if GetUseLocalJava() {
-		javaHome := GetJavaHomeDirectory()
-		if _, err := os.Stat(javaHome); os.IsNotExist(err) {
-			return fmt.Errorf("local Java home directory not found: %s", javaHome)
+		if (packageInfo.JavaHomeArm64 == "") != (packageInfo.JavaHomeAmd64 == "") {
+			return fmt.Errorf("java_home_arm64 and java_home_amd64 must be set together")
 		}
+
+		javaHomes := []string{GetJavaHomeDirectory()}
+		if GetPerArchitectureJava() {
+			javaHomes = []string{GetJavaHomeArm64(), GetJavaHomeAmd64()}
+		}
+		for _, javaHome := range javaHomes {
+			if _, err := os.Stat(javaHome); os.IsNotExist(err) {
+				return fmt.Errorf("local Java home directory not found: %s", javaHome)
+			}
+		}
+	}
+
+	// 4. Check the Java runtime layout
+	if layout := GetRuntimeLayout(); layout != runtimeLayoutJava && layout != runtimeLayoutJPackage {
+		return fmt.Errorf("invalid runtime_layout %q: expected %q or %q", layout, runtimeLayoutJava, runtimeLayoutJPackage)
+	}
+	if mode := GetJavaRuntimeMode(); mode != javaRuntimeModeCopy && mode != javaRuntimeModeSymlink {
+		return fmt.Errorf("invalid java_runtime_mode %q: expected %q or %q", mode, javaRuntimeModeCopy, javaRuntimeModeSymlink)
+	}
+
+	// 5. Check additional resource files and directories (including the splash image)
+	for _, resource := range GetResources() {
+		if _, err := os.Stat(resource); os.IsNotExist(err) {
+			return fmt.Errorf("resource not found: %s", resource)
+		}
+	}
+	if splashImage := GetSplashImage(); splashImage != "" {
+		if _, err := os.Stat(splashImage); os.IsNotExist(err) {
+			return fmt.Errorf("splash image not found: %s", splashImage)
+		}
+	}
+	if helpBookFolder := GetHelpBookFolder(); helpBookFolder != "" {
+		if info, err := os.Stat(helpBookFolder); err != nil || !info.IsDir() {
+			return fmt.Errorf("help book folder not found: %s", helpBookFolder)
+		}
+		if GetHelpBookName() == "" {
+			return fmt.Errorf("help_book_name is required when help_book_folder is set")
+		}
+	}
+
+	// 6. Check the format of the minimum macOS version
+	minimumVersion := GetMinimumMacOSVersion()
+	if minimumVersion != "" && !macOSVersionPattern.MatchString(minimumVersion) {
+		return fmt.Errorf("invalid system_minimal_os_version %q: expected X.Y or X.Y.Z", minimumVersion)
+	}
+
+	for architecture, version := range GetMinimumMacOSVersionByArchitecture() {
+		if !minimumVersionArchitectures[architecture] {
+			return fmt.Errorf("invalid min_os_by_arch architecture %q: expected arm64 or x86_64", architecture)
+		}
+		if !macOSVersionPattern.MatchString(version) {
+			return fmt.Errorf("invalid min_os_by_arch version %q for %s: expected X.Y or X.Y.Z", version, architecture)
+		}
+	}
+
+	// 7. Check the format of the user-visible version and the Info.plist format version (optional)
+	shortVersion := GetCFBundleShortVersionString()
+	if shortVersion != "" && !shortVersionPattern.MatchString(shortVersion) {
+		return fmt.Errorf("invalid short_version_string %q: expected up to three period-separated integers (e.g. \"1.2.3\")", shortVersion)
+	}
+	if !infoDictionaryVersionPattern.MatchString(GetInfoDictionaryVersion()) {
+		return fmt.Errorf("invalid info_dictionary_version %q: expected a version number like \"%s\"", GetInfoDictionaryVersion(), defaultInfoDictionaryVersion)
+	}
+
+	// 8. Check the format of the development region
+	if !developmentRegionPattern.MatchString(GetDevelopmentRegion()) {
+		return fmt.Errorf("invalid development_region %q: expected a language code like \"en\" or \"pt-BR\"", GetDevelopmentRegion())
+	}
+
+	// 9. Check the additional Info.plist keys
+	if err := validateExtraPlistKeys(GetExtraPlistKeys()); err != nil {
+		return err
+	}
+
+	// 10. Check the permissions of the bundle executable and directories (optional)
+	if packageInfo.ExecutableMode != "" {
+		if _, err := parseFileMode(packageInfo.ExecutableMode); err != nil {
+			return fmt.Errorf("invalid executable_mode %q: %v", packageInfo.ExecutableMode, err)
+		}
+	}
+	if packageInfo.DirectoryMode != "" {
+		mode, err := parseFileMode(packageInfo.DirectoryMode)
+		if err != nil {
+			return fmt.Errorf("invalid directory_mode %q: %v", packageInfo.DirectoryMode, err)
+		}
+		// The bundle is filled after its directories are created
+		if mode.Perm()&0700 != 0700 {
+			return fmt.Errorf("invalid directory_mode %q: the owner needs read, write and execute permission (0700)", packageInfo.DirectoryMode)
+		}
+	}
+
+	// 11. Check the requirement file of the signature (optional)
+	if isRequirementFile(packageInfo.SigningRequirement) {
+		requirementFile := GetSigningRequirement()
+		if _, err := os.Stat(requirementFile); os.IsNotExist(err) {
+			return fmt.Errorf("signing requirement file not found: %s", requirementFile)
+		}
+	}
+
+	// 12. Check the disk space factor (optional)
+	if packageInfo.DiskSpaceFactor != 0 && packageInfo.DiskSpaceFactor < 1 {
+		return fmt.Errorf("invalid disk_space_factor %g: must be at least 1", packageInfo.DiskSpaceFactor)
+	}
+
+	// 13. Check the launch agent (optional)
+	if agent := GetLaunchAgent(); agent != nil {
+		if err := validateLaunchAgent(agent); err != nil {
+			return err
+		}
+	}
+
+	// 14. Reject files excluded from the signature: codesign has ignored custom resource rules
+	// since OS X 10.10 and seals every file of the bundle, so modifying one breaks the signature
+	if len(packageInfo.SigningExcludes) > 0 {
+		return fmt.Errorf("signing_excludes is not supported: codesign seals every file of the bundle " +
+			"(resource rules are ignored since OS X 10.10); store modifiable files outside the bundle, " +
+			"e.g. copy a default from Resources to ~/Library/Application Support on first launch")
 	}
 
 	return nil
@@ -155,6 +634,11 @@ func GetBundleVersion() string {
 	return packageInfo.BundleVersion
 }
 
+// GetVersionScheme returns the configured version scheme ("calver", or "" for the version from the configuration).
+func GetVersionScheme() string {
+	return packageInfo.VersionScheme
+}
+
 // GetBundleExecutable returns the name of the executable file (CFBundleExecutable in Info.plist).
 func GetBundleExecutable() string {
 	return packageInfo.BundleExecutable
@@ -165,14 +649,66 @@ func GetMinimumMacOSVersion() string {
 	return packageInfo.MinimumMacOSVersion
 }
 
+// GetMinimumMacOSVersionByArchitecture returns the minimum macOS versions per architecture
+// (LSMinimumSystemVersionByArchitecture), e.g. {"arm64": "11.0"}. Empty if not configured.
+func GetMinimumMacOSVersionByArchitecture() map[string]string {
+	return packageInfo.MinimumMacOSByArchitecture
+}
+
+// GetDevelopmentRegion returns the default language of the bundle, defaulting to "en".
+func GetDevelopmentRegion() string {
+	if packageInfo.DevelopmentRegion != "" {
+		return packageInfo.DevelopmentRegion
+	}
+	return defaultDevelopmentRegion
+}
+
+// GetInfoDictionaryVersion returns the Info.plist format version, defaulting to "6.0".
+func GetInfoDictionaryVersion() string {
+	if packageInfo.InfoDictionaryVersion != "" {
+		return packageInfo.InfoDictionaryVersion
+	}
+	return defaultInfoDictionaryVersion
+}
+
+// GetMultipleInstancesProhibited returns true if only one instance of the app may run at a time.
+func GetMultipleInstancesProhibited() bool {
+	return packageInfo.MultipleInstancesProhibited
+}
+
 // GetIconFileName returns the name of the icon file (without directory path).
 func GetIconFileName() string {
 	return packageInfo.IconFileName
 }
 
+// GetIconName returns the name of the icon in the asset catalog (CFBundleIconName).
+func GetIconName() string {
+	return packageInfo.IconName
+}
+
+// GetAssetCatalog returns the resolved path of the compiled asset catalog, or "" if not set.
+func GetAssetCatalog() string {
+	if packageInfo.AssetCatalog == "" {
+		return ""
+	}
+	return resolveConfigPath("AssetCatalog", packageInfo.AssetCatalog)
+}
+
 // GetIconFileDirectory returns the directory containing the icon file.
 func GetIconFileDirectory() string {
-	return packageInfo.IconFileDirectory
+	return resolveConfigPath("IconFileDirectory", packageInfo.IconFileDirectory)
+}
+
+// GetIconFilePath returns the resolved source path of the icon file.
+// Example: icon_file_directory: ./test/icon, icon_file: appIcon.icns
+// Results in: ./test/icon/appIcon.icns
+func GetIconFilePath() string {
+	iconDirectory := GetIconFileDirectory()
+	if iconDirectory == "" {
+		return resolveConfigPath("IconFileName", GetIconFileName())
+	}
+
+	return filepath.Join(iconDirectory, GetIconFileName())
 }
 
 // GetPackageType returns the bundle package type, defaulting to "APP" if not specified.
@@ -191,7 +727,37 @@ func GetExecutableName() string {
 
 // GetExecutableDirectory returns the directory containing the executable/JAR file.
 func GetExecutableDirectory() string {
-	return packageInfo.ExecFileDirectory
+	return resolveConfigPath("ExecFileDirectory", packageInfo.ExecFileDirectory)
+}
+
+// GetExecutablePath returns the resolved source path of the executable/JAR file:
+//   - An HTTP(S) URL is returned unchanged (the file is downloaded by CopyExecutable)
+//   - An absolute exec_file is used as is
+//   - A relative exec_file (a plain name or a path like "build/myapp") is resolved
+//     relative to local_exec_directory if set, otherwise relative to exec_file_directory
+func GetExecutablePath() string {
+	execFile := GetExecutableName()
+	if isExecutableURL(execFile) {
+		return execFile
+	}
+	if filepath.IsAbs(execFile) {
+		return filepath.Clean(execFile)
+	}
+
+	execDir := GetExecutableDirectory()
+	if GetLocalExecDirectory() != "" {
+		execDir = GetLocalExecDirectory()
+	}
+	if execDir == "" {
+		return resolveConfigPath("ExecFileName", execFile)
+	}
+
+	return filepath.Join(execDir, execFile)
+}
+
+// GetExecutableChecksum returns the expected SHA-256 checksum of a downloaded executable, or "".
+func GetExecutableChecksum() string {
+	return strings.TrimSpace(packageInfo.ExecFileSHA256)
 }
 
 // GetUseLocalJava returns true if the configuration specifies bundling a local Java runtime.
@@ -203,9 +769,50 @@ func GetUseLocalJava() bool {
 	return false
 }
 
+// GetRuntimeLayout returns the layout of the bundled Java runtime, defaulting to "java".
+func GetRuntimeLayout() string {
+	if packageInfo.RuntimeLayout != "" {
+		return strings.ToLower(packageInfo.RuntimeLayout)
+	}
+	return runtimeLayoutJava
+}
+
+// GetJavaRuntimeMode returns how the Java runtime is placed into the bundle, defaulting to "copy".
+func GetJavaRuntimeMode() string {
+	if packageInfo.JavaRuntimeMode != "" {
+		return strings.ToLower(packageInfo.JavaRuntimeMode)
+	}
+	return javaRuntimeModeCopy
+}
+
+// GetTargetArchitecture returns the architecture the bundle is built for.
+// Defaults to the architecture of the host (x86_64 or arm64).
+func GetTargetArchitecture() string {
+	if packageInfo.TargetArchitecture != "" {
+		return packageInfo.TargetArchitecture
+	}
+	return hostArchitecture()
+}
+
 // GetJavaHomeDirectory returns the path to the Java installation to bundle (if local_java is enabled).
 func GetJavaHomeDirectory() string {
-	return packageInfo.LocalJavaHome
+	return resolveConfigPath("LocalJavaHome", packageInfo.LocalJavaHome)
+}
+
+// GetPerArchitectureJava returns true if a separate Java installation is bundled for each
+// architecture (java_home_arm64 and java_home_amd64), selected by the launcher at start.
+func GetPerArchitectureJava() bool {
+	return packageInfo.JavaHomeArm64 != "" && packageInfo.JavaHomeAmd64 != ""
+}
+
+// GetJavaHomeArm64 returns the path to the Java installation bundled for Apple silicon.
+func GetJavaHomeArm64() string {
+	return resolveConfigPath("JavaHomeArm64", packageInfo.JavaHomeArm64)
+}
+
+// GetJavaHomeAmd64 returns the path to the Java installation bundled for Intel Macs.
+func GetJavaHomeAmd64() string {
+	return resolveConfigPath("JavaHomeAmd64", packageInfo.JavaHomeAmd64)
 }
 
 // GetBundleDisplayName returns the user-visible name of the bundle.
@@ -213,16 +820,17 @@ func GetBundleDisplayName() string {
 	return packageInfo.BundleDisplayName
 }
 
+// GetBundleSpokenName returns the name VoiceOver speaks for the bundle (CFBundleSpokenName),
+// or "" if the name is not set.
+func GetBundleSpokenName() string {
+	return packageInfo.BundleSpokenName
+}
+
 // GetBundleSignature returns the build signature.
 func GetBundleSignature() string {
 	return packageInfo.BundleSignature
 }
 
-// GetCFBundleDocumentTypes returns the document types this app can open.
-func GetCFBundleDocumentTypes() string {
-	return packageInfo.CFBundleDocumentTypes
-}
-
 // GetCFBundleShortVersionString returns the user-visible version string.
 func GetCFBundleShortVersionString() string {
 	return packageInfo.CFBundleShortVersionString
@@ -243,7 +851,237 @@ func GetNSPrincipalClass() string {
 	return packageInfo.NSPrincipalClass
 }
 
+// GetAllowMixedLocalizations returns true if CFBundleAllowMixedLocalizations should be set.
+func GetAllowMixedLocalizations() bool {
+	return packageInfo.AllowMixedLocalizations
+}
+
+// GetExtraPlistKeys returns the additional Info.plist keys and their values.
+func GetExtraPlistKeys() map[string]interface{} {
+	return packageInfo.ExtraPlistKeys
+}
+
+// GetSkipPkgInfo returns true if the configuration disables the legacy PkgInfo file.
+func GetSkipPkgInfo() bool {
+	return packageInfo.SkipPkgInfo
+}
+
+// GetNotaryProfile returns the template of the notarization keychain profile ("" if not set).
+func GetNotaryProfile() string {
+	return packageInfo.NotaryProfile
+}
+
+// GetMetadataFromManifest returns true if an empty name and short_version_string are taken
+// from the manifest of the JAR.
+func GetMetadataFromManifest() bool {
+	return packageInfo.MetadataFromManifest
+}
+
 // GetLocalExecDirectory returns the alternative executable directory.
 func GetLocalExecDirectory() string {
-	return packageInfo.LocalExecDirectory
+	return resolveConfigPath("LocalExecDirectory", packageInfo.LocalExecDirectory)
+}
+
+// GetStripBinary returns true if debug symbols should be stripped from the copied binary.
+func GetStripBinary() bool {
+	return packageInfo.StripBinary
+}
+
+// GetPreserveXattrs returns true if the executable is copied with its extended attributes.
+func GetPreserveXattrs() bool {
+	return packageInfo.PreserveXattrs
+}
+
+// GetGoPackage returns the resolved directory of the Go package to build, or "" if the
+// executable is taken from exec_file.
+func GetGoPackage() string {
+	if packageInfo.GoPackage == "" {
+		return ""
+	}
+	return resolveConfigPath("GoPackage", packageInfo.GoPackage)
+}
+
+// GetGoOS returns the GOOS the Go package is built for, defaulting to "darwin".
+func GetGoOS() string {
+	if packageInfo.GoOS != "" {
+		return packageInfo.GoOS
+	}
+	return "darwin"
+}
+
+// GetGoArch returns the GOARCH the Go package is built for.
+// Defaults to the Go name of the target architecture (amd64 for x86_64, arm64).
+func GetGoArch() string {
+	if packageInfo.GoArch != "" {
+		return packageInfo.GoArch
+	}
+	return goArchitecture(GetTargetArchitecture())
+}
+
+// GetGoArchitectures returns the GOARCH values (amd64 for x86_64, arm64) combined into a
+// universal binary, or nil if a single architecture is built (see GetGoArch).
+func GetGoArchitectures() []string {
+	var architectures []string
+	for _, architecture := range packageInfo.GoArchitectures {
+		architectures = append(architectures, goArchitecture(architecture))
+	}
+	return architectures
+}
+
+// validateGoArchitectures checks go_architectures: only the macOS architectures arm64 and
+// amd64 (x86_64), each once, not combined with go_arch, and only for GOOS darwin.
+func validateGoArchitectures() error {
+	architectures := GetGoArchitectures()
+	if len(architectures) == 0 {
+		return nil
+	}
+
+	if packageInfo.GoArch != "" {
+		return fmt.Errorf("go_arch and go_architectures cannot be combined")
+	}
+	if GetGoOS() != "darwin" {
+		return fmt.Errorf("go_architectures requires go_os darwin (universal binaries are Mach-O only)")
+	}
+
+	seen := map[string]bool{}
+	for _, architecture := range architectures {
+		if architecture != "arm64" && architecture != "amd64" {
+			return fmt.Errorf("invalid go_architectures entry %q (expected arm64 or amd64)", architecture)
+		}
+		if seen[architecture] {
+			return fmt.Errorf("go_architectures lists %s twice", architecture)
+		}
+		seen[architecture] = true
+	}
+
+	return nil
+}
+
+// GetGoLdflags returns the linker flags passed to "go build" with -ldflags.
+func GetGoLdflags() string {
+	return packageInfo.GoLdflags
+}
+
+// GetArgumentLauncher returns true if a compiled binary is started through a launcher script
+// forwarding all arguments (the binary is copied as <executable>-bin).
+func GetArgumentLauncher() bool {
+	return packageInfo.ArgumentLauncher
+}
+
+// GetExecutableMode returns the permissions of the bundle executable, defaulting to 0755.
+// An invalid executable_mode (rejected by ValidateConfiguration) also yields the default.
+func GetExecutableMode() os.FileMode {
+	if packageInfo.ExecutableMode == "" {
+		return defaultExecutableMode
+	}
+
+	mode, err := parseFileMode(packageInfo.ExecutableMode)
+	if err != nil {
+		return defaultExecutableMode
+	}
+	return mode
+}
+
+// GetDirectoryMode returns the permissions of the directories created in the bundle, defaulting
+// to 0755. An invalid directory_mode (rejected by ValidateConfiguration) also yields the default.
+func GetDirectoryMode() os.FileMode {
+	if packageInfo.DirectoryMode == "" {
+		return defaultDirectoryMode
+	}
+
+	mode, err := parseFileMode(packageInfo.DirectoryMode)
+	if err != nil {
+		return defaultDirectoryMode
+	}
+	return mode
+}
+
+// parseFileMode parses an octal permission string like "0750" or "2755".
+// The setuid (4000), setgid (2000) and sticky (1000) bits are converted to the matching
+// os.FileMode flags, as os.Chmod ignores them in the permission bits.
+func parseFileMode(value string) (os.FileMode, error) {
+	bits, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("expected an octal file mode like \"0755\"")
+	}
+	if bits > 07777 {
+		return 0, fmt.Errorf("file mode out of range (maximum is 7777)")
+	}
+
+	mode := os.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+
+	return mode, nil
+}
+
+// GetSigningRequirement returns the designated requirement of the signature: the requirement
+// text, or the resolved path of a .csreq file. Returns "" if codesign derives the default.
+func GetSigningRequirement() string {
+	if isRequirementFile(packageInfo.SigningRequirement) {
+		return resolveConfigPath("SigningRequirement", packageInfo.SigningRequirement)
+	}
+	return packageInfo.SigningRequirement
+}
+
+// GetSplashImage returns the resolved path of the Java splash screen image, or "" if not set.
+func GetSplashImage() string {
+	if packageInfo.SplashImage == "" {
+		return ""
+	}
+	return resolveConfigPath("SplashImage", packageInfo.SplashImage)
+}
+
+// GetHelpBookFolder returns the resolved path of the Apple Help bundle, or "" if not set.
+func GetHelpBookFolder() string {
+	if packageInfo.HelpBookFolder == "" {
+		return ""
+	}
+	return resolveConfigPath("HelpBookFolder", packageInfo.HelpBookFolder)
+}
+
+// GetHelpBookName returns the name of the help book (CFBundleHelpBookName).
+func GetHelpBookName() string {
+	return packageInfo.HelpBookName
+}
+
+// GetDiskSpaceFactor returns the factor applied to the size of the sources to estimate the
+// disk space needed for the bundle. Defaults to 2.
+func GetDiskSpaceFactor() float64 {
+	if packageInfo.DiskSpaceFactor == 0 {
+		return defaultDiskSpaceFactor
+	}
+	return packageInfo.DiskSpaceFactor
+}
+
+// GetResources returns the additional files and directories copied into Contents/Resources/.
+func GetResources() []string {
+	var resources []string
+
+	for _, resource := range packageInfo.Resources {
+		resources = append(resources, resolveConfigPath("Resources", resource))
+	}
+
+	return resources
+}
+
+// GetServices returns the NSServices declarations. An empty port name defaults to the bundle name.
+func GetServices() []Service {
+	var services []Service
+
+	for _, service := range packageInfo.Services {
+		if service.PortName == "" {
+			service.PortName = GetBundleName()
+		}
+		services = append(services, service)
+	}
+
+	return services
 }
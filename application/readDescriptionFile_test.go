@@ -0,0 +1,510 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readTestConfig writes the configuration and an executable "MyApp" into a temporary
+// directory and reads the configuration, with exec_file_directory set to that directory.
+// The previous configuration is restored afterwards.
+func readTestConfig(t *testing.T, content string) error {
+	t.Helper()
+	setTestPackageInfo(t, packageParameter{})
+	previousBaseDirectory := configBaseDirectory
+	t.Cleanup(func() { configBaseDirectory = previousBaseDirectory })
+
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "MyApp"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	packageFileName := filepath.Join(directory, "application.yaml")
+	content = "exec_file_directory: " + directory + "\n" + content
+	if err := os.WriteFile(packageFileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Read(packageFileName)
+}
+
+func TestMinimumMacOSVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    string
+		wantErr string
+	}{
+		{name: "default", want: defaultMinimumMacOSVersion},
+		{name: "major and minor", config: "system_minimal_os_version: \"11.0\"\n", want: "11.0"},
+		{name: "major minor and patch", config: "system_minimal_os_version: 10.15.7\n", want: "10.15.7"},
+		{name: "name instead of number", config: "system_minimal_os_version: Catalina\n", wantErr: "invalid system_minimal_os_version"},
+		{name: "major only", config: "system_minimal_os_version: \"14\"\n", wantErr: "invalid system_minimal_os_version"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\n"+test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := GetMinimumMacOSVersion(); got != test.want {
+				t.Errorf("minimum version = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInfoDictionaryVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "6.0"},
+		{name: "override", config: "info_dictionary_version: \"7.0\"\n", want: "7.0"},
+		{name: "markup", config: "info_dictionary_version: 6.0</string>\n", wantErr: true},
+		{name: "text", config: "info_dictionary_version: latest\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\n"+test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid info_dictionary_version") {
+					t.Fatalf("error = %v, want an invalid info_dictionary_version", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := testPlistData()
+			data.InfoDictionaryVersion = NewInfoPlistData().InfoDictionaryVersion
+			if got := renderTestPlist(t, data)["CFBundleInfoDictionaryVersion"]; got != test.want {
+				t.Errorf("CFBundleInfoDictionaryVersion = %v, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestSigningExcludesRejected checks that a bundle cannot leave files out of its seal, as
+// codesign ignores resource rules and the signature would break once such a file changes.
+func TestSigningExcludesRejected(t *testing.T) {
+	if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\nsigning_excludes:\n  - Contents/Resources/settings.conf\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ValidateConfiguration()
+	if err == nil || !strings.Contains(err.Error(), "signing_excludes is not supported") {
+		t.Fatalf("error = %v, want signing_excludes to be rejected", err)
+	}
+}
+
+func TestSplitConfigFiles(t *testing.T) {
+	tests := []struct {
+		packageFileNames string
+		want             []string
+	}{
+		{"", []string{"application.yaml"}},
+		{"app.yaml", []string{"app.yaml"}},
+		{"base.yaml, release.yaml,", []string{"base.yaml", "release.yaml"}},
+	}
+
+	for _, test := range tests {
+		if got := SplitConfigFiles(test.packageFileNames); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitConfigFiles(%q) = %q, want %q", test.packageFileNames, got, test.want)
+		}
+	}
+}
+
+func TestMergeParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    packageParameter
+		overlay packageParameter
+		want    packageParameter
+	}{
+		{
+			name:    "overlay value replaces base value",
+			base:    packageParameter{BundleName: "MyApp", BundleVersion: "1"},
+			overlay: packageParameter{BundleVersion: "2"},
+			want:    packageParameter{BundleName: "MyApp", BundleVersion: "2"},
+		},
+		{
+			name:    "empty overlay keeps base values",
+			base:    packageParameter{StripBinary: true, Resources: []string{"README.md"}},
+			overlay: packageParameter{Resources: []string{}},
+			want:    packageParameter{StripBinary: true, Resources: []string{"README.md"}},
+		},
+		{
+			name:    "lists are replaced, not appended",
+			base:    packageParameter{Resources: []string{"README.md"}},
+			overlay: packageParameter{Resources: []string{"LICENSE"}},
+			want:    packageParameter{Resources: []string{"LICENSE"}},
+		},
+		{
+			name:    "extra plist keys are merged by key",
+			base:    packageParameter{ExtraPlistKeys: map[string]interface{}{"A": "base", "B": "base"}},
+			overlay: packageParameter{ExtraPlistKeys: map[string]interface{}{"B": "overlay", "C": true}},
+			want:    packageParameter{ExtraPlistKeys: map[string]interface{}{"A": "base", "B": "overlay", "C": true}},
+		},
+		{
+			name:    "extra plist keys without base keys",
+			overlay: packageParameter{ExtraPlistKeys: map[string]interface{}{"C": true}},
+			want:    packageParameter{ExtraPlistKeys: map[string]interface{}{"C": true}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := test.base
+			mergeParameters(&merged, test.overlay)
+			if !reflect.DeepEqual(merged, test.want) {
+				t.Errorf("mergeParameters() = %+v, want %+v", merged, test.want)
+			}
+		})
+	}
+}
+
+// TestResolveRelativeToConfig reads a configuration including a file from a subdirectory,
+// with an overlay in another directory, while the working directory is elsewhere. Relative
+// paths must resolve against the directory of the file that declares them.
+func TestResolveRelativeToConfig(t *testing.T) {
+	setTestPackageInfo(t, packageParameter{})
+	previousBaseDirectory, previousResolve := configBaseDirectory, resolveRelativeToConfig
+	t.Cleanup(func() { configBaseDirectory, resolveRelativeToConfig = previousBaseDirectory, previousResolve })
+	SetResolveRelativeToConfig(true)
+
+	directory := t.TempDir()
+	writeBundleFile(t, directory, "bin/MyApp", "#!/bin/sh\n")
+	writeBundleFile(t, directory, "application.yaml", "exec_file: MyApp\nexec_file_directory: bin\ninclude: [shared/paths.yaml]\n")
+	writeBundleFile(t, directory, "shared/paths.yaml", "icon_file_directory: icons\nresources: [README.md]\n")
+	writeBundleFile(t, directory, "local/local.yaml", "local_java_home: jdk\n")
+
+	setTestWorkingDirectory(t)
+
+	if err := Read(filepath.Join(directory, "application.yaml") + "," + filepath.Join(directory, "local", "local.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"base file", GetExecutableDirectory(), filepath.Join(directory, "bin")},
+		{"included file", GetIconFileDirectory(), filepath.Join(directory, "shared", "icons")},
+		{"included list", strings.Join(GetResources(), ","), filepath.Join(directory, "shared", "README.md")},
+		{"overlay file", GetJavaHomeDirectory(), filepath.Join(directory, "local", "jdk")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Errorf("path = %s, want %s", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestShortVersionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr bool
+	}{
+		{name: "not set"},
+		{name: "one part", version: "2"},
+		{name: "two parts", version: "1.2"},
+		{name: "three parts", version: "1.2.3"},
+		{name: "pre-release", version: "1.0.0-beta", wantErr: true},
+		{name: "prefix", version: "v1.0", wantErr: true},
+		{name: "four parts", version: "1.2.3.4", wantErr: true},
+		{name: "empty part", version: "1..2", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := "name: MyApp\nexec_file: MyApp\n"
+			if test.version != "" {
+				config += "short_version_string: \"" + test.version + "\"\n"
+			}
+			if err := readTestConfig(t, config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid short_version_string") {
+					t.Fatalf("error = %v, want an invalid short_version_string", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := testPlistData()
+			data.ShortVersionString = NewInfoPlistData().ShortVersionString
+			if got := renderTestPlist(t, data)["CFBundleShortVersionString"]; got != test.version {
+				t.Errorf("CFBundleShortVersionString = %v, want %q", got, test.version)
+			}
+		})
+	}
+}
+
+func TestDevelopmentRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "en"},
+		{name: "language", region: "de", want: "de"},
+		{name: "language and region", region: "pt-BR", want: "pt-BR"},
+		{name: "underscore", region: "zh_CN", want: "zh_CN"},
+		{name: "script", region: "zh-Hans", want: "zh-Hans"},
+		{name: "language name", region: "German", wantErr: true},
+		{name: "too many parts", region: "en-US-POSIX", wantErr: true},
+		{name: "markup", region: "en<", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := "name: MyApp\nexec_file: MyApp\n"
+			if test.region != "" {
+				config += "development_region: \"" + test.region + "\"\n"
+			}
+			if err := readTestConfig(t, config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid development_region") {
+					t.Fatalf("error = %v, want an invalid development_region", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := testPlistData()
+			data.DevelopmentRegion = NewInfoPlistData().DevelopmentRegion
+			if got := renderTestPlist(t, data)["CFBundleDevelopmentRegion"]; got != test.want {
+				t.Errorf("CFBundleDevelopmentRegion = %v, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMinimumMacOSVersionByArchitecture(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{name: "not set"},
+		{
+			name:   "both architectures",
+			config: "min_os_by_arch:\n  arm64: \"11.0\"\n  x86_64: \"10.13\"\n",
+			want:   map[string]interface{}{"arm64": "11.0", "x86_64": "10.13"},
+		},
+		{
+			name:   "one architecture",
+			config: "min_os_by_arch:\n  arm64: \"11.0.1\"\n",
+			want:   map[string]interface{}{"arm64": "11.0.1"},
+		},
+		{name: "unknown architecture", config: "min_os_by_arch:\n  ppc: \"10.5\"\n", wantErr: "invalid min_os_by_arch architecture"},
+		{name: "invalid version", config: "min_os_by_arch:\n  arm64: eleven\n", wantErr: "invalid min_os_by_arch version"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := readTestConfig(t, "name: MyApp\nexec_file: MyApp\n"+test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateConfiguration()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := testPlistData()
+			data.MinSystemVersionByArchitecture = NewInfoPlistData().MinSystemVersionByArchitecture
+			dictionary := renderTestPlist(t, data)
+
+			got, found := dictionary["LSMinimumSystemVersionByArchitecture"]
+			if test.want == nil {
+				if found {
+					t.Errorf("LSMinimumSystemVersionByArchitecture = %v, want no key", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LSMinimumSystemVersionByArchitecture = %v, want %v", got, test.want)
+			}
+			if dictionary["LSMinimumSystemVersion"] != "11.0" {
+				t.Errorf("LSMinimumSystemVersion = %v, want the fallback 11.0", dictionary["LSMinimumSystemVersion"])
+			}
+		})
+	}
+}
+
+func TestGetExecutablePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter packageParameter
+		want      string
+	}{
+		{
+			name:      "file name",
+			parameter: packageParameter{ExecFileName: "myapp", ExecFileDirectory: "/build"},
+			want:      "/build/myapp",
+		},
+		{
+			name:      "nested relative path",
+			parameter: packageParameter{ExecFileName: "bin/release/myapp", ExecFileDirectory: "/build"},
+			want:      "/build/bin/release/myapp",
+		},
+		{
+			name:      "relative directory",
+			parameter: packageParameter{ExecFileName: "myapp", ExecFileDirectory: "build"},
+			want:      "build/myapp",
+		},
+		{
+			name:      "absolute path",
+			parameter: packageParameter{ExecFileName: "/opt/build/../release/myapp", ExecFileDirectory: "/build"},
+			want:      "/opt/release/myapp",
+		},
+		{
+			name:      "no directory",
+			parameter: packageParameter{ExecFileName: "build/myapp"},
+			want:      "build/myapp",
+		},
+		{
+			name:      "local exec directory",
+			parameter: packageParameter{ExecFileName: "myapp", ExecFileDirectory: "/build", LocalExecDirectory: "/local"},
+			want:      "/local/myapp",
+		},
+		{
+			name:      "URL",
+			parameter: packageParameter{ExecFileName: "https://example.com/myapp", ExecFileDirectory: "/build"},
+			want:      "https://example.com/myapp",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, test.parameter)
+			if got := GetExecutablePath(); got != test.want {
+				t.Errorf("GetExecutablePath() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    os.FileMode
+		wantErr string
+	}{
+		{value: "0750", want: 0750},
+		{value: "755", want: 0755},
+		{value: "4755", want: os.ModeSetuid | 0755},
+		{value: "1777", want: os.ModeSticky | 0777},
+		{value: "0758", wantErr: "expected an octal file mode"},
+		{value: "rwxr-x---", wantErr: "expected an octal file mode"},
+		{value: "17777", wantErr: "out of range"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseFileMode(test.value)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("parseFileMode(%q) = %v, %v, want %v", test.value, got, err, test.want)
+			}
+		})
+	}
+}
+
+// TestVersionFile reads the version from a VERSION file: the plist reflects the trimmed
+// content, and the version of the configuration is overridden with a warning.
+func TestVersionFile(t *testing.T) {
+	directory := t.TempDir()
+	writeBundleFile(t, directory, "VERSION", " 2.3.4\n")
+	writeBundleFile(t, directory, "EMPTY", "\n")
+
+	tests := []struct {
+		name        string
+		versionFile string
+		want        string
+		wantErr     string
+	}{
+		{name: "version file", versionFile: "VERSION", want: "2.3.4"},
+		{name: "empty version file", versionFile: "EMPTY", wantErr: "is empty"},
+		{name: "missing version file", versionFile: "MISSING", wantErr: "failed to read version file"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := captureTestLog(t)
+
+			err := readTestConfig(t, testPlistConfig+"version_file: "+filepath.Join(directory, test.versionFile)+"\n")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dictionary := renderTestPlist(t, NewInfoPlistData())
+			for _, key := range []string{"CFBundleVersion", "CFBundleShortVersionString"} {
+				if dictionary[key] != test.want {
+					t.Errorf("%s = %v, want %s", key, dictionary[key], test.want)
+				}
+			}
+			if !strings.Contains(log.String(), `version "1" is overridden by version_file`) {
+				t.Errorf("log = %q, want a warning about the overridden version", log.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package application: This file supports reproducible builds (-reproducible). The content of
+// a bundle only depends on its inputs, but the modification times of its files, the order of
+// the entries in the zip archive and a CalVer build number depend on when and where it was
+// built. With -reproducible, all of them are derived from a fixed point in time instead, so
+// two builds from the same inputs are byte-identical before signing (signatures contain a
+// secure timestamp and always differ).
+package application
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// sourceDateEpochVariable is the environment variable defining the time of a reproducible
+// build (seconds since 1970-01-01 UTC, see https://reproducible-builds.org/specs/source-date-epoch/)
+const sourceDateEpochVariable = "SOURCE_DATE_EPOCH"
+
+// defaultSourceDateEpoch is used when SOURCE_DATE_EPOCH is not set: 1980-01-01 00:00:00 UTC,
+// the earliest time a zip archive can store
+const defaultSourceDateEpoch int64 = 315532800
+
+// reproducible is set with -reproducible
+var reproducible = false
+
+// sourceDateEpoch is the modification time of all files of a reproducible build
+var sourceDateEpoch time.Time
+
+// SetReproducible enables reproducible builds. The build time is read from SOURCE_DATE_EPOCH,
+// or defaultSourceDateEpoch if it is not set; it is also used for CalVer build numbers.
+//
+// Returns an error if SOURCE_DATE_EPOCH is not a non-negative number of seconds.
+func SetReproducible(enabled bool) error {
+	reproducible = enabled
+	if !enabled {
+		return nil
+	}
+
+	epoch := defaultSourceDateEpoch
+	if value, found := os.LookupEnv(sourceDateEpochVariable); found && value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			return fmt.Errorf("invalid %s %q: expected the number of seconds since 1970-01-01", sourceDateEpochVariable, value)
+		}
+		epoch = seconds
+	}
+
+	sourceDateEpoch = time.Unix(epoch, 0).UTC()
+	versionClock = func() time.Time { return sourceDateEpoch }
+	return nil
+}
+
+// NormalizeTimestamps sets the modification time of every file and directory of the bundle
+// to the build time of a reproducible build. Symbolic links are skipped, since changing
+// their time would change the file they point to. Does nothing without -reproducible.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns an error if a modification time cannot be set.
+func NormalizeTimestamps(appPath string) error {
+	if !reproducible {
+		return nil
+	}
+
+	return filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		return tracedChtimes(path, sourceDateEpoch)
+	})
+}
+
+// zipArguments returns the zip arguments archiving the bundle. Without -reproducible, zip
+// adds the bundle recursively (-r) in the order the file system lists it. For reproducible
+// builds the entries are passed explicitly in lexical order, and -X leaves out the extra
+// attributes (owner, access times) that differ between machines.
+//
+// Returns an error if the bundle cannot be listed.
+func zipArguments(zipPath string, appPath string) ([]string, error) {
+	if !reproducible {
+		return []string{"-r", zipPath, appPath}, nil
+	}
+
+	arguments := []string{"-X", zipPath}
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// filepath.WalkDir visits the entries of a directory in lexical order
+		arguments = append(arguments, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", appPath, err)
+	}
+
+	// zip adds to an existing archive, which would keep entries and order of an earlier build
+	if err := tracedRemoveAll(zipPath); err != nil {
+		return nil, err
+	}
+
+	return arguments, nil
+}
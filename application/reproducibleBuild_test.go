@@ -0,0 +1,209 @@
+package application
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setTestReproducible enables -reproducible with the given SOURCE_DATE_EPOCH for the duration
+// of a test.
+func setTestReproducible(t *testing.T, epoch string) {
+	t.Helper()
+	previousReproducible, previousEpoch, previousClock := reproducible, sourceDateEpoch, versionClock
+	t.Cleanup(func() {
+		reproducible, sourceDateEpoch, versionClock = previousReproducible, previousEpoch, previousClock
+	})
+
+	t.Setenv(sourceDateEpochVariable, epoch)
+	if err := SetReproducible(true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestBundle creates a small bundle with the same content on every call.
+func writeTestBundle(t *testing.T, appPath string) {
+	t.Helper()
+	files := map[string]string{
+		"Contents/Info.plist":           "<plist/>",
+		"Contents/MacOS/MyApp":          "#!/bin/sh\n",
+		"Contents/Resources/b.txt":      "b",
+		"Contents/Resources/a.txt":      "a",
+		"Contents/Resources/Base.lproj": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(appPath, filepath.FromSlash(name))
+		if content == "" {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// bundleFingerprint returns the relative path, modification time and content hash of every
+// file and directory of a bundle.
+func bundleFingerprint(t *testing.T, appPath string) []string {
+	t.Helper()
+	var fingerprint []string
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+
+		hash := ""
+		if entry.Type().IsRegular() {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			hash = fmt.Sprintf("%x", sha256.Sum256(content))
+		}
+		fingerprint = append(fingerprint, fmt.Sprintf("%s %d %s", relativePath, info.ModTime().Unix(), hash))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fingerprint
+}
+
+func TestSetReproducible(t *testing.T) {
+	tests := []struct {
+		epoch   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Unix(defaultSourceDateEpoch, 0).UTC(), false},
+		{"1700000000", time.Unix(1700000000, 0).UTC(), false},
+		{"yesterday", time.Time{}, true},
+		{"-1", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("SOURCE_DATE_EPOCH=%q", test.epoch), func(t *testing.T) {
+			previousReproducible, previousEpoch, previousClock := reproducible, sourceDateEpoch, versionClock
+			t.Cleanup(func() {
+				reproducible, sourceDateEpoch, versionClock = previousReproducible, previousEpoch, previousClock
+			})
+			t.Setenv(sourceDateEpochVariable, test.epoch)
+
+			err := SetReproducible(true)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if !sourceDateEpoch.Equal(test.want) || !versionClock().Equal(test.want) {
+				t.Errorf("build time = %v, version clock = %v, want %v", sourceDateEpoch, versionClock(), test.want)
+			}
+		})
+	}
+}
+
+// TestReproducibleBundlesAreIdentical builds the same bundle twice, with different modification
+// times, and compares the modification times and hashes of all files after normalizing them.
+func TestReproducibleBundlesAreIdentical(t *testing.T) {
+	setTestReproducible(t, "1700000000")
+
+	firstPath := filepath.Join(t.TempDir(), "MyApp.app")
+	writeTestBundle(t, firstPath)
+	secondPath := filepath.Join(t.TempDir(), "MyApp.app")
+	writeTestBundle(t, secondPath)
+
+	// The second build happens an hour later
+	later := time.Now().Add(time.Hour)
+	err := filepath.WalkDir(secondPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, later, later)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, appPath := range []string{firstPath, secondPath} {
+		if err := NormalizeTimestamps(appPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, second := bundleFingerprint(t, firstPath), bundleFingerprint(t, secondPath)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("bundles differ:\n%q\n%q", first, second)
+	}
+	info, err := os.Stat(filepath.Join(firstPath, "Contents", "Info.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ModTime().Unix() != 1700000000 {
+		t.Errorf("Info.plist modification time = %v, want SOURCE_DATE_EPOCH", info.ModTime())
+	}
+}
+
+func TestZipArguments(t *testing.T) {
+	directory := t.TempDir()
+	appPath := filepath.Join(directory, "MyApp.app")
+	zipPath := filepath.Join(directory, "MyApp.zip")
+	writeTestBundle(t, appPath)
+
+	t.Run("default", func(t *testing.T) {
+		got, err := zipArguments(zipPath, appPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"-r", zipPath, appPath}; !reflect.DeepEqual(got, want) {
+			t.Errorf("zipArguments() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		setTestReproducible(t, "1700000000")
+		if err := os.WriteFile(zipPath, []byte("earlier build"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := zipArguments(zipPath, appPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		want = append(want, "-X", zipPath)
+		for _, name := range []string{
+			"", "Contents", "Contents/Info.plist", "Contents/MacOS", "Contents/MacOS/MyApp",
+			"Contents/Resources", "Contents/Resources/Base.lproj", "Contents/Resources/a.txt", "Contents/Resources/b.txt",
+		} {
+			want = append(want, filepath.Join(appPath, filepath.FromSlash(name)))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("zipArguments() =\n%q\nwant\n%q", got, want)
+		}
+
+		// zip would add to the archive of the earlier build
+		if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+			t.Errorf("existing archive was not removed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Package application: This file checks that a bundled Java runtime matches the target
+// architecture of the bundle. An x86_64 JRE inside an arm64 bundle (or vice versa) is copied
+// without complaint but the app then fails at launch, so the mismatch is reported up front.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// strictMode turns warnings about likely broken bundles into errors (set via SetStrict)
+var strictMode bool
+
+// SetStrict enables strict mode: checks that normally only warn (e.g. a JRE architecture
+// mismatch) fail the build instead.
+func SetStrict(strict bool) {
+	strictMode = strict
+}
+
+// hostArchitecture returns the Mach-O name of the architecture this program runs on.
+func hostArchitecture() string {
+	if runtime.GOARCH == "amd64" {
+		return "x86_64"
+	}
+
+	return runtime.GOARCH
+}
+
+// parseArchitectures extracts the architecture names from "lipo -archs" output
+// (e.g. "x86_64 arm64" for a universal binary).
+func parseArchitectures(output string) []string {
+	return strings.Fields(output)
+}
+
+// binaryArchitectures returns the architectures contained in a Mach-O binary using lipo.
+func binaryArchitectures(path string) ([]string, error) {
+	lipoPath, err := fileManagement.FindProgramPath("lipo")
+	if err != nil {
+		return nil, err
+	}
+
+	out, stderr, err := runQuery(lipoPath, "-archs", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read architectures of %q: %v\n%s", path, err, stderr)
+	}
+
+	return parseArchitectures(out), nil
+}
+
+// checkArchitectureMatch returns an error describing the mismatch if the target architecture
+// is not part of the binary's architectures.
+func checkArchitectureMatch(binary string, architectures []string, target string) error {
+	for _, architecture := range architectures {
+		if architecture == target {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("java runtime %s is built for %s, but the bundle targets %s",
+		binary, strings.Join(architectures, ", "), target)
+}
+
+// checkRuntimeArchitecture inspects bin/java of the Java installation to bundle and reports
+// if it does not contain the given architecture. The mismatch is logged as a warning, or
+// returned as an error in strict mode.
+//
+// Parameters:
+//   - javaHome: Path to the Java installation that will be copied into the bundle
+//   - target: Architecture the runtime must support (e.g. target_arch, or arm64 for java_home_arm64)
+//
+// Returns an error only in strict mode, if the architecture does not match or cannot be determined.
+func checkRuntimeArchitecture(javaHome string, target string) error {
+	javaBinary := filepath.Join(javaHome, "bin", "java")
+
+	architectures, err := binaryArchitectures(javaBinary)
+	if err == nil && len(architectures) == 0 {
+		err = errors.New("no architectures reported for " + javaBinary)
+	}
+	if err == nil {
+		err = checkArchitectureMatch(javaBinary, architectures, target)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if strictMode {
+		return err
+	}
+
+	logger.Warn("Java runtime architecture check: %v", err)
+	return nil
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setTestLipo installs a fake lipo in front of PATH that reports the given architectures for
+// every binary.
+func setTestLipo(t *testing.T, architectures string) {
+	t.Helper()
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "lipo"), []byte("#!/bin/sh\necho \""+architectures+"\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", directory+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestCheckRuntimeArchitecture checks a Java runtime whose bin/java is reported with a
+// mismatched architecture: the mismatch is a warning, or an error in strict mode.
+func TestCheckRuntimeArchitecture(t *testing.T) {
+	javaHome := t.TempDir()
+	writeBundleFile(t, javaHome, "bin/java", testMachOContent)
+	const mismatch = "is built for x86_64, but the bundle targets arm64"
+
+	tests := []struct {
+		name          string
+		architectures string
+		strict        bool
+		wantWarning   string
+		wantErr       string
+	}{
+		{"matching", "x86_64 arm64", false, "", ""},
+		{"matching in strict mode", "arm64", true, "", ""},
+		{"mismatched", "x86_64", false, mismatch, ""},
+		{"mismatched in strict mode", "x86_64", true, "", mismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestLipo(t, test.architectures)
+			setTestStrictMode(t, test.strict)
+			log := captureTestLog(t)
+
+			err := checkRuntimeArchitecture(javaHome, "arm64")
+			if test.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+			}
+
+			warned := strings.Contains(log.String(), "Java runtime architecture check")
+			if warned != (test.wantWarning != "") || !strings.Contains(log.String(), test.wantWarning) {
+				t.Errorf("log = %q, want warning %q", log.String(), test.wantWarning)
+			}
+		})
+	}
+}
@@ -11,105 +11,344 @@ package application
 import (
 	"appbundler/utilities/fileManagement"
 	"appbundler/utilities/logger"
-	"bytes"
+	"errors"
 	"fmt"
-	"os/exec"
+	"net/url"
+	"os"
 	"regexp"
+	"strings"
 )
 
-// getDefaultSigningIdentity finds the first available code signing certificate in the keychain.
+// Timestamp settings for codesign (set via SetTimestampServer)
+var (
+	timestampURL     string // Custom timestamp authority URL (empty = Apple's default server)
+	timestampDisable bool   // true to sign without a secure timestamp
+)
+
+// SetTimestampServer configures the timestamp authority used when signing.
+// By default codesign requests a timestamp from Apple's server.
+//
+// Parameters:
+//   - serverURL: URL of a custom timestamp authority (empty for Apple's default server)
+//   - disabled: true to sign without a timestamp (not accepted by notarization)
+//
+// Returns an error if both options are set or the URL is not a valid http(s) URL.
+func SetTimestampServer(serverURL string, disabled bool) error {
+	if serverURL != "" && disabled {
+		return errors.New("a timestamp server URL cannot be combined with disabling the timestamp")
+	}
+
+	if serverURL != "" {
+		parsedURL, err := url.Parse(serverURL)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return fmt.Errorf("invalid timestamp server URL %q", serverURL)
+		}
+	}
+
+	timestampURL = serverURL
+	timestampDisable = disabled
+	return nil
+}
+
+// timestampArgument returns the codesign --timestamp argument for the configured server.
+func timestampArgument() string {
+	if timestampDisable {
+		return "--timestamp=none"
+	}
+	if timestampURL != "" {
+		return "--timestamp=" + timestampURL
+	}
+
+	return "--timestamp"
+}
+
+// signingIdentityPattern matches one identity line of "security find-identity" output.
+// Example output line: 1) ABCDEF1234567890ABCDEF1234567890ABCDEF12 "Apple Development: John Doe (ABCD123456)"
+// The regex captures the quoted certificate name up to the last quote of the line, as the
+// name itself may contain quotes.
+var signingIdentityPattern = regexp.MustCompile(`\d+\)\s+[A-F0-9]+\s+"(.+)"`)
+
+// teamIDPattern matches the Team ID at the end of a certificate name,
+// e.g. "Developer ID Application: Example Inc (ABCDE12345)".
+var teamIDPattern = regexp.MustCompile(`\(([A-Z0-9]{10})\)\s*$`)
+
+// parseTeamID extracts the Team ID from a certificate name, or returns "" if it has none.
+func parseTeamID(identity string) string {
+	matches := teamIDPattern.FindStringSubmatch(identity)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// logSigningTeam logs the Team ID of the signing identity together with the bundle identifier,
+// so a bundle signed for the wrong team is noticed before notarization rejects it.
+func logSigningTeam(appPath string, identity string) {
+	bundleIdentifier := "(unknown)"
+	if data, err := ReadPlist(appPath); err == nil && data.BundleIdentifier != "" {
+		bundleIdentifier = data.BundleIdentifier
+	}
+
+	teamID := parseTeamID(identity)
+	if teamID == "" {
+		logger.Warn("Signing identity %q has no Team ID, cannot cross-check it with bundle identifier %s", identity, bundleIdentifier)
+		return
+	}
+
+	logger.Info("Signing bundle identifier %s for Team ID %s", bundleIdentifier, teamID)
+}
+
+// parseSigningIdentities extracts all certificate names from "security find-identity" output,
+// in the order they are listed by the security tool.
+func parseSigningIdentities(output string) []string {
+	var identities []string
+
+	for _, matches := range signingIdentityPattern.FindAllStringSubmatch(output, -1) {
+		identities = append(identities, matches[1])
+	}
+
+	return identities
+}
+
+// ListSigningIdentities returns all valid code signing certificates in the keychain.
 // It uses the macOS "security" command-line tool to query the keychain for valid
 // code signing identities (development certificates).
 //
 // Returns:
-//   - The certificate name (e.g., "Apple Development: John Doe (ABCD123456)")
-//   - An error if no certificate is found or the security tool fails
-func getDefaultSigningIdentity() (string, error) {
+//   - The certificate names (e.g., "Apple Development: John Doe (ABCD123456)")
+//   - An error if the security tool fails
+func ListSigningIdentities() ([]string, error) {
 	// Find the "security" command-line tool (part of macOS)
 	securityPath, err := fileManagement.FindProgramPath("security")
 	if err != nil {
-		logger.Error(err)
-		return "", err
+		return nil, err
 	}
 
 	// Run: security find-identity -p codesigning -v
 	// This lists all code signing certificates in the keychain
-	cmd := exec.Command(securityPath, "find-identity", "-p", "codesigning", "-v")
+	// The output is captured (and only additionally logged in verbose mode)
+	out, _, err := runQuery(securityPath, "find-identity", "-p", "codesigning", "-v")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run security tool: %v", err)
+	}
 
-	// Capture the command output
-	var out bytes.Buffer
-	cmd.Stdout = &out
+	return parseSigningIdentities(out), nil
+}
 
-	// Execute the command
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to run security tool: %v", err)
+// getDefaultSigningIdentity finds the first available code signing certificate in the keychain.
+//
+// Returns:
+//   - The certificate name (e.g., "Apple Development: John Doe (ABCD123456)")
+//   - An error if no certificate is found or the security tool fails
+func getDefaultSigningIdentity() (string, error) {
+	identities, err := ListSigningIdentities()
+	if err != nil {
+		return "", err
 	}
 
-	// Parse the output to extract the certificate name
-	// Example output line:
-	//   1) ABCDEF1234567890ABCDEF1234567890ABCDEF12 "Apple Development: John Doe (ABCD123456)"
-	// The regex captures the quoted certificate name
-	re := regexp.MustCompile(`\d+\)\s+[A-F0-9]+\s+"(.+?)"`)
-	matches := re.FindStringSubmatch(out.String())
-	if len(matches) < 2 {
+	if len(identities) == 0 {
 		return "", fmt.Errorf("no valid code signing identity found in keychain")
 	}
 
 	// Return the first matching certificate name
-	return matches[1], nil
+	return identities[0], nil
+}
+
+// identityEnvironmentVariable is the environment variable CI systems commonly use to pass the
+// signing identity.
+const identityEnvironmentVariable = "CODESIGN_IDENTITY"
+
+// signingIdentity is the identity requested on the command line (set via SetSigningIdentity)
+var signingIdentity string
+
+// SetSigningIdentity sets the code signing identity (certificate name or SHA-1 hash) used
+// instead of the CODESIGN_IDENTITY environment variable and the keychain discovery.
+//
+// Parameters:
+//   - identity: Signing identity, or "" to fall back to CODESIGN_IDENTITY or the keychain
+func SetSigningIdentity(identity string) {
+	signingIdentity = strings.TrimSpace(identity)
+}
+
+// resolveSigningIdentity determines the signing identity in this order:
+//  1. The -identity flag
+//  2. The CODESIGN_IDENTITY environment variable
+//  3. The first valid identity in the keychain
+//
+// Returns the identity and a description of its source, or an error if the keychain
+// discovery fails.
+func resolveSigningIdentity() (string, string, error) {
+	if signingIdentity != "" {
+		return signingIdentity, "-identity flag", nil
+	}
+
+	if identity := strings.TrimSpace(os.Getenv(identityEnvironmentVariable)); identity != "" {
+		return identity, identityEnvironmentVariable + " environment variable", nil
+	}
+
+	identity, err := getDefaultSigningIdentity()
+	return identity, "keychain", err
 }
 
 // SignApplication code signs the entire application bundle using Apple's codesign tool.
 // This function:
 //  1. Finds the codesign tool
-//  2. Automatically discovers a signing certificate from the keychain
-//  3. Signs the bundle (currently commented out - needs implementation)
+//  2. Determines the signing identity (-identity, CODESIGN_IDENTITY or the keychain)
+//  3. Signs nested code components (frameworks, helpers, plug-ins) inside-out, and with
+//     -sign-resources the loose executables in Resources/ and MacOS/
+//  4. Signs the outer bundle and verifies the signature
+//
+// Parameters:
+//   - appPath: Path to the .app bundle to sign (e.g. a bundle built in an earlier pipeline stage)
 //
 // Returns an error if:
 //   - codesign tool is not found
 //   - No signing certificate is available
 //   - Signing process fails
-//
-// Note: The actual signing command is currently commented out and needs to be enabled.
-func SignApplication() error {
+func SignApplication(appPath string) error {
 	// Find the "codesign" command-line tool (part of macOS Xcode Command Line Tools)
 	codeSignPath, err := fileManagement.FindProgramPath("codesign")
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
 	logger.Debug("Program codesign found at: %s", codeSignPath)
 
-	// Automatically find a code signing certificate in the keychain
-	identity, err := getDefaultSigningIdentity()
+	// Only one appbundler process signs at a time (keychain access)
+	release, err := acquireSigningLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Use the requested identity, or find a code signing certificate in the keychain
+	identity, source, err := resolveSigningIdentity()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Signing with identity %s (from %s)", identity, source)
+	logSigningTeam(appPath, identity)
+
+	// Sign the nested components first (leaves first), so that every component is
+	// signed before the component containing it
+	components, err := findNestedComponents(appPath)
+	if err != nil {
+		return err
+	}
+
+	// The Sparkle preset adds the framework's helper executables and their signing options
+	if sparkleSigning {
+		components = addSparkleComponents(components)
+	}
+
+	// Loose executables in Resources/ and MacOS/ get their own signature (-sign-resources)
+	if signResources {
+		executables, err := findLooseExecutables(appPath)
+		if err != nil {
+			return err
+		}
+		components = append(components, executables...)
+	} else {
+		// The binary behind an argument launcher always needs its own signature
+		// (with -sign-resources, it is one of the loose executables)
+		components = append(components, launchedBinaryComponents(appPath)...)
+	}
+
+	err = signNestedComponents(components, func(componentPath string) error {
+		options := codesignOptions{}
+		if sparkleSigning {
+			options = sparkleComponentOptions(componentPath)
+		}
+		return signPath(codeSignPath, identity, componentPath, options)
+	})
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
-	logger.Debug("Identity used: %s", identity)
+	// The outer bundle is signed last. If nested components were signed individually,
+	// --deep must not be used, as it would re-sign them without their own settings.
+	outerOptions := codesignOptions{deep: len(components) == 0}
+
+	// A custom designated requirement applies to the bundle itself (e.g. pinning a Team ID)
+	outerOptions.requirements = requirementArgument(GetSigningRequirement())
 
-	// The codesign command signs the entire bundle recursively:
-	//   --sign: Sign with the specified identity
-	//   --deep: Sign nested code (frameworks, helpers, etc.)
-	//   --force: Replace existing signature
-	//   --options runtime: Enable hardened runtime (required for notarization)
-	//   --timestamp: Request timestamp from Apple (required for notarization)
-	cmd := exec.Command(codeSignPath, "--sign", identity, "--deep", "--force", "--options", "runtime", "--timestamp", applicationDirectory)
+	// Entitlements presets apply to the bundle's main executable
+	if len(presetEntitlements) > 0 {
+		entitlements, err := writePresetEntitlements()
+		if err != nil {
+			return err
+		}
+		defer os.Remove(entitlements)
+		outerOptions.entitlements = entitlements
+		logger.Info("Signing with entitlements: %s", strings.Join(presetEntitlements, ", "))
+	}
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	// Report a signature that is about to be replaced by --force
+	logExistingSignature(codeSignPath, appPath)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to sign %q: %v\n%s", applicationDirectory, err, stderr.String())
+	err = signPath(codeSignPath, identity, appPath, outerOptions)
+	if err != nil {
+		return err
 	}
 
 	// Verify the signature after signing
-	err = VerifyApplicationSignature(applicationDirectory)
+	err = VerifyApplicationSignature(appPath)
 	return err
 }
 
+// codesignOptions holds the per-path settings for a codesign invocation.
+type codesignOptions struct {
+	deep                 bool   // Sign nested code recursively (only used when no nested components were signed)
+	preserveEntitlements bool   // Keep the entitlements of the existing signature (Sparkle Downloader.xpc)
+	requirements         string // Value of --requirements (see requirementArgument), empty for the default
+	entitlements         string // Path to an entitlements plist (optional)
+}
+
+// codesignArguments builds the argument list for signing a single path:
+//
+//	--sign: Sign with the specified identity
+//	--deep: Sign nested code (frameworks, helpers, etc.), only if requested
+//	--force: Replace existing signature
+//	--options runtime: Enable hardened runtime (required for notarization)
+//	--timestamp: Request timestamp from Apple or a custom server (required for notarization)
+//	--preserve-metadata=entitlements: Keep the existing entitlements, only if requested
+//	--requirements: Custom designated requirement, only if requested
+//	--entitlements: Entitlements plist (-entitlements-preset), only if requested
+//	-codesign-extra arguments: Passed through verbatim after the managed arguments
+func codesignArguments(identity string, path string, options codesignOptions) []string {
+	arguments := []string{"--sign", identity}
+	if options.deep {
+		arguments = append(arguments, "--deep")
+	}
+	arguments = append(arguments, "--force", "--options", "runtime", timestampArgument())
+	if options.preserveEntitlements {
+		arguments = append(arguments, "--preserve-metadata=entitlements")
+	}
+	if options.requirements != "" {
+		arguments = append(arguments, "--requirements", options.requirements)
+	}
+	if options.entitlements != "" {
+		arguments = append(arguments, "--entitlements", options.entitlements)
+	}
+	arguments = append(arguments, codesignExtraArguments...)
+	arguments = append(arguments, path)
+
+	return arguments
+}
+
+// signPath runs codesign for a single path (the bundle or one nested component).
+func signPath(codeSignPath string, identity string, path string, options codesignOptions) error {
+	logger.Debug("Signing %s", path)
+
+	_, stderr, err := runCommand(codeSignPath, codesignArguments(identity, path, options)...)
+	if err != nil {
+		return fmt.Errorf("failed to sign %q: %v\n%s", path, err, stderr)
+	}
+
+	return nil
+}
+
 // VerifyApplicationSignature verifies that an application bundle is properly code signed.
 // This is useful for testing and ensuring the signing process completed successfully.
 //
@@ -122,7 +361,6 @@ func SignApplication() error {
 func VerifyApplicationSignature(appPath string) error {
 	codeSignPath, err := fileManagement.FindProgramPath("codesign")
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
@@ -131,13 +369,9 @@ func VerifyApplicationSignature(appPath string) error {
 	//   --deep: Verify nested code recursively
 	//   --strict: Use strict verification (fails on warnings)
 	//   --verbose=2: Show detailed verification information
-	cmd := exec.Command(codeSignPath, "--verify", "--deep", "--strict", "--verbose=2", appPath)
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("signature verification failed for %q: %v\n%s", appPath, err, stderr.String())
+	_, stderr, err := runCommand(codeSignPath, "--verify", "--deep", "--strict", "--verbose=2", appPath)
+	if err != nil {
+		return fmt.Errorf("signature verification failed for %q: %v\n%s", appPath, err, stderr)
 	}
 	return nil
 }
@@ -153,6 +387,7 @@ func VerifyApplicationSignature(appPath string) error {
 // Returns an error if:
 //   - Required tools (zip, xcrun) are not found
 //   - Zipping the app fails
+//   - The notarization preflight checks find issues (see NotarizePreflight)
 //   - Notarization submission fails
 //
 // Note: The app must be code signed before notarization.
@@ -164,37 +399,70 @@ func NotarizeApplication(applicationRoot string, appleIDProfile string) error {
 	// Find the zip command-line tool
 	zipPath, err := fileManagement.FindProgramPath("zip")
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
 	// Create a zip file containing the entire .app bundle
-	// -r flag means recursive (include all files and subdirectories)
-	if err = exec.Command(zipPath, "-r", zipApplication, applicationRoot).Run(); err != nil {
+	// -r flag means recursive (include all files and subdirectories); reproducible builds
+	// list the files in a fixed order with fixed modification times instead
+	if err = NormalizeTimestamps(applicationRoot + ".app"); err != nil {
+		return err
+	}
+	arguments, err := zipArguments(zipApplication, applicationRoot+".app")
+	if err != nil {
+		return err
+	}
+	if _, _, err = runCommand(zipPath, arguments...); err != nil {
 		return fmt.Errorf("failed to zip app for notarization: %v", err)
 	}
 
+	// Catch common notarization blockers locally before waiting for Apple's service
+	if skipNotarizePreflight {
+		logger.Warn("Skipping the notarization preflight checks")
+	} else if printingCommands() {
+		logger.Info("Skipping the notarization preflight checks, the bundle was not signed (-print-commands)")
+	} else if err = NotarizePreflight(applicationRoot + ".app"); err != nil {
+		return err
+	}
+
+	return submitForNotarization(zipApplication, appleIDProfile)
+}
+
+// submitForNotarization submits a zip, disk image or package to Apple's notary service and
+// waits for the result.
+//
+// Parameters:
+//   - path: Path of the file to submit
+//   - appleIDProfile: Keychain profile name containing Apple ID credentials
+//
+// Returns an error if xcrun is not found, the notarization fails or the submission is not
+// accepted (see checkNotarizationStatus).
+func submitForNotarization(path string, appleIDProfile string) error {
 	// Find xcrun (Xcode command-line tool runner)
 	xcrunPath, err := fileManagement.FindProgramPath("xcrun")
 	if err != nil {
-		logger.Error(err)
 		return err
 	}
 
-	// Submit the zip file to Apple for notarization
+	// Submit the file to Apple for notarization
 	// --keychain-profile: Use stored Apple ID credentials from keychain
 	// --wait: Wait for notarization to complete (can take several minutes)
-	cmd := exec.Command(xcrunPath, "notarytool", "submit", zipApplication,
-		"--keychain-profile", appleIDProfile, "--wait")
-
-	var out, stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+	// The submission waits for Apple's service, so it has its own (longer) timeout and
+	// reports the elapsed time while it runs
+	stopProgress := logProgress("notarization", notarizeProgressInterval)
+	// --output-format json: Machine-readable result with the submission ID and status
+	out, stderr, err := runCommandWithTimeout(notarizeTimeout, xcrunPath, "notarytool", "submit", path,
+		"--keychain-profile", appleIDProfile, "--wait", "--output-format", "json")
+	stopProgress()
+	if err != nil {
+		return fmt.Errorf("notarization failed: %v\n%s", err, stderr)
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("notarization failed: %v\n%s", err, stderr.String())
+	logger.Debug("Notarization output:\n%s\n", out)
+	if printingCommands() {
+		return nil
 	}
 
-	logger.Debug("Notarization output:\n%s\n", out.String())
-	return nil
+	// notarytool also succeeds for rejected submissions, only Accepted can be stapled
+	return checkNotarizationStatus(xcrunPath, out, appleIDProfile)
 }
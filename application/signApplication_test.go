@@ -0,0 +1,256 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeTool is a stand-in for an external tool (codesign, xattr, ...) that records its
+// arguments in the file "calls" next to it and succeeds.
+const fakeTool = `#!/bin/sh
+echo "$*" >> "$(dirname "$0")/calls"
+`
+
+// setTestTool installs fakeTool under the given name in front of PATH for the duration of a
+// test. Returns the path of the file recording the calls.
+func setTestTool(t *testing.T, name string) string {
+	t.Helper()
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, name), []byte(fakeTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", directory+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return filepath.Join(directory, "calls")
+}
+
+// setTestCodesign installs a fake codesign and signs with the ad-hoc identity for the duration
+// of a test. Returns the path of the file recording the calls.
+func setTestCodesign(t *testing.T) string {
+	t.Helper()
+	callsFile := setTestTool(t, "codesign")
+
+	previousIdentity := signingIdentity
+	signingIdentity = "-"
+	t.Cleanup(func() { signingIdentity = previousIdentity })
+	return callsFile
+}
+
+// readTestCalls returns the calls recorded by a fake tool, one per line.
+func readTestCalls(t *testing.T, callsFile string) []string {
+	t.Helper()
+	content, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// writeTestApp creates a bundle with an Info.plist for the executable MyApp and the given
+// files (slash-separated paths below the bundle, directories end with a slash).
+func writeTestApp(t *testing.T, files ...string) string {
+	t.Helper()
+	appPath := filepath.Join(t.TempDir(), "MyApp.app")
+	content, err := RenderPlist(testPlistData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeBundleFile(t, appPath, "Contents/Info.plist", content)
+
+	for _, name := range files {
+		path := filepath.Join(appPath, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		writeBundleFile(t, appPath, name, "#!/bin/sh\n")
+		if err := os.Chmod(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return appPath
+}
+
+func TestParseSigningIdentities(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "no identities",
+			output: "     0 valid identities found\n",
+		},
+		{
+			name: "several identities",
+			output: `  1) ABCDEF1234567890ABCDEF1234567890ABCDEF12 "Apple Development: John Doe (ABCD123456)"
+  2) 1234567890ABCDEF1234567890ABCDEF12345678 "Developer ID Application: Example Inc (ABCDE12345)"
+     2 valid identities found
+`,
+			want: []string{"Apple Development: John Doe (ABCD123456)", "Developer ID Application: Example Inc (ABCDE12345)"},
+		},
+		{
+			name:   "quotes in the name",
+			output: `  1) ABCDEF1234567890ABCDEF1234567890ABCDEF12 "Developer ID Application: "Quoted" Inc (ABCDE12345)"` + "\n",
+			want:   []string{`Developer ID Application: "Quoted" Inc (ABCDE12345)`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseSigningIdentities(test.output); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseSigningIdentities() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTeamID(t *testing.T) {
+	tests := []struct {
+		identity string
+		want     string
+	}{
+		{"Developer ID Application: Example Inc (ABCDE12345)", "ABCDE12345"},
+		{"Apple Development: John Doe (ABCD123456)", "ABCD123456"},
+		{"Developer ID Application: Example (Europe) Inc (ABCDE12345) ", "ABCDE12345"},
+		{"Apple Development: John Doe (abcde12345)", ""},
+		{"Apple Development: John Doe (ABCD12345)", ""},
+		{"-", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := parseTeamID(test.identity); got != test.want {
+			t.Errorf("parseTeamID(%q) = %q, want %q", test.identity, got, test.want)
+		}
+	}
+}
+
+// setTestTimestampServer restores the timestamp settings after a test.
+func setTestTimestampServer(t *testing.T) {
+	t.Helper()
+	previousURL, previousDisable := timestampURL, timestampDisable
+	t.Cleanup(func() { timestampURL, timestampDisable = previousURL, previousDisable })
+}
+
+func TestTimestampServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		disabled bool
+		want     string
+		wantErr  string
+	}{
+		{name: "default", want: "--timestamp"},
+		{name: "custom server", url: "https://timestamp.example.com/tsa", want: "--timestamp=https://timestamp.example.com/tsa"},
+		{name: "http server", url: "http://timestamp.example.com", want: "--timestamp=http://timestamp.example.com"},
+		{name: "disabled", disabled: true, want: "--timestamp=none"},
+		{name: "URL and disabled", url: "https://timestamp.example.com", disabled: true, wantErr: "cannot be combined"},
+		{name: "no scheme", url: "timestamp.example.com", wantErr: "invalid timestamp server URL"},
+		{name: "other scheme", url: "ftp://timestamp.example.com", wantErr: "invalid timestamp server URL"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestTimestampServer(t)
+
+			err := SetTimestampServer(test.url, test.disabled)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			arguments := codesignArguments("-", "MyApp.app", codesignOptions{})
+			if !slices.Contains(arguments, test.want) {
+				t.Errorf("codesign arguments %q do not contain %s", arguments, test.want)
+			}
+		})
+	}
+}
+
+// TestSignApplicationPath signs a bundle passed by path while another bundle is being built:
+// only the given bundle is signed and verified.
+func TestSignApplicationPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		building bool
+	}{
+		{"no bundle being built", false},
+		{"other bundle being built", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callsFile := setTestCodesign(t)
+			if test.building {
+				setTestBundle(t, packageParameter{})
+			}
+			appPath := writeTestApp(t, "Contents/MacOS/MyApp")
+
+			if err := SignApplication(appPath); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := readTestCalls(t, callsFile)
+			for _, call := range calls {
+				if !strings.HasSuffix(call, " "+appPath) {
+					t.Errorf("codesign called for another path: %s", call)
+				}
+			}
+			if !slices.ContainsFunc(calls, func(call string) bool { return strings.HasPrefix(call, "--sign") }) ||
+				!slices.ContainsFunc(calls, func(call string) bool { return strings.HasPrefix(call, "--verify") }) {
+				t.Errorf("bundle not signed and verified:\n%s", strings.Join(calls, "\n"))
+			}
+		})
+	}
+}
+
+// TestResolveSigningIdentity checks the precedence of the identity sources: the -identity
+// flag, then CODESIGN_IDENTITY, then the first identity of a fake security tool.
+func TestResolveSigningIdentity(t *testing.T) {
+	toolDirectory := t.TempDir()
+	security := "#!/bin/sh\necho '  1) ABCDEF1234567890ABCDEF1234567890ABCDEF12 \"Developer ID Application: Keychain Inc (ABCDE12345)\"'\n"
+	if err := os.WriteFile(filepath.Join(toolDirectory, "security"), []byte(security), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name        string
+		flag        string
+		environment string
+		want        string
+		wantSource  string
+	}{
+		{"keychain", "", "", "Developer ID Application: Keychain Inc (ABCDE12345)", "keychain"},
+		{"environment variable", "", "Developer ID Application: CI Inc (ABCDE12345)", "Developer ID Application: CI Inc (ABCDE12345)", "CODESIGN_IDENTITY environment variable"},
+		{"flag overrides environment variable", "Apple Development: Local (ABCD123456)", "Developer ID Application: CI Inc (ABCDE12345)", "Apple Development: Local (ABCD123456)", "-identity flag"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previousIdentity := signingIdentity
+			t.Cleanup(func() { signingIdentity = previousIdentity })
+			SetSigningIdentity(test.flag)
+			t.Setenv(identityEnvironmentVariable, test.environment)
+
+			identity, source, err := resolveSigningIdentity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity != test.want || source != test.wantSource {
+				t.Errorf("resolveSigningIdentity() = %q from %s, want %q from %s", identity, source, test.want, test.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package application: This file finds loose executables in a bundle for signing.
+// Helper scripts and command-line tools placed in Contents/Resources/ or next to the main
+// executable in Contents/MacOS/ are not nested components (frameworks, helpers, ...), so they
+// are only sealed as resources. Gatekeeper and the notary service expect such code to carry
+// its own signature ("code has no resources but signature indicates they must be present").
+package application
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// signResources enables signing of loose executables (set via SetSignResources)
+var signResources bool
+
+// SetSignResources enables or disables signing the executable files in Contents/Resources/
+// and Contents/MacOS/ (except the main executable) before the bundle is signed.
+func SetSignResources(enabled bool) {
+	signResources = enabled
+}
+
+// isLooseExecutable reports whether a file is code that needs its own signature:
+// a Mach-O binary or a file with an executable permission bit (scripts, tools).
+func isLooseExecutable(path string, info fs.FileInfo) bool {
+	if info.Mode().Perm()&0111 != 0 {
+		return true
+	}
+
+	fileType, err := detectExecutableType(path)
+	return err == nil && fileType == executableTypeMachO
+}
+
+// mainExecutableName returns the name of the bundle's main executable (CFBundleExecutable),
+// read from Info.plist so that prebuilt bundles (-sign-only) are handled as well.
+func mainExecutableName(appPath string) string {
+	if data, err := ReadPlist(appPath); err == nil && data.ExecutableName != "" {
+		return data.ExecutableName
+	}
+
+	return GetBundleExecutable()
+}
+
+// findLooseExecutables returns the executable files in Contents/Resources/ and Contents/MacOS/
+// of a bundle, except the main executable (signed with the bundle). Nested code components and
+// the files inside them are skipped, as they are signed as components. Symbolic links are not followed.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the files as components directly inside the app, or an error if a directory cannot be read.
+func findLooseExecutables(appPath string) ([]nestedComponent, error) {
+	var executables []nestedComponent
+
+	mainExecutable := filepath.Join(appPath, "Contents", "MacOS", mainExecutableName(appPath))
+
+	for _, directory := range []string{"Resources", "MacOS"} {
+		root := filepath.Join(appPath, "Contents", directory)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Nested components (including .dylib files) are found by findNestedComponents
+			if path != root && nestedCodeExtensions[strings.ToLower(filepath.Ext(path))] {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() || path == mainExecutable {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if isLooseExecutable(path, info) {
+				executables = append(executables, nestedComponent{path: path, depth: 1})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return executables, nil
+}
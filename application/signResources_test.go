@@ -0,0 +1,47 @@
+package application
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSignResources signs a bundle with an extra executable resource: with -sign-resources the
+// resource is signed before the bundle, the main executable and data files are not signed on their own.
+func TestSignResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    []string
+	}{
+		{"disabled", false, []string{"MyApp.app"}},
+		{"enabled", true, []string{"MyApp.app/Contents/Resources/tools/helper", "MyApp.app"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previousSignResources := signResources
+			t.Cleanup(func() { signResources = previousSignResources })
+			SetSignResources(test.enabled)
+
+			callsFile := setTestCodesign(t)
+			appPath := writeTestApp(t, "Contents/MacOS/MyApp", "Contents/Resources/tools/helper")
+			writeBundleFile(t, appPath, "Contents/Resources/data.txt", "data")
+
+			if err := SignApplication(appPath); err != nil {
+				t.Fatal(err)
+			}
+
+			var signed []string
+			for _, call := range readTestCalls(t, callsFile) {
+				if strings.HasPrefix(call, "--sign") {
+					path := call[strings.LastIndex(call, " ")+1:]
+					signed = append(signed, strings.TrimPrefix(path, strings.TrimSuffix(appPath, "MyApp.app")))
+				}
+			}
+			if !slices.Equal(signed, test.want) {
+				t.Errorf("signed %q, want %q", signed, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package application: This file serializes code signing between concurrent appbundler
+// processes (e.g. bundles built with -jobs). Parallel codesign runs accessing the same
+// keychain can fail or trigger several keychain prompts at once, so only one process
+// signs at a time. The lock is an advisory file lock that is released automatically
+// when the process exits.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// signingLockFile is the lock file shared by all appbundler processes of the user
+var signingLockFile = filepath.Join(os.TempDir(), "appbundler-signing.lock")
+
+// acquireSigningLock blocks until no other appbundler process is signing.
+//
+// Returns a function releasing the lock, or an error if the lock file cannot be opened or locked.
+func acquireSigningLock() (func(), error) {
+	file, err := os.OpenFile(signingLockFile, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Waiting for the signing lock: %s", signingLockFile)
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,37 @@
+// Package application: This file handles custom designated requirements of the signature.
+// codesign derives a designated requirement from the signing certificate by default. Some
+// workflows need a stricter one, e.g. pinning the app to a Team ID:
+//
+//	designated => anchor apple generic and certificate leaf[subject.OU] = "ABCD123456"
+//
+// The requirement is configured as text or as a compiled .csreq file (signing_requirement).
+package application
+
+import (
+	"strings"
+)
+
+// requirementFileSuffix is the extension of compiled code requirement files.
+const requirementFileSuffix = ".csreq"
+
+// isRequirementFile reports whether a signing_requirement value refers to a .csreq file
+// rather than containing the requirement text.
+func isRequirementFile(requirement string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSpace(requirement)), requirementFileSuffix)
+}
+
+// requirementArgument returns the value passed to codesign --requirements. codesign reads a
+// requirement file by path and inline requirement text prefixed with "=".
+//
+// Parameters:
+//   - requirement: Requirement text or path of a .csreq file (see GetSigningRequirement)
+//
+// Returns "" if no requirement is configured.
+func requirementArgument(requirement string) string {
+	requirement = strings.TrimSpace(requirement)
+	if requirement == "" || isRequirementFile(requirement) || strings.HasPrefix(requirement, "=") {
+		return requirement
+	}
+
+	return "=" + requirement
+}
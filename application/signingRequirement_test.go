@@ -0,0 +1,52 @@
+package application
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSigningRequirement signs a bundle with a fake codesign: the configured requirement is
+// forwarded to the signature of the bundle with --requirements.
+func TestSigningRequirement(t *testing.T) {
+	const teamRequirement = `designated => anchor apple generic and certificate leaf[subject.OU] = "ABCDE12345"`
+	directory := t.TempDir()
+	writeBundleFile(t, directory, "team.csreq", "compiled requirement")
+	requirementFile := filepath.Join(directory, "team.csreq")
+
+	tests := []struct {
+		name        string
+		requirement string
+		want        string
+	}{
+		{"default", "", ""},
+		{"requirement text", teamRequirement, "--requirements =" + teamRequirement},
+		{"prefixed requirement text", "=" + teamRequirement, "--requirements =" + teamRequirement},
+		{"requirement file", requirementFile, "--requirements " + requirementFile},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callsFile := setTestCodesign(t)
+			setTestPackageInfo(t, packageParameter{SigningRequirement: test.requirement})
+			appPath := writeTestApp(t, "Contents/MacOS/MyApp")
+
+			if err := SignApplication(appPath); err != nil {
+				t.Fatal(err)
+			}
+
+			var signCall string
+			for _, call := range readTestCalls(t, callsFile) {
+				if strings.HasPrefix(call, "--sign") && strings.HasSuffix(call, " "+appPath) {
+					signCall = call
+				}
+			}
+			if test.want == "" && strings.Contains(signCall, "--requirements") {
+				t.Errorf("codesign call %q has a requirement, want the default", signCall)
+			}
+			if !strings.Contains(signCall, test.want) {
+				t.Errorf("codesign call %q does not contain %q", signCall, test.want)
+			}
+		})
+	}
+}
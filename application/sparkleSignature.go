@@ -0,0 +1,73 @@
+// Package application: This file creates the EdDSA (ed25519) signatures used by Sparkle 2
+// to verify downloaded updates. The signature is published in the sparkle:edSignature
+// attribute of the appcast enclosure for the update archive.
+package application
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseSparklePrivateKey decodes a private key in the format exported by Sparkle's
+// generate_keys tool ("generate_keys -x"): a base64 encoded ed25519 key, either the 32 byte
+// seed or the 64 byte private key of crypto/ed25519 (seed followed by the public key).
+//
+// Keys of old Sparkle versions (96 bytes) start with the expanded and clamped SHA-512 scalar
+// instead of the seed. crypto/ed25519 cannot sign with it, and a key derived from its first
+// bytes would produce signatures that no client accepts, so these keys are rejected.
+func parseSparklePrivateKey(encodedKey string) (ed25519.PrivateKey, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sparkle private key: %v", err)
+	}
+
+	switch len(keyBytes) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(keyBytes), nil
+	case ed25519.PrivateKeySize:
+		// The public key derived from the seed must match the one stored in the key
+		privateKey := ed25519.NewKeyFromSeed(keyBytes[:ed25519.SeedSize])
+		if !bytes.Equal(privateKey.Public().(ed25519.PublicKey), keyBytes[ed25519.SeedSize:]) {
+			return nil, fmt.Errorf("invalid Sparkle private key: the public key does not belong to the private key")
+		}
+		return privateKey, nil
+	case ed25519.PrivateKeySize + ed25519.PublicKeySize:
+		return nil, fmt.Errorf("unsupported Sparkle private key: the legacy 96 byte format contains no seed; export the key again with the generate_keys of Sparkle 2")
+	default:
+		return nil, fmt.Errorf("invalid Sparkle private key: unexpected key length of %d bytes", len(keyBytes))
+	}
+}
+
+// SignUpdate computes the EdDSA signature of an update archive (zip or dmg) for Sparkle 2.
+//
+// Parameters:
+//   - archivePath: Path to the update archive to sign
+//   - privateKeyPath: Path to the private key file exported with "generate_keys -x"
+//
+// Returns the base64 encoded signature for the sparkle:edSignature attribute, or an error if:
+//   - The key file or the archive cannot be read
+//   - The key file does not contain a valid ed25519 key
+func SignUpdate(archivePath string, privateKeyPath string) (string, error) {
+	encodedKey, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Sparkle private key: %v", err)
+	}
+
+	privateKey, err := parseSparklePrivateKey(string(encodedKey))
+	if err != nil {
+		return "", err
+	}
+
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read update archive: %v", err)
+	}
+
+	// Sparkle signs the complete archive contents (ed25519 hashes the message internally)
+	signature := ed25519.Sign(privateKey, archive)
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
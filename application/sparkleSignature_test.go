@@ -0,0 +1,89 @@
+package application
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test vector 1 of RFC 8032 (section 7.1): key pair and the signature of an empty message
+const (
+	testSparkleSeed      = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	testSparklePublicKey = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	testSparkleSignature = "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b"
+)
+
+// encodeTestKey returns the base64 encoding of the concatenated hex strings.
+func encodeTestKey(t *testing.T, parts ...string) string {
+	t.Helper()
+	keyBytes, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(keyBytes)
+}
+
+func TestParseSparklePrivateKey(t *testing.T) {
+	otherPublicKey := strings.Repeat("00", 32)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{"seed", encodeTestKey(t, testSparkleSeed), ""},
+		{"seed with newline", encodeTestKey(t, testSparkleSeed) + "\n", ""},
+		{"seed and public key", encodeTestKey(t, testSparkleSeed, testSparklePublicKey), ""},
+		{"seed and foreign public key", encodeTestKey(t, testSparkleSeed, otherPublicKey), "does not belong"},
+		{"legacy format", encodeTestKey(t, testSparkleSeed, testSparklePublicKey, testSparklePublicKey), "legacy 96 byte format"},
+		{"wrong length", encodeTestKey(t, testSparklePublicKey[:40]), "unexpected key length of 20 bytes"},
+		{"not base64", "not a key!", "invalid Sparkle private key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			privateKey, err := parseSparklePrivateKey(test.key)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(privateKey.Public().(ed25519.PublicKey)); got != testSparklePublicKey {
+				t.Errorf("public key = %s, want %s", got, testSparklePublicKey)
+			}
+		})
+	}
+}
+
+func TestSignUpdate(t *testing.T) {
+	directory := t.TempDir()
+	keyPath := filepath.Join(directory, "sparkle_private_key")
+	archivePath := filepath.Join(directory, "MyApp.zip")
+
+	if err := os.WriteFile(keyPath, []byte(encodeTestKey(t, testSparkleSeed)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(archivePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := SignUpdate(archivePath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := encodeTestKey(t, testSparkleSignature); signature != want {
+		t.Errorf("signature = %s, want %s", signature, want)
+	}
+
+	if _, err := SignUpdate(filepath.Join(directory, "missing.zip"), keyPath); err == nil {
+		t.Error("expected an error for a missing archive")
+	}
+}
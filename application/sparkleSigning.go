@@ -0,0 +1,75 @@
+// Package application: This file contains the signing preset for apps updated with Sparkle.
+// Sparkle.framework contains helper programs (Autoupdate, Updater.app and XPC services) that
+// must each be signed before the framework, and the framework before the app. The plain
+// Autoupdate executable has no bundle extension, so it is not found by the generic nested
+// component search and is added by this preset. Downloader.xpc needs its entitlements kept.
+//
+// Resulting order (Sparkle 2): XPC services, Autoupdate, Updater.app -> Sparkle.framework -> app
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sparkleSigning enables the Sparkle signing preset (set via SetSparkleSigning)
+var sparkleSigning bool
+
+// SetSparkleSigning enables the signing preset for Sparkle auto-updating apps.
+func SetSparkleSigning(enabled bool) {
+	sparkleSigning = enabled
+}
+
+// sparkleFramework is the directory name of the Sparkle framework
+const sparkleFramework = "Sparkle.framework"
+
+// addSparkleComponents adds the helper executables of every Sparkle.framework found in the
+// components (Versions/<version>/Autoupdate) with a depth below the framework, so they are
+// signed before it. Symbolic links (e.g. Versions/Current) are skipped.
+func addSparkleComponents(components []nestedComponent) []nestedComponent {
+	result := components
+	frameworkFound := false
+
+	for _, component := range components {
+		if filepath.Base(component.path) != sparkleFramework {
+			continue
+		}
+		frameworkFound = true
+
+		helpers, _ := filepath.Glob(filepath.Join(component.path, "Versions", "*", "Autoupdate"))
+		for _, helper := range helpers {
+			info, err := os.Lstat(helper)
+			if err != nil || !info.Mode().IsRegular() || isBelowSymlink(component.path, helper) {
+				continue
+			}
+			result = append(result, nestedComponent{path: helper, depth: component.depth + 1})
+		}
+	}
+
+	if !frameworkFound {
+		logger.Warn("Sparkle signing preset enabled, but no %s found in the bundle", sparkleFramework)
+	}
+
+	return result
+}
+
+// isBelowSymlink reports whether any directory between root and path is a symbolic link.
+func isBelowSymlink(root string, path string) bool {
+	for directory := filepath.Dir(path); directory != root && strings.HasPrefix(directory, root); directory = filepath.Dir(directory) {
+		if info, err := os.Lstat(directory); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sparkleComponentOptions returns the codesign options for a nested component under the
+// Sparkle preset. Downloader.xpc is signed with its entitlements preserved, as Sparkle
+// requires its network client entitlement.
+func sparkleComponentOptions(path string) codesignOptions {
+	return codesignOptions{preserveEntitlements: filepath.Base(path) == "Downloader.xpc"}
+}
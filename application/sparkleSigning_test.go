@@ -0,0 +1,70 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSparkleSigning signs a bundle with the Sparkle 2 framework layout using the Sparkle
+// preset: every component must be signed exactly once, before the component containing it.
+func TestSparkleSigning(t *testing.T) {
+	previousSparkleSigning := sparkleSigning
+	t.Cleanup(func() { sparkleSigning = previousSparkleSigning })
+	SetSparkleSigning(true)
+
+	callsFile := setTestCodesign(t)
+	const version = "Contents/Frameworks/Sparkle.framework/Versions/B/"
+	appPath := writeTestApp(t, "Contents/MacOS/MyApp",
+		version+"Autoupdate", version+"Updater.app/", version+"XPCServices/Downloader.xpc/", version+"XPCServices/Installer.xpc/")
+	if err := os.Symlink("B", filepath.Join(appPath, "Contents", "Frameworks", "Sparkle.framework", "Versions", "Current")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignApplication(appPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// signed maps the signed paths (relative to the bundle) to the index of their codesign call
+	signed := map[string]int{}
+	calls := readTestCalls(t, callsFile)
+	for index, call := range calls {
+		if !strings.HasPrefix(call, "--sign") {
+			continue
+		}
+		path := call[strings.LastIndex(call, " "+appPath)+1:]
+		relative, err := filepath.Rel(appPath, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		relative = filepath.ToSlash(relative)
+		if _, ok := signed[relative]; ok {
+			t.Errorf("%s signed twice", relative)
+		}
+		signed[relative] = index
+
+		preserved := strings.Contains(call, "--preserve-metadata=entitlements")
+		if wantPreserved := filepath.Base(relative) == "Downloader.xpc"; preserved != wantPreserved {
+			t.Errorf("%s signed with preserved entitlements: %v, want %v", relative, preserved, wantPreserved)
+		}
+	}
+
+	order := []struct {
+		component string
+		parent    string
+	}{
+		{version + "Autoupdate", "Contents/Frameworks/Sparkle.framework"},
+		{version + "Updater.app", "Contents/Frameworks/Sparkle.framework"},
+		{version + "XPCServices/Downloader.xpc", "Contents/Frameworks/Sparkle.framework"},
+		{version + "XPCServices/Installer.xpc", "Contents/Frameworks/Sparkle.framework"},
+		{"Contents/Frameworks/Sparkle.framework", "."},
+	}
+	for _, test := range order {
+		component, componentSigned := signed[test.component]
+		parent, parentSigned := signed[test.parent]
+		if !componentSigned || !parentSigned || component > parent {
+			t.Errorf("%s not signed before %s:\n%s", test.component, test.parent, strings.Join(calls, "\n"))
+		}
+	}
+}
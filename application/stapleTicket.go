@@ -0,0 +1,78 @@
+// Package application: This file staples the notarization ticket to a bundle and exports it.
+// Stapling attaches the ticket issued by Apple's notary service to the bundle, so Gatekeeper
+// can verify the notarization offline. For bundles, stapler stores the ticket in
+// Contents/CodeResources; exporting that file lets teams that re-wrap the application
+// (e.g. into another container) staple the same ticket again without a new submission.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stapledTicketPath returns the file in which stapler stores the ticket of a bundle.
+func stapledTicketPath(appPath string) string {
+	return filepath.Join(appPath, "Contents", "CodeResources")
+}
+
+// StapleApplication attaches the notarization ticket to a notarized bundle by running:
+// xcrun stapler staple <appPath>
+//
+// Parameters:
+//   - appPath: Path to the notarized .app bundle
+//
+// Returns an error if xcrun is not found or stapling fails (e.g. the bundle is not notarized).
+func StapleApplication(appPath string) error {
+	return staple(appPath)
+}
+
+// staple runs "xcrun stapler staple" for a notarized bundle, disk image or package.
+func staple(path string) error {
+	xcrunPath, err := fileManagement.FindProgramPath("xcrun")
+	if err != nil {
+		return err
+	}
+
+	out, stderr, err := runCommand(xcrunPath, "stapler", "staple", path)
+	if err != nil {
+		return fmt.Errorf("failed to staple the notarization ticket to %q: %v\n%s", path, err, stderr)
+	}
+
+	logger.Debug("Stapler output:\n%s\n", out)
+	return nil
+}
+
+// ExportTicket copies the ticket stapled to a bundle into a standalone file.
+//
+// Parameters:
+//   - appPath: Path to the stapled .app bundle
+//   - destination: Path of the exported ticket file
+//
+// Returns an error if the bundle has no stapled ticket or the file cannot be copied.
+func ExportTicket(appPath string, destination string) error {
+	if printingCommands() {
+		logger.Info("Skipping the ticket export, the ticket was not stapled (-print-commands)")
+		return nil
+	}
+
+	ticketPath := stapledTicketPath(appPath)
+	info, err := os.Stat(ticketPath)
+	if err != nil {
+		return fmt.Errorf("no stapled notarization ticket found in %q: %v", appPath, err)
+	}
+
+	if directory := filepath.Dir(destination); directory != "." {
+		if err := fileManagement.CreateIfNotExists(directory, 0755); err != nil {
+			return err
+		}
+	}
+	if err := fileManagement.Copy(ticketPath, destination); err != nil {
+		return fmt.Errorf("failed to export the notarization ticket to %q: %v", destination, err)
+	}
+
+	logger.Info("Notarization ticket exported to %s (%d bytes)", destination, info.Size())
+	return nil
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeStapler is a stand-in for "xcrun stapler staple <bundle>" that stores a ticket in
+// Contents/CodeResources of the bundle, or fails if the file "fail" exists next to it.
+const fakeStapler = `#!/bin/sh
+echo "$*" >> "$(dirname "$0")/calls"
+test -f "$(dirname "$0")/fail" && exit 1
+echo ticket > "$3/Contents/CodeResources"
+`
+
+// TestExportTicket staples a bundle with a fake stapler and exports the ticket: the exported
+// file is the ticket stapled before, and nothing is exported if stapling failed.
+func TestExportTicket(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    bool
+		wantErr string
+	}{
+		{name: "stapled"},
+		{name: "stapling failed", fail: true, wantErr: "no stapled notarization ticket found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			toolDirectory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(toolDirectory, "xcrun"), []byte(fakeStapler), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if test.fail {
+				writeBundleFile(t, toolDirectory, "fail", "")
+			}
+			t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+			appPath := writeTestApp(t, "Contents/MacOS/MyApp")
+			destination := filepath.Join(t.TempDir(), "tickets", "MyApp.ticket")
+
+			stapleErr := StapleApplication(appPath)
+			if (stapleErr != nil) != test.fail {
+				t.Fatalf("staple error = %v, want failure %v", stapleErr, test.fail)
+			}
+			if calls := readTestCalls(t, filepath.Join(toolDirectory, "calls")); len(calls) != 1 || calls[0] != "stapler staple "+appPath {
+				t.Errorf("xcrun calls = %q, want stapler staple %s", calls, appPath)
+			}
+
+			err := ExportTicket(appPath, destination)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readBundleFile(t, filepath.Dir(destination), "MyApp.ticket"); got != "ticket\n" {
+				t.Errorf("exported ticket = %q, want the stapled ticket", got)
+			}
+		})
+	}
+}
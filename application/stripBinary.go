@@ -0,0 +1,68 @@
+// Package application: This file handles stripping debug symbols from copied binaries.
+// Stripping reduces the bundle size. It must happen before signing, because modifying
+// a binary invalidates its signature. Universal (fat) binaries are handled by strip
+// itself, which processes every architecture slice.
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"appbundler/utilities/logger"
+	"fmt"
+)
+
+// isSigned reports whether the binary at the given path carries a signature with a
+// certificate. Ad-hoc signatures are ignored: the linker of current toolchains (including
+// Go) signs every arm64 binary ad-hoc, and strip updates such linker signatures itself.
+// If codesign is not available the binary is treated as unsigned.
+func isSigned(path string) bool {
+	codeSignPath, err := fileManagement.FindProgramPath("codesign")
+	if err != nil {
+		return false
+	}
+
+	// "codesign -dvv" exits with a non-zero status if the file is not signed, and writes
+	// the signature details (Authority=..., Signature=adhoc) to stderr
+	stdout, stderr, err := runQuery(codeSignPath, "-dvv", path)
+	if err != nil {
+		return false
+	}
+
+	return hasCertificateSignature(stderr + stdout)
+}
+
+// hasCertificateSignature reports whether "codesign -dvv" output describes a signature made
+// with a certificate, as opposed to an ad-hoc or linker signature.
+func hasCertificateSignature(output string) bool {
+	authority := parseSigningAuthority(output)
+	return authority != "" && authority != adhocSignature
+}
+
+// stripBinary removes debug symbols from a binary in place by running: strip -S <path>
+// Binaries signed with a certificate are skipped with a warning, as stripping would break the
+// signature; ad-hoc and linker signatures are updated by strip.
+//
+// Parameters:
+//   - path: Path of the binary inside the bundle
+//
+// Returns an error if the strip tool is not found or fails.
+func stripBinary(path string) error {
+	if isSigned(path) {
+		logger.Warn("Binary %s is already signed, skipping strip (stripping would invalidate the signature)", path)
+		return nil
+	}
+
+	stripPath, err := fileManagement.FindProgramPath("strip")
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Stripping debug symbols from %s", path)
+
+	// -S: remove the debugging symbol table entries
+	_, stderr, err := runCommand(stripPath, "-S", path)
+	if err != nil {
+		return fmt.Errorf("failed to strip %q: %v\n%s", path, err, stderr)
+	}
+
+	return nil
+}
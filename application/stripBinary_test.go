@@ -0,0 +1,45 @@
+package application
+
+import "testing"
+
+func TestHasCertificateSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{
+			name: "linker signature",
+			output: "Executable=/tmp/MyApp\nIdentifier=a.out\nFormat=Mach-O thin (arm64)\n" +
+				"CodeDirectory v=20400 size=1234 flags=0x20002(adhoc,linker-signed) hashes=33+0 location=embedded\n" +
+				"Signature=adhoc\nTeamIdentifier=not set\n",
+			want: false,
+		},
+		{
+			name: "ad-hoc signature",
+			output: "Executable=/tmp/MyApp\nCodeDirectory v=20500 size=1234 flags=0x2(adhoc) hashes=33+2 location=embedded\n" +
+				"Signature=adhoc\n",
+			want: false,
+		},
+		{
+			name: "developer ID signature",
+			output: "Executable=/tmp/MyApp\nCodeDirectory v=20500 size=1234 flags=0x10000(runtime) hashes=33+7 location=embedded\n" +
+				"Signature size=9000\nAuthority=Developer ID Application: Example Corp (ABCDE12345)\n" +
+				"Authority=Developer ID Certification Authority\nAuthority=Apple Root CA\nTeamIdentifier=ABCDE12345\n",
+			want: true,
+		},
+		{
+			name:   "no signature details",
+			output: "",
+			want:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hasCertificateSignature(test.output); got != test.want {
+				t.Errorf("hasCertificateSignature() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package application: This file removes junk files left by Finder and archive tools.
+// Resources copied from older systems or extracted from zip archives can contain .DS_Store
+// files, "._" AppleDouble files (resource forks and extended attributes) and __MACOSX
+// directories. They are sealed like any other resource, and AppleDouble files in particular
+// make codesign and the notary service fail, so they are removed before signing.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isJunkFile reports whether a file name is a Finder or AppleDouble leftover.
+func isJunkFile(name string) bool {
+	return name == ".DS_Store" || strings.HasPrefix(name, "._")
+}
+
+// StripJunk removes .DS_Store files, "._" AppleDouble files and the __MACOSX directories
+// that are empty afterwards from a bundle. Symbolic links are not followed.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns the number of removed entries, or an error if the bundle cannot be read or
+// an entry cannot be removed.
+func StripJunk(appPath string) (int, error) {
+	removed := 0
+	var archiveDirectories []string
+
+	err := filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == appPath {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == "__MACOSX" {
+				archiveDirectories = append(archiveDirectories, path)
+			}
+			return nil
+		}
+		if !isJunkFile(entry.Name()) {
+			return nil
+		}
+
+		if err := tracedRemoveAll(path); err != nil {
+			return err
+		}
+		logger.Debug("Removed junk file %s", path)
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	// Remove nested __MACOSX directories first, so their parents can become empty
+	sort.Sort(sort.Reverse(sort.StringSlice(archiveDirectories)))
+	for _, directory := range archiveDirectories {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return removed, err
+		}
+		if len(entries) > 0 {
+			logger.Warn("Keeping %s: it still contains %d entries", directory, len(entries))
+			continue
+		}
+
+		if err := tracedRemoveAll(directory); err != nil {
+			return removed, err
+		}
+		logger.Debug("Removed empty directory %s", directory)
+		removed++
+	}
+
+	if removed > 0 {
+		logger.Info("Removed %d junk entries from %s", removed, appPath)
+	}
+	return removed, nil
+}
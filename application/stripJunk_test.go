@@ -0,0 +1,60 @@
+package application
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestStripJunk seeds a bundle with Finder and archive leftovers: the junk files and the
+// __MACOSX directory that becomes empty are removed, legitimate files remain.
+func TestStripJunk(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "MyApp.app")
+	junk := []string{
+		"Contents/.DS_Store",
+		"Contents/Resources/._MyApp.icns",
+		"Contents/Resources/__MACOSX/._data.txt",
+	}
+	legitimate := []string{
+		"Contents/Info.plist",
+		"Contents/MacOS/MyApp",
+		"Contents/Resources/.hidden",
+		"Contents/Resources/MyApp.icns",
+		"Contents/Resources/data.txt",
+		"Contents/Resources/docs/__MACOSX/readme.txt",
+	}
+	for _, name := range append(junk, legitimate...) {
+		writeBundleFile(t, appPath, name, "content")
+	}
+
+	removed, err := StripJunk(appPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The junk files and the emptied Contents/Resources/__MACOSX directory
+	if removed != len(junk)+1 {
+		t.Errorf("removed %d entries, want %d", removed, len(junk)+1)
+	}
+
+	var remaining []string
+	err = filepath.WalkDir(appPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(appPath, path)
+		remaining = append(remaining, filepath.ToSlash(relative))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(remaining)
+	if !reflect.DeepEqual(remaining, legitimate) {
+		t.Errorf("remaining files = %q, want %q", remaining, legitimate)
+	}
+	if entries, err := filepath.Glob(filepath.Join(appPath, "Contents", "Resources", "__MACOSX")); err != nil || len(entries) != 0 {
+		t.Errorf("empty __MACOSX directory was kept: %v (%v)", entries, err)
+	}
+}
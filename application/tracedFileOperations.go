@@ -0,0 +1,72 @@
+// Package application: This file wraps the file system operations that change the bundle,
+// so they are recorded in the trace file when tracing is enabled (see fileManagement.Trace).
+package application
+
+import (
+	"appbundler/utilities/fileManagement"
+	"os"
+	"time"
+)
+
+// tracedRemoveAll removes a path and everything below it (os.RemoveAll).
+func tracedRemoveAll(path string) error {
+	err := os.RemoveAll(path)
+	fileManagement.Trace("remove", err, path)
+	return err
+}
+
+// tracedRemove removes a file or an empty directory (os.Remove).
+func tracedRemove(path string) error {
+	err := os.Remove(path)
+	fileManagement.Trace("remove", err, path)
+	return err
+}
+
+// tracedRename renames (moves) a file or directory (os.Rename).
+func tracedRename(oldPath string, newPath string) error {
+	err := os.Rename(oldPath, newPath)
+	fileManagement.Trace("rename", err, oldPath, newPath)
+	return err
+}
+
+// tracedMkdirAll creates a directory and all missing parents (os.MkdirAll).
+func tracedMkdirAll(path string, perm os.FileMode) error {
+	err := os.MkdirAll(path, perm)
+	fileManagement.Trace("mkdir", err, path)
+	return err
+}
+
+// tracedChmod changes the permissions of a file (os.Chmod).
+func tracedChmod(path string, mode os.FileMode) error {
+	err := os.Chmod(path, mode)
+	fileManagement.Trace("chmod", err, path)
+	return err
+}
+
+// tracedCreate creates or truncates a file (os.Create).
+func tracedCreate(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	fileManagement.Trace("create", err, path)
+	return file, err
+}
+
+// tracedWriteFile writes a complete file (os.WriteFile).
+func tracedWriteFile(path string, data []byte, perm os.FileMode) error {
+	err := os.WriteFile(path, data, perm)
+	fileManagement.Trace("write", err, path)
+	return err
+}
+
+// tracedSymlink creates a symbolic link at path pointing to target (os.Symlink).
+func tracedSymlink(target string, path string) error {
+	err := os.Symlink(target, path)
+	fileManagement.Trace("symlink", err, target, path)
+	return err
+}
+
+// tracedChtimes sets the access and modification time of a file (os.Chtimes).
+func tracedChtimes(path string, modificationTime time.Time) error {
+	err := os.Chtimes(path, modificationTime, modificationTime)
+	fileManagement.Trace("chtimes", err, path)
+	return err
+}
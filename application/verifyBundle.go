@@ -0,0 +1,63 @@
+// Package application: This file checks the structure of a built bundle. macOS starts an
+// application through Contents/MacOS/<CFBundleExecutable>; if the file is missing, named
+// differently (e.g. a JAR launcher written under another name) or not executable, the bundle
+// looks complete in Finder but fails to launch with a generic error.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// VerifyBundleExecutable checks that the executable declared in Info.plist (CFBundleExecutable)
+// exists in Contents/MacOS/ as a regular file with an execute bit set.
+//
+// Parameters:
+//   - appPath: Path to the .app bundle
+//
+// Returns an error if Info.plist cannot be read, declares no executable, or the executable
+// is missing, not a file or not executable.
+func VerifyBundleExecutable(appPath string) error {
+	plist, err := ReadPlist(appPath)
+	if err != nil {
+		return err
+	}
+	if plist.ExecutableName == "" {
+		return fmt.Errorf("%s: Info.plist declares no CFBundleExecutable", appPath)
+	}
+
+	executablePath := filepath.Join(appPath, "Contents", "MacOS", plist.ExecutableName)
+	info, err := os.Stat(executablePath)
+	if err != nil {
+		return fmt.Errorf("%s: CFBundleExecutable %q not found in Contents/MacOS/%s", appPath, plist.ExecutableName, macosDirectoryListing(appPath))
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: CFBundleExecutable %s is not a file", appPath, executablePath)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s: CFBundleExecutable %s is not executable (mode %s)", appPath, executablePath, info.Mode().Perm())
+	}
+
+	logger.Debug("Bundle executable %s is present and executable", executablePath)
+	return nil
+}
+
+// macosDirectoryListing returns " (contains: a, b)" with the files of Contents/MacOS/, which
+// usually shows the name the executable was written under.
+func macosDirectoryListing(appPath string) string {
+	entries, err := os.ReadDir(filepath.Join(appPath, "Contents", "MacOS"))
+	if err != nil || len(entries) == 0 {
+		return " (the directory is empty)"
+	}
+
+	names := ""
+	for index, entry := range entries {
+		if index > 0 {
+			names += ", "
+		}
+		names += entry.Name()
+	}
+	return " (contains: " + names + ")"
+}
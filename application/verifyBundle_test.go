@@ -0,0 +1,47 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVerifyBundleExecutable checks a correct bundle and bundles whose CFBundleExecutable
+// (MyApp) is missing, written under another name, not executable or a directory.
+func TestVerifyBundleExecutable(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		mode    os.FileMode // Mode of Contents/MacOS/MyApp, if set
+		wantErr string
+	}{
+		{name: "correct bundle", files: []string{"Contents/MacOS/MyApp"}},
+		{name: "empty MacOS directory", files: []string{"Contents/MacOS/"}, wantErr: `CFBundleExecutable "MyApp" not found in Contents/MacOS/ (the directory is empty)`},
+		{name: "mismatched name", files: []string{"Contents/MacOS/MyApp.jar"}, wantErr: `CFBundleExecutable "MyApp" not found in Contents/MacOS/ (contains: MyApp.jar)`},
+		{name: "not executable", files: []string{"Contents/MacOS/MyApp"}, mode: 0644, wantErr: "is not executable (mode -rw-r--r--)"},
+		{name: "directory", files: []string{"Contents/MacOS/MyApp/"}, wantErr: "is not a file"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appPath := writeTestApp(t, test.files...)
+			if test.mode != 0 {
+				if err := os.Chmod(filepath.Join(appPath, "Contents", "MacOS", "MyApp"), test.mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := VerifyBundleExecutable(appPath)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
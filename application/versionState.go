@@ -0,0 +1,91 @@
+// Package application: This file handles the automatic increment of the bundle build number.
+// macOS refuses to install an application over an existing one with an equal or lower
+// CFBundleVersion, so the last used build number is persisted in a small state file
+// (a dotfile next to the configuration file) and incremented on every build.
+package application
+
+import (
+	"appbundler/utilities/logger"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// versionStateFile returns the path of the build number state file for a configuration file.
+// For a list of overlay files, the state file belongs to the base (first) file.
+// Example: ./config/application.yaml -> ./config/.application.build
+func versionStateFile(packageFileName string) string {
+	packageFileName = SplitConfigFiles(packageFileName)[0]
+	directory := filepath.Dir(packageFileName)
+	baseName := filepath.Base(packageFileName)
+	baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
+
+	return filepath.Join(directory, "."+baseName+".build")
+}
+
+// BumpBundleVersion increments the CFBundleVersion build number and stores the new value
+// in the state file next to the configuration file. The new value replaces the version
+// read from the configuration, so it is picked up by CreatePlist.
+//
+// On the first build (no state file yet) the version from the configuration is used as is.
+// On subsequent builds the last build number is incremented by one. If the configuration
+// contains a higher number than the next build number, the configured value wins.
+//
+// Parameters:
+//   - packageFileName: Path to the YAML configuration file
+//
+// Returns the new build number, or an error if:
+//   - The configured version or the stored build number is not an integer
+//   - The state file cannot be written
+func BumpBundleVersion(packageFileName string) (string, error) {
+	if packageFileName == "" {
+		packageFileName = "application.yaml"
+	}
+
+	// A date-based build number already increases with every build
+	if GetVersionScheme() == versionSchemeCalver {
+		return "", fmt.Errorf("-bump-version cannot be combined with version_scheme %q", versionSchemeCalver)
+	}
+
+	// The configured version acts as the lower bound for the build number
+	configuredVersion := 0
+	if GetBundleVersion() != "" {
+		var err error
+		configuredVersion, err = strconv.Atoi(strings.TrimSpace(GetBundleVersion()))
+		if err != nil {
+			return "", fmt.Errorf("cannot auto-increment non-numeric version %q", GetBundleVersion())
+		}
+	}
+
+	nextVersion := configuredVersion
+	stateFile := versionStateFile(packageFileName)
+
+	// Read the last build number if a previous build stored one
+	data, err := os.ReadFile(stateFile)
+	if err == nil {
+		lastVersion, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return "", fmt.Errorf("invalid build number in state file %s: %v", stateFile, err)
+		}
+
+		nextVersion = lastVersion + 1
+		if configuredVersion > nextVersion {
+			nextVersion = configuredVersion
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	} else {
+		logger.Debug("No build number state file found at %s, using the configured version", stateFile)
+	}
+
+	// Persist the new build number for the next run
+	newVersion := strconv.Itoa(nextVersion)
+	if err := os.WriteFile(stateFile, []byte(newVersion+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write build number state file: %v", err)
+	}
+
+	packageInfo.BundleVersion = newVersion
+	return newVersion, nil
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setTestPackageInfo replaces the loaded configuration for the duration of a test.
+func setTestPackageInfo(t *testing.T, parameter packageParameter) {
+	t.Helper()
+	previous := packageInfo
+	packageInfo = parameter
+	t.Cleanup(func() { packageInfo = previous })
+}
+
+func TestVersionStateFile(t *testing.T) {
+	tests := []struct {
+		packageFileName string
+		want            string
+	}{
+		{"application.yaml", ".application.build"},
+		{filepath.Join("config", "application.yaml"), filepath.Join("config", ".application.build")},
+		{"base.yaml,release.yaml", ".base.build"},
+	}
+
+	for _, test := range tests {
+		if got := versionStateFile(test.packageFileName); got != test.want {
+			t.Errorf("versionStateFile(%q) = %q, want %q", test.packageFileName, got, test.want)
+		}
+	}
+}
+
+func TestBumpBundleVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		state      string // Content of the state file, "" if there is none
+		scheme     string
+		want       string
+		wantErr    bool
+	}{
+		{name: "first build", configured: "7", want: "7"},
+		{name: "first build without version", want: "0"},
+		{name: "subsequent build", configured: "7", state: "7\n", want: "8"},
+		{name: "configured version is higher", configured: "20", state: "7\n", want: "20"},
+		{name: "non-numeric version", configured: "1.2", wantErr: true},
+		{name: "invalid state file", configured: "7", state: "seven\n", wantErr: true},
+		{name: "calver", configured: "7", scheme: versionSchemeCalver, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestPackageInfo(t, packageParameter{BundleVersion: test.configured, VersionScheme: test.scheme})
+
+			packageFileName := filepath.Join(t.TempDir(), "application.yaml")
+			stateFile := versionStateFile(packageFileName)
+			if test.state != "" {
+				if err := os.WriteFile(stateFile, []byte(test.state), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := BumpBundleVersion(packageFileName)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got version %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want || GetBundleVersion() != test.want {
+				t.Errorf("version = %q (configuration %q), want %q", got, GetBundleVersion(), test.want)
+			}
+
+			stored, err := os.ReadFile(stateFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(stored)) != test.want {
+				t.Errorf("state file contains %q, want %q", stored, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package main: This file writes the machine-readable result of a run (-json). Instead of
+// parsing log lines, CI pipelines read a single JSON object from stdout describing the built
+// bundles, the artifacts produced and the warnings logged. Log messages go to stderr.
+package main
+
+import (
+	"appbundler/application"
+	"appbundler/utilities/logger"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// bundleReport describes one bundle built during the run.
+type bundleReport struct {
+	AppPath    string   `json:"app_path"`   // Absolute path of the .app bundle
+	Identifier string   `json:"identifier"` // CFBundleIdentifier
+	Version    string   `json:"version"`    // CFBundleShortVersionString
+	Build      string   `json:"build"`      // CFBundleVersion
+	Signed     bool     `json:"signed"`     // The bundle was signed
+	Notarized  bool     `json:"notarized"`  // The bundle was notarized
+	Artifacts  []string `json:"artifacts"`  // Distribution files produced (zip, pkg)
+	SizeBytes  int64    `json:"size_bytes"` // Total size of the files in the bundle
+	Deleted    bool     `json:"deleted"`    // The bundle was deleted after building (-delete)
+}
+
+// runReport is the JSON result of the whole run.
+type runReport struct {
+	Success  bool           `json:"success"`         // All bundles were built successfully
+	Error    string         `json:"error,omitempty"` // Error that stopped the run (if any)
+	Bundles  []bundleReport `json:"bundles"`         // Bundles built successfully
+	Warnings []string       `json:"warnings"`        // Warnings logged during the run
+}
+
+// builtBundles collects the reports of the bundles built so far
+var builtBundles = []bundleReport{}
+
+// jsonOutput reports whether the run ends with a JSON result (-json).
+func jsonOutput() bool {
+	return jsonFlag != nil && *jsonFlag
+}
+
+// directorySize returns the total size in bytes of all files below a directory.
+func directorySize(path string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
+
+// recordBundle adds the finished bundle to the JSON result. It must be called while the
+// configuration of the bundle is still loaded and before the bundle is deleted (-delete).
+//
+// Parameters:
+//   - outputName: Name of the .app directory (without extension)
+//   - signed: The bundle was actually signed (not only printed with -print-commands)
+//   - notarized: The bundle was actually notarized
+//   - artifacts: Distribution files produced for the bundle
+func recordBundle(outputName string, signed bool, notarized bool, artifacts []string) {
+	if !jsonOutput() {
+		return
+	}
+
+	appPath := outputName + ".app"
+	if absolutePath, err := filepath.Abs(appPath); err == nil {
+		appPath = absolutePath
+	}
+
+	size, err := directorySize(appPath)
+	if err != nil {
+		logger.Debug("Failed to determine the size of %s: %v", appPath, err)
+	}
+
+	builtBundles = append(builtBundles, bundleReport{
+		AppPath:    appPath,
+		Identifier: application.GetBundleIdentifier(),
+		Version:    application.GetCFBundleShortVersionString(),
+		Build:      application.GetBundleVersion(),
+		Signed:     signed,
+		Notarized:  notarized,
+		Artifacts:  append([]string{}, artifacts...),
+		SizeBytes:  size,
+		Deleted:    deleteFlag != nil && *deleteFlag,
+	})
+}
+
+// writeReport writes the JSON result of the run to stdout.
+//
+// Parameters:
+//   - err: Error that stopped the run, or nil if it completed successfully
+func writeReport(err error) {
+	report := runReport{
+		Success:  err == nil,
+		Bundles:  builtBundles,
+		Warnings: logger.Warnings(),
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if report.Warnings == nil {
+		report.Warnings = []string{}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordBundle(t *testing.T) {
+	previousJSON, previousBundles := *jsonFlag, builtBundles
+	*jsonFlag = true
+	builtBundles = []bundleReport{}
+	t.Cleanup(func() { *jsonFlag, builtBundles = previousJSON, previousBundles })
+
+	outputName := filepath.Join(t.TempDir(), "MyApp")
+	macOSDirectory := filepath.Join(outputName+".app", "Contents", "MacOS")
+	if err := os.MkdirAll(macOSDirectory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(macOSDirectory, "MyApp"), []byte("12345"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		signed    bool
+		notarized bool
+	}{
+		{"signed and notarized", true, true},
+		{"signing only printed", false, false},
+		{"signed only", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builtBundles = []bundleReport{}
+			recordBundle(outputName, test.signed, test.notarized, []string{outputName + ".zip"})
+
+			if len(builtBundles) != 1 {
+				t.Fatalf("%d bundles recorded, want 1", len(builtBundles))
+			}
+			report := builtBundles[0]
+			if report.Signed != test.signed || report.Notarized != test.notarized {
+				t.Errorf("signed = %v, notarized = %v, want %v and %v", report.Signed, report.Notarized, test.signed, test.notarized)
+			}
+			if report.AppPath != outputName+".app" || report.SizeBytes != 5 {
+				t.Errorf("app path = %s, size = %d", report.AppPath, report.SizeBytes)
+			}
+		})
+	}
+}
@@ -5,10 +5,15 @@ package main
 
 import (
 	"appbundler/application"
+	"appbundler/utilities/fileManagement"
 	"appbundler/utilities/logger"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // Command-line flags define the behavior of the application bundler.
@@ -20,7 +25,8 @@ var (
 
 	// packageFileFlag: Path to the YAML configuration file containing bundle metadata.
 	// This file defines bundle identifier, version, executable name, icon, etc.
-	packageFileFlag = flag.String("application", "application.yaml", "Package description file")
+	// Several comma-separated files (base file first, then overrides) are merged in order.
+	packageFileFlag = flag.String("application", "application.yaml", "Package description file (comma-separated files are merged in order)")
 
 	// cleanFlag: If true, removes any existing .app bundle before creating a new one.
 	// Useful when rebuilding to ensure a clean state.
@@ -30,6 +36,10 @@ var (
 	// Required for distribution and Gatekeeper compatibility on macOS.
 	signFlag = flag.Bool("sign", false, "Sign the application structure with a real development key")
 
+	// noCleanOnErrorFlag: If true, the incomplete bundle of a failed build (<name>.app.tmp-<pid>)
+	// is kept for debugging instead of being removed
+	noCleanOnErrorFlag = flag.Bool("no-clean-on-error", false, "Keep the incomplete bundle when the build fails (for debugging)")
+
 	// deleteFlag: If true, removes the created bundle after building (useful for testing).
 	deleteFlag = flag.Bool("delete", false, "Delete the application structure")
 
@@ -38,15 +48,192 @@ var (
 	notariseFlag = flag.Bool("notarize", false, "Notarize for distribution")
 
 	// appleIDProfileFlag: The name of the keychain profile containing Apple ID credentials.
-	// Required if -notarize is used.
+	// Used by -notarize; without it the profile is derived from the bundle identifier (notary_profile).
 	appleIDProfileFlag = flag.String("profile", "", "Apple ID profile name for notarization")
 
+	// exportTicketFlag: Path of a file receiving the notarization ticket. With -notarize, the
+	// ticket is stapled to the bundle and then exported as a standalone file.
+	exportTicketFlag = flag.String("export-ticket", "", "Staple the notarization ticket and export it to the given file")
+
+	// stapleDmgFlag: If true, a disk image (<name>.dmg) is created from the notarized and stapled
+	// bundle, then signed, notarized and stapled itself. Requires -notarize
+	stapleDmgFlag = flag.Bool("staple-dmg", false, "Create a notarized and stapled disk image of the bundle")
+
 	// silentFlag: If true, suppresses informational log messages (only errors will be shown).
 	silentFlag = flag.Bool("silent", false, "Silent mode during installation")
 
 	// logDirFlag: Directory where log files should be written. If set, enables file logging.
 	// Log files are named with the application name and timestamp: <appName>_YYYY-MM-DD_HH-MM-SS.log
 	logDirFlag = flag.String("logdir", "", "Directory for log files (enables file logging)")
+
+	// logUTCFlag: If true, log timestamps are written in RFC 3339 UTC format with milliseconds
+	// instead of local time with second precision.
+	logUTCFlag = flag.Bool("log-utc", false, "Write log timestamps in UTC (RFC 3339) with milliseconds")
+
+	// bumpVersionFlag: If true, increments the CFBundleVersion build number before creating Info.plist.
+	// The last build number is stored in a dotfile next to the configuration file.
+	bumpVersionFlag = flag.Bool("bump-version", false, "Auto-increment the bundle build number (CFBundleVersion)")
+
+	// listIdentitiesFlag: If true, prints all code signing identities found in the keychain and exits.
+	listIdentitiesFlag = flag.Bool("list-identities", false, "List available code signing identities and exit")
+
+	// dequarantineFlag: If true, removes the com.apple.quarantine attribute from the finished bundle.
+	// This is for local testing only and doesn't replace signing or notarization.
+	dequarantineFlag = flag.Bool("dequarantine", false, "Remove the quarantine attribute from the bundle (local testing only)")
+
+	// signWorkersFlag: Maximum number of nested components (frameworks, helpers) signed concurrently.
+	// Components are always signed inside-out; only siblings on the same level run in parallel.
+	signWorkersFlag = flag.Int("sign-workers", 1, "Number of nested components to sign concurrently")
+
+	// cleanArtifactsFlag: If true, removes distribution artifacts (<name>.zip, <name>.dmg, <name>.pkg) and
+	// temporary iconsets of the current bundle. Also implied by -clean.
+	cleanArtifactsFlag = flag.Bool("clean-artifacts", false, "Remove zip/dmg artifacts and temporary iconsets of the bundle")
+
+	// keepGoingFlag: If true, a failing bundle in a batch run (several configuration files) is
+	// reported and the remaining bundles are still built. The exit code is non-zero if any failed.
+	keepGoingFlag = flag.Bool("keep-going", false, "Continue with the remaining bundles when one fails (batch mode)")
+
+	// relativeToConfigFlag: If true, relative paths in the configuration file are resolved relative
+	// to the directory containing the configuration file instead of the current working directory.
+	relativeToConfigFlag = flag.Bool("relative-to-config", false, "Resolve relative paths in the config relative to the config file")
+
+	// noPkgInfoFlag: If true, the legacy Contents/PkgInfo file is not created.
+	// Modern macOS ignores it; it is still created by default for backward compatibility.
+	noPkgInfoFlag = flag.Bool("no-pkginfo", false, "Do not create the legacy PkgInfo file")
+
+	// noIconFlag: If true, the bundle is built without an icon (no CFBundleIconFile), e.g. for
+	// command-line tools packaged as .app. Configured icons are ignored.
+	noIconFlag = flag.Bool("no-icon", false, "Build the bundle without an icon")
+
+	// timestampURLFlag: URL of a custom timestamp authority passed to codesign (--timestamp=<url>).
+	// If not provided, Apple's timestamp server is used.
+	timestampURLFlag = flag.String("timestamp-url", "", "Custom timestamp server URL for code signing")
+
+	// noTimestampFlag: If true, signs without a secure timestamp (--timestamp=none).
+	// Notarization requires a timestamp, so this is only useful for local builds.
+	noTimestampFlag = flag.Bool("no-timestamp", false, "Sign without a secure timestamp")
+
+	// printCommandsFlag: If true, the external commands acting on the bundle (codesign, notarytool,
+	// zip, stapler, pkgbuild, ...) are printed as shell command lines instead of being run.
+	printCommandsFlag = flag.Bool("print-commands", false, "Print the signing, notarization and packaging commands instead of running them")
+
+	// verboseFlag: If true, the output of external tools (codesign, notarytool, ...) is streamed
+	// live to the log at Debug level, not only reported when a tool fails.
+	verboseFlag = flag.Bool("verbose", false, "Stream the output of external tools to the log")
+
+	// infoFlag: If true, prints every resolved configuration value and exits without building.
+	// Missing source files are reported as "not found" instead of stopping the program.
+	infoFlag = flag.Bool("info", false, "Print the resolved configuration and exit")
+
+	// pkgFlag: If true, builds an installer package (<name>.pkg) installing the bundle into
+	// /Applications. The payload is installed as root:wheel (pkgbuild --ownership recommended).
+	pkgFlag = flag.Bool("pkg", false, "Build an installer package for the bundle")
+
+	// sparkleFlag: If true, signs Sparkle.framework with its known-good order (helpers, then the
+	// framework, then the app), including the Autoupdate helper executable.
+	sparkleFlag = flag.Bool("sparkle", false, "Use the signing preset for apps updated with Sparkle")
+
+	// outputNameFlag: Name of the .app directory (without extension), e.g. MyApp-beta.
+	// Only the directory name changes; CFBundleName in Info.plist stays the configured name.
+	outputNameFlag = flag.String("output-name", "", "Name of the .app directory (default is the bundle name)")
+
+	// jobsFlag: Maximum number of bundles built concurrently in batch mode. Each bundle is built
+	// by a separate appbundler process; signing is still done by one process at a time.
+	jobsFlag = flag.Int("jobs", 1, "Number of bundles to build concurrently (batch mode)")
+
+	// commandTimeoutFlag: Maximum run time of external tools (codesign, security, zip, ...).
+	// A tool running longer is killed and the build fails. 0 disables the timeout.
+	commandTimeoutFlag = flag.Duration("command-timeout", 10*time.Minute, "Timeout for external commands (0 = no timeout)")
+
+	// notarizeTimeoutFlag: Maximum time to wait for the notarization submission (notarytool --wait).
+	notarizeTimeoutFlag = flag.Duration("notarize-timeout", 2*time.Hour, "Timeout for the notarization submission (0 = no timeout)")
+
+	// iconFromAppFlag: Path of an existing .app bundle whose icon (CFBundleIconFile) replaces
+	// the icon configured in the YAML file, e.g. to reuse the icon of a previous build.
+	iconFromAppFlag = flag.String("icon-from-app", "", "Reuse the icon of an existing .app bundle")
+
+	// explodeIconFlag: Path of an .icns file to expand into an .iconset directory of PNG images
+	// (for inspecting or editing the icon). No bundle is built.
+	explodeIconFlag = flag.String("explode-icon", "", "Expand an .icns file into an .iconset directory and exit")
+
+	// iconsetOutputFlag: Output directory for -explode-icon (default <icon name>.iconset).
+	iconsetOutputFlag = flag.String("iconset-output", "", "Output directory for -explode-icon")
+
+	// traceFlag: Path of a trace file recording every file system operation of the build
+	// (mkdir, copy, chmod, remove, ...) with full paths and results. Disabled if empty.
+	traceFlag = flag.String("trace", "", "Record all file system operations in the given trace file")
+
+	// signOnlyFlag: Path of an existing .app bundle to sign. No bundle is built; only signing
+	// and verification run (for pipelines that build and sign in separate stages).
+	signOnlyFlag = flag.String("sign-only", "", "Sign an existing .app bundle without building it")
+
+	// skipPreflightFlag: If true, the bundle is submitted for notarization without checking it
+	// for common notarization blockers first (hardened runtime, timestamp, unsigned code)
+	skipPreflightFlag = flag.Bool("skip-preflight", false, "Skip the notarization preflight checks")
+
+	// jsonFlag: If true, a single JSON object describing the run (bundles, artifacts, warnings)
+	// is written to stdout at the end; log messages go to stderr
+	jsonFlag = flag.Bool("json", false, "Write the result of the run as JSON to stdout (logs go to stderr)")
+
+	// signResourcesFlag: If true, executable files in Contents/Resources/ and Contents/MacOS/
+	// (helper scripts, tools) are signed individually before the bundle
+	signResourcesFlag = flag.Bool("sign-resources", false, "Sign loose executables in Resources and MacOS before the bundle")
+
+	// stripJunkFlag: If true, .DS_Store files, "._" AppleDouble files and empty __MACOSX
+	// directories are removed from the bundle before it is signed.
+	stripJunkFlag = flag.Bool("strip-junk", false, "Remove .DS_Store, ._* and empty __MACOSX entries before signing")
+
+	// codesignExtraFlag: Additional arguments appended to every codesign signing call
+	// (split like a shell would, e.g. "--entitlements 'My App.entitlements'")
+	codesignExtraFlag = flag.String("codesign-extra", "", "Additional arguments passed verbatim to codesign")
+
+	// entitlementsPresetFlag: Comma-separated entitlements presets (jit, allow-unsigned-libraries,
+	// disable-library-validation) the bundle is signed with. Each preset weakens the hardened runtime
+	entitlementsPresetFlag = flag.String("entitlements-preset", "", "Comma-separated entitlements presets to sign with (jit, allow-unsigned-libraries, disable-library-validation)")
+
+	// identityFlag: Code signing identity (certificate name or SHA-1 hash). Takes precedence
+	// over the CODESIGN_IDENTITY environment variable; without both, the keychain is searched
+	identityFlag = flag.String("identity", "", "Code signing identity (default: CODESIGN_IDENTITY, then the first identity in the keychain)")
+
+	// updatePlistFlag: Path of an existing .app bundle whose Info.plist (and PkgInfo) is
+	// regenerated from the configuration; nothing else in the bundle is changed
+	updatePlistFlag = flag.String("update-plist", "", "Regenerate Info.plist of an existing .app bundle from the configuration and exit")
+
+	// compareFlag: Path of a first .app bundle to compare with the bundle given as argument
+	// (-compare <appA> <appB>). Differences of Info.plist and files are printed; nothing is built.
+	compareFlag = flag.String("compare", "", "Compare the given .app bundle with the bundle passed as argument and exit")
+
+	// jdepsFlag: Path of an application JAR whose required Java modules are printed (via jdeps)
+	// for use with jlink --add-modules. Further arguments are JARs of the class path.
+	jdepsFlag = flag.String("jdeps", "", "Print the Java modules needed by the given JAR (further arguments: class path JARs) and exit")
+
+	// listDocumentTypesFlag: If true, the document types LaunchServices registered for the
+	// bundle identifier of the configuration are printed (via lsregister -dump); nothing is built
+	listDocumentTypesFlag = flag.Bool("list-document-types", false, "Print the document types LaunchServices registered for the bundle identifier and exit")
+
+	// verifyFlag: Path of an existing .app bundle whose structure is checked (the executable
+	// declared in Info.plist must exist in Contents/MacOS/ and be executable); nothing is built
+	verifyFlag = flag.String("verify", "", "Check the structure of an existing .app bundle and exit")
+
+	// signUpdateFlag: Path of an update archive (zip or dmg) whose Sparkle EdDSA signature is
+	// printed as the attributes of the appcast enclosure; requires -sparkle-key. Nothing is built
+	signUpdateFlag = flag.String("sign-update", "", "Print the Sparkle EdDSA signature of an update archive and exit (requires -sparkle-key)")
+
+	// sparkleKeyFlag: Private key file exported with Sparkle's "generate_keys -x", used by -sign-update
+	sparkleKeyFlag = flag.String("sparkle-key", "", "Sparkle private key file (exported with generate_keys -x) for -sign-update")
+
+	// configSchemaFlag: If true, an example configuration file listing every supported field
+	// with its description is printed; nothing is built
+	configSchemaFlag = flag.Bool("config-schema", false, "Print an example configuration with all supported fields and exit")
+
+	// reproducibleFlag: If true, all files of the bundle get the same modification time
+	// (SOURCE_DATE_EPOCH, default 1980-01-01) and the zip archive lists them in a fixed order,
+	// so builds from the same inputs are byte-identical before signing
+	reproducibleFlag = flag.Bool("reproducible", false, "Use fixed file times (SOURCE_DATE_EPOCH) and a fixed zip order for reproducible builds")
+
+	// strictFlag: If true, checks that normally only warn fail the build instead
+	// (e.g. a bundled Java runtime that does not match the target architecture).
+	strictFlag = flag.Bool("strict", false, "Treat warnings about likely broken bundles as errors")
 )
 
 // main is the entry point of the application bundler.
@@ -59,34 +246,259 @@ var (
 // 6. Copy icon file
 // 7. Optionally sign the application
 // 8. Optionally clean up temporary files
+//
+// Additional configuration files can be passed as arguments to build several bundles in
+// one run. With -keep-going, a failing bundle is reported and the remaining ones are built.
 func main() {
 	// Parse all command-line flags defined above
 	flag.Parse()
 
-	// Get the application name from command-line flag
-	applicationName := *applicationNameFlag
+	// Keep stdout free for the JSON result
+	if jsonOutput() {
+		logger.SetOutput(os.Stderr)
+	}
+
+	// Unambiguous timestamps for correlating logs across machines and time zones
+	if logUTCFlag != nil && *logUTCFlag {
+		logger.SetUTCTimestamps(true)
+	}
 
 	// Configure logger to suppress output if silent mode is enabled
 	if silentFlag != nil && *silentFlag {
 		logger.SetSilent(*silentFlag)
 	}
 
+	// Remove the incomplete bundle if the build is interrupted (Ctrl-C)
+	installSignalCleanup()
+
+	// List the available signing identities and exit (no bundle is built)
+	if listIdentitiesFlag != nil && *listIdentitiesFlag {
+		identities, err := application.ListSigningIdentities()
+		if err != nil {
+			errorExit(err)
+		}
+		for index, identity := range identities {
+			fmt.Printf("%d) %s\n", index+1, identity)
+		}
+		if len(identities) == 0 {
+			fmt.Println("No valid code signing identity found in keychain")
+		}
+		exit(0)
+	}
+
+	application.SetResolveRelativeToConfig(*relativeToConfigFlag)
+	application.SetSkipPkgInfo(*noPkgInfoFlag)
+	application.SetSkipIcon(*noIconFlag)
+	application.SetVerbose(*verboseFlag)
+	if printCommandsFlag != nil && *printCommandsFlag {
+		// The command lines go where the logs go, so stdout stays free for the JSON result
+		if jsonOutput() {
+			application.SetPrintCommands(os.Stderr)
+		} else {
+			application.SetPrintCommands(os.Stdout)
+		}
+	}
+	application.SetStrict(*strictFlag)
+	application.SetKeepFailedBundle(*noCleanOnErrorFlag)
+	application.SetSkipNotarizePreflight(*skipPreflightFlag)
+	application.SetSignResources(*signResourcesFlag)
+	application.SetSigningIdentity(*identityFlag)
+	application.SetCommandTimeouts(*commandTimeoutFlag, *notarizeTimeoutFlag)
+	if traceFlag != nil && *traceFlag != "" {
+		if err := fileManagement.SetTraceFile(*traceFlag); err != nil {
+			errorExit(err)
+		}
+		// Also closed by exit(), as os.Exit skips deferred calls
+		defer fileManagement.CloseTrace()
+	}
+
+	// The ticket only exists after a successful notarization
+	if exportTicketFlag != nil && *exportTicketFlag != "" && !*notariseFlag {
+		errorExit(fmt.Errorf("-export-ticket requires -notarize"))
+	}
+	if stapleDmgFlag != nil && *stapleDmgFlag && !*notariseFlag {
+		errorExit(fmt.Errorf("-staple-dmg requires -notarize"))
+	}
+	if noIconFlag != nil && *noIconFlag && *iconFromAppFlag != "" {
+		errorExit(fmt.Errorf("-no-icon cannot be combined with -icon-from-app"))
+	}
+
+	if err := application.SetTimestampServer(*timestampURLFlag, *noTimestampFlag); err != nil {
+		errorExit(err)
+	}
+	if err := application.SetCodesignExtraArguments(*codesignExtraFlag); err != nil {
+		errorExit(err)
+	}
+	if err := application.SetEntitlementsPresets(*entitlementsPresetFlag); err != nil {
+		errorExit(err)
+	}
+	if err := application.SetReproducible(*reproducibleFlag); err != nil {
+		errorExit(err)
+	}
+
+	// Expand an icon into an iconset and exit (nothing is built)
+	if explodeIconFlag != nil && *explodeIconFlag != "" {
+		iconset, err := application.ExplodeIcon(*explodeIconFlag, *iconsetOutputFlag)
+		errorExit(err)
+		fmt.Println(iconset)
+		exit(0)
+	}
+
+	// Print the example configuration and exit (no configuration needed)
+	if configSchemaFlag != nil && *configSchemaFlag {
+		fmt.Print(application.ConfigSchema())
+		exit(0)
+	}
+
+	// Print the Java modules needed by a JAR and exit
+	if jdepsFlag != nil && *jdepsFlag != "" {
+		errorExit(printJavaModules(*jdepsFlag, flag.Args()))
+		exit(0)
+	}
+
+	// Print the document types registered in LaunchServices and exit (read-only)
+	if listDocumentTypesFlag != nil && *listDocumentTypesFlag {
+		errorExit(application.Read(*packageFileFlag))
+		errorExit(printRegisteredDocumentTypes(application.GetBundleIdentifier()))
+		exit(0)
+	}
+
+	// Check an existing bundle and exit (read-only, no configuration needed)
+	if verifyFlag != nil && *verifyFlag != "" {
+		errorExit(application.VerifyBundleExecutable(*verifyFlag))
+		logger.Info("Bundle %s verified", *verifyFlag)
+		exit(0)
+	}
+
+	// Sign an update archive for the Sparkle appcast and exit (no configuration needed)
+	if signUpdateFlag != nil && *signUpdateFlag != "" {
+		errorExit(printUpdateSignature(*signUpdateFlag, *sparkleKeyFlag))
+		exit(0)
+	}
+
+	// Compare two existing bundles and exit (read-only, no configuration needed)
+	if compareFlag != nil && *compareFlag != "" {
+		exit(compareBundles(*compareFlag, flag.Args()))
+	}
+
+	// Regenerate the Info.plist of an existing bundle and exit (nothing is built)
+	if updatePlistFlag != nil && *updatePlistFlag != "" {
+		errorExit(application.Read(*packageFileFlag))
+		errorExit(application.UpdatePlist(*updatePlistFlag))
+		exit(0)
+	}
+
+	// Sign an existing bundle and exit (nothing is built)
+	if signOnlyFlag != nil && *signOnlyFlag != "" {
+		errorExit(signExistingBundle(*signOnlyFlag))
+		logger.Info("Application Bundler completed successfully")
+		exit(0)
+	}
+
+	// Build the bundle of the -application file, followed by any configuration
+	// files passed as arguments (batch mode)
+	packageFiles := append([]string{*packageFileFlag}, flag.Args()...)
+
+	// Print the resolved configuration and exit (read-only, nothing is built)
+	if infoFlag != nil && *infoFlag {
+		for _, packageFile := range packageFiles {
+			if err := application.Read(packageFile); err != nil {
+				errorExit(err)
+			}
+			fmt.Printf("Configuration: %s\n", packageFile)
+			if err := application.PrintConfiguration(os.Stdout); err != nil {
+				errorExit(err)
+			}
+		}
+		exit(0)
+	}
+
+	// Build several bundles concurrently in separate processes
+	if jobsFlag != nil && *jobsFlag > 1 && len(packageFiles) > 1 {
+		if jsonOutput() {
+			errorExit(fmt.Errorf("-json cannot be combined with -jobs"))
+		}
+		failedBuilds := runParallelBuilds(packageFiles, *jobsFlag)
+		if failedBuilds > 0 {
+			errorExit(fmt.Errorf("%d of %d bundles failed to build", failedBuilds, len(packageFiles)))
+		}
+
+		logger.Info("Application Bundler completed successfully")
+		return
+	}
+
+	failedBuilds := 0
+	for _, packageFile := range packageFiles {
+		err := buildApplication(packageFile)
+		if err == nil {
+			continue
+		}
+
+		// Without -keep-going the first failure stops the whole run
+		if keepGoingFlag == nil || !*keepGoingFlag {
+			errorExit(err)
+		}
+
+		logger.Warn("Building the bundle for %s failed: %v", packageFile, err)
+		failedBuilds++
+	}
+
+	if failedBuilds > 0 {
+		errorExit(fmt.Errorf("%d of %d bundles failed to build", failedBuilds, len(packageFiles)))
+	}
+
+	logger.Info("Application Bundler completed successfully")
+	if jsonOutput() {
+		writeReport(nil)
+	}
+}
+
+// buildApplication runs the complete bundling process for one configuration file.
+//
+// Parameters:
+//   - packageFile: Path to the YAML configuration file describing the bundle
+//
+// Returns the first error that occurred, which stops the build of this bundle.
+func buildApplication(packageFile string) error {
 	// Read the YAML configuration file that contains bundle metadata
 	// This populates internal structures with bundle identifier, version, executable name, etc.
-	packageFileError := application.Read(*packageFileFlag)
+	packageFileError := application.Read(packageFile)
 	if packageFileError != nil {
-		logger.Debug(packageFileError.Error())
-		os.Exit(1)
+		return packageFileError
+	}
+
+	// Reuse the icon of an existing bundle instead of the configured one
+	if iconFromAppFlag != nil && *iconFromAppFlag != "" {
+		if err := application.UseIconFromApp(*iconFromAppFlag); err != nil {
+			return err
+		}
 	}
 
 	// Step 0: Validate the configuration and check if all source files exist
 	// This prevents partial builds by ensuring everything is ready before we start
 	if err := application.ValidateConfiguration(); err != nil {
-		logger.Error(err)
-		os.Exit(1)
+		return err
+	}
+
+	// Check that all external tools needed for the requested steps are installed,
+	// so a long build doesn't fail at the very end
+	preflightOptions := application.PreflightOptions{
+		Sign:         *signFlag,
+		Notarize:     *notariseFlag,
+		Dequarantine: *dequarantineFlag,
+		Package:      *pkgFlag,
+	}
+	if err := application.PreflightCheck(preflightOptions); err != nil {
+		return err
+	}
+
+	// Make sure the bundle fits on the disk, instead of failing halfway through a large copy
+	if err := application.CheckDiskSpace("."); err != nil {
+		return err
 	}
 
 	// If no application name was provided via command-line, use the name from the config file
+	applicationName := *applicationNameFlag
 	if applicationNameFlag == nil || applicationName == "" {
 		applicationName = application.GetBundleName()
 		logger.Debug("Application name is %s", applicationName)
@@ -110,20 +522,50 @@ func main() {
 		}
 	}
 
-	// If clean flag is set, remove any existing bundle to start fresh
+	// The .app directory is named after the bundle unless a different output name is requested
+	outputName := application.GetBundleName()
+	if outputNameFlag != nil && *outputNameFlag != "" {
+		outputName = *outputNameFlag
+	}
+	if err := application.ValidateOutputName(outputName); err != nil {
+		return err
+	}
+
+	// If clean flag is set, remove the existing bundle of this configuration to start fresh
 	if *cleanFlag == true {
-		logger.Debug("Delete previous generated files")
-		application.DeleteAll()
+		packageFileError = application.CleanBundle(outputName)
+		if packageFileError != nil {
+			return packageFileError
+		}
 	}
 
-	logger.Debug("Name of the application bundle description file: %s", *packageFileFlag)
+	// Remove distribution artifacts of previous builds, so a rebuild starts fresh
+	if *cleanFlag || (cleanArtifactsFlag != nil && *cleanArtifactsFlag) {
+		packageFileError = application.CleanArtifacts(outputName)
+		if packageFileError != nil {
+			return packageFileError
+		}
+	}
+
+	logger.Debug("Name of the application bundle description file: %s", packageFile)
 
 	// Step 1: Create the macOS bundle directory structure
-	// This creates: MyApp.app/Contents/{MacOS, Resources, Java/runtime}
-	packageFileError = application.CreateDirectoryStructure(application.GetBundleName())
+	// This creates: MyApp.app/Contents/{MacOS, Resources} and, for Java applications, Java/runtime (in a temporary directory)
+	// The bundle is built in a temporary directory; if any of the following steps fails,
+	// the incomplete bundle is removed and an existing bundle stays untouched
+	packageFileError = application.CreateDirectoryStructure(outputName)
 	if packageFileError != nil {
-		errorExit(packageFileError)
-		return
+		return packageFileError
+	}
+	defer application.DiscardFailedBundle()
+
+	// Optionally increment the build number before it is written into Info.plist
+	if bumpVersionFlag != nil && *bumpVersionFlag {
+		newVersion, err := application.BumpBundleVersion(packageFile)
+		if err != nil {
+			return err
+		}
+		logger.Info("Bundle version bumped to %s", newVersion)
 	}
 
 	// Step 2: Generate the Info.plist file
@@ -131,7 +573,7 @@ func main() {
 	// It contains metadata like bundle identifier, version, executable name, icon, etc.
 	packageFileError = application.CreatePlist()
 	if packageFileError != nil {
-		errorExit(packageFileError)
+		return packageFileError
 	}
 
 	// Step 3: Copy the executable file into the bundle
@@ -139,16 +581,60 @@ func main() {
 	// For compiled executables: copies the binary and makes it executable
 	packageFileError = application.CopyExecutable()
 	if packageFileError != nil {
-		errorExit(packageFileError)
+		return packageFileError
 	}
 
 	// Step 4: Copy the application icon to Resources directory
 	// The icon file (usually .icns format) is required for proper macOS integration
 	packageFileError = application.CopyIcon()
 	if packageFileError != nil {
-		errorExit(packageFileError)
+		return packageFileError
+	}
+
+	// Copy additional resource files and directories to the Resources directory
+	packageFileError = application.CopyResources()
+	if packageFileError != nil {
+		return packageFileError
+	}
+
+	// Write the launchd property list of a background agent (only if launch_agent is configured)
+	packageFileError = application.CreateLaunchAgent()
+	if packageFileError != nil {
+		return packageFileError
+	}
+
+	// Remove Finder and AppleDouble leftovers, which break signing and notarization (optional)
+	if stripJunkFlag != nil && *stripJunkFlag {
+		if _, err := application.StripJunk(application.GetApplicationDirectory()); err != nil {
+			return err
+		}
+	}
+
+	// Make sure macOS can launch the bundle: Contents/MacOS/<CFBundleExecutable> must be executable
+	packageFileError = application.VerifyBundleExecutable(application.GetApplicationDirectory())
+	if packageFileError != nil {
+		return packageFileError
 	}
 
+	// Check Info.plist for mistakes plutil does not catch (unusual identifiers, versions, missing icon)
+	plistFindings, err := application.LintPlist(application.GetApplicationDirectory())
+	if err != nil {
+		return err
+	}
+	for _, finding := range plistFindings {
+		logger.Warn("Info.plist: %s", finding)
+	}
+
+	// Give all files the same modification time, so the unsigned bundle is reproducible (-reproducible)
+	packageFileError = application.NormalizeTimestamps(application.GetApplicationDirectory())
+	if packageFileError != nil {
+		return packageFileError
+	}
+
+	// Results of the optional steps for the JSON result (-json); with -print-commands they
+	// succeed without changing the bundle
+	signed, notarized := false, false
+
 	// Step 5: Code sign the application bundle (optional)
 	// Code signing is required for:
 	// - Distribution outside the Mac App Store
@@ -156,38 +642,275 @@ func main() {
 	// - Notarization (if distributing)
 	// Uses the first available development certificate from the keychain
 	if signFlag != nil && *signFlag == true {
-		packageFileError = application.SignApplication()
+		application.SetSignWorkers(*signWorkersFlag)
+		application.SetSparkleSigning(*sparkleFlag)
+		packageFileError = application.SignApplication(application.GetApplicationDirectory())
 		if packageFileError != nil {
-			errorExit(packageFileError)
+			return packageFileError
 		}
+
+		// Signing rewrote the binaries and added _CodeSignature directories
+		packageFileError = application.NormalizeTimestamps(application.GetApplicationDirectory())
+		if packageFileError != nil {
+			return packageFileError
+		}
+		signed = !application.PrintingCommands()
 	}
 
+	// Move the completed bundle to its final <name>.app location, replacing an existing bundle
+	packageFileError = application.FinalizeBundle()
+	if packageFileError != nil {
+		return packageFileError
+	}
+
+	// Distribution files produced from the bundle (reported with -json)
+	var artifacts []string
+
 	// Step 6: Notarize the application bundle (optional)
 	// Notarization requires the bundle to be signed first.
-	// It also requires an Apple ID profile for credentials.
+	// It also requires an Apple ID profile for credentials (-profile, or derived from the bundle identifier).
 	if notariseFlag != nil && *notariseFlag == true {
-		if appleIDProfileFlag == nil || *appleIDProfileFlag == "" {
-			errorExit(fmt.Errorf("notarization requires an Apple ID profile (use -profile <name>)"))
+		appleIDProfile, profileSource, err := application.NotaryProfile(*appleIDProfileFlag)
+		if err != nil {
+			return err
 		}
+		logger.Info("Using notarization profile %q (from %s)", appleIDProfile, profileSource)
 
 		logger.Info("Starting notarization process (this may take several minutes)...")
-		packageFileError = application.NotarizeApplication(application.GetBundleName(), *appleIDProfileFlag)
+		packageFileError = application.NotarizeApplication(outputName, appleIDProfile)
 		if packageFileError != nil {
-			errorExit(packageFileError)
+			return packageFileError
 		}
+		artifacts = append(artifacts, outputName+".zip")
+		notarized = !application.PrintingCommands()
 		logger.Info("Notarization completed successfully")
+
+		// Staple the ticket to the bundle, which is needed for exporting it and before the
+		// bundle is wrapped into a disk image (optional)
+		exportTicket := exportTicketFlag != nil && *exportTicketFlag != ""
+		stapleDmg := stapleDmgFlag != nil && *stapleDmgFlag
+		if exportTicket || stapleDmg {
+			packageFileError = application.StapleApplication(outputName + ".app")
+			if packageFileError != nil {
+				return packageFileError
+			}
+		}
+
+		// Keep a copy of the ticket for re-stapling a re-wrapped bundle (optional)
+		if exportTicket {
+			packageFileError = application.ExportTicket(outputName+".app", *exportTicketFlag)
+			if packageFileError != nil {
+				return packageFileError
+			}
+			artifacts = append(artifacts, *exportTicketFlag)
+		}
+
+		// Wrap the stapled bundle into a disk image that is notarized and stapled last (optional)
+		if stapleDmg {
+			imagePath, err := application.CreateStapledDiskImage(outputName+".app", appleIDProfile)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, imagePath)
+		}
+	}
+
+	// Remove the quarantine attribute from the finished bundle (optional, local testing only)
+	if dequarantineFlag != nil && *dequarantineFlag {
+		packageFileError = application.RemoveQuarantine(outputName + ".app")
+		if packageFileError != nil {
+			return packageFileError
+		}
+	}
+
+	// Build an installer package from the finished bundle (optional)
+	if pkgFlag != nil && *pkgFlag {
+		packagePath, err := application.BuildPackage(outputName + ".app")
+		if err != nil {
+			return err
+		}
+		logger.Info("Installer package created: %s", packagePath)
+		artifacts = append(artifacts, packagePath)
 	}
 
+	// Describe the finished bundle in the JSON result (-json)
+	recordBundle(outputName, signed, notarized, artifacts)
+
 	// Step 7: Clean up (optional, mainly for testing)
 	// If delete flag is set, remove the bundle after creation
 	if deleteFlag != nil && *deleteFlag {
 		packageFileError = application.DeleteAll()
 		if packageFileError != nil {
-			errorExit(packageFileError)
+			return packageFileError
 		}
 	}
 
-	logger.Info("Application Bundler completed successfully")
+	return nil
+}
+
+// signExistingBundle signs a prebuilt application bundle (-sign-only mode).
+//
+// Parameters:
+//   - appPath: Path to the existing .app bundle
+//
+// Returns an error if the path is not a bundle directory, a required tool is missing,
+// or signing or verification fails.
+func signExistingBundle(appPath string) error {
+	info, err := os.Stat(appPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || !strings.HasSuffix(strings.TrimSuffix(appPath, "/"), ".app") {
+		return fmt.Errorf("%s is not an application bundle (.app directory)", appPath)
+	}
+
+	if err := application.PreflightCheck(application.PreflightOptions{Sign: true}); err != nil {
+		return err
+	}
+
+	if stripJunkFlag != nil && *stripJunkFlag {
+		if _, err := application.StripJunk(appPath); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Signing existing bundle %s", appPath)
+	application.SetSignWorkers(*signWorkersFlag)
+	application.SetSparkleSigning(*sparkleFlag)
+	return application.SignApplication(appPath)
+}
+
+// printJavaModules prints the Java modules needed by a JAR as a comma-separated list (-jdeps).
+// jdeps is taken from the Java home of the configuration if it can be read, otherwise from
+// $JAVA_HOME or the PATH.
+//
+// Parameters:
+//   - mainJar: Path to the application JAR (value of -jdeps)
+//   - classPath: Remaining command-line arguments, the JARs of the class path
+func printJavaModules(mainJar string, classPath []string) error {
+	javaHome := ""
+	if err := application.Read(*packageFileFlag); err == nil {
+		javaHome = application.GetJavaHomeDirectory()
+	} else {
+		logger.Debug("Configuration not loaded, looking for jdeps in JAVA_HOME and PATH: %v", err)
+	}
+
+	modules, err := application.JavaModuleDependencies(mainJar, classPath, javaHome)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(strings.Join(modules, ","))
+	return nil
+}
+
+// printUpdateSignature prints the attributes of the appcast enclosure of a Sparkle update
+// (sparkle:edSignature and length), in the format of Sparkle's sign_update tool.
+//
+// Parameters:
+//   - archivePath: Path to the update archive (zip or dmg)
+//   - privateKeyPath: Path to the private key file exported with "generate_keys -x"
+//
+// Returns an error if no key is given, or the archive cannot be signed.
+func printUpdateSignature(archivePath string, privateKeyPath string) error {
+	if privateKeyPath == "" {
+		return fmt.Errorf("-sign-update requires -sparkle-key")
+	}
+
+	signature, err := application.SignUpdate(archivePath, privateKeyPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("sparkle:edSignature=\"%s\" length=\"%d\"\n", signature, info.Size())
+	return nil
+}
+
+// printRegisteredDocumentTypes prints the document types LaunchServices registered for a
+// bundle identifier, grouped by registered bundle (-list-document-types).
+//
+// Parameters:
+//   - bundleIdentifier: CFBundleIdentifier of the application
+//
+// Returns an error if lsregister fails or the bundle is not registered.
+func printRegisteredDocumentTypes(bundleIdentifier string) error {
+	registrations, err := application.RegisteredDocumentTypes(bundleIdentifier)
+	if err != nil {
+		return err
+	}
+	if len(registrations) == 0 {
+		return fmt.Errorf("no bundle with identifier %s is registered in LaunchServices (open the bundle once to register it)", bundleIdentifier)
+	}
+
+	for _, registration := range registrations {
+		fmt.Println(registration.Path)
+		if len(registration.DocumentTypes) == 0 {
+			fmt.Println("  (no document types)")
+		}
+		for _, documentType := range registration.DocumentTypes {
+			fmt.Printf("  %s (%s): %s\n", documentType.Name, documentType.Role, strings.Join(documentType.Bindings, ", "))
+		}
+	}
+
+	return nil
+}
+
+// compareBundles prints the differences between two bundles, one per line.
+// Like diff, it returns the exit code: 0 if the bundles are identical, 1 if they differ.
+//
+// Parameters:
+//   - oldApp: Path to the first bundle (value of -compare)
+//   - arguments: Remaining command-line arguments, which must be the second bundle
+func compareBundles(oldApp string, arguments []string) int {
+	if len(arguments) != 1 {
+		errorExit(fmt.Errorf("-compare expects two bundles: -compare <appA> <appB>"))
+	}
+
+	differences, err := application.CompareBundles(oldApp, arguments[0])
+	errorExit(err)
+
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+	if len(differences) > 0 {
+		return 1
+	}
+
+	fmt.Println("Bundles are identical")
+	return 0
+}
+
+// installSignalCleanup removes the bundle under construction when the program receives
+// SIGINT (Ctrl-C) or SIGTERM, and exits with a clear message. Bundles are built in a temporary
+// directory, so an interrupted build leaves an existing bundle untouched. The handler only
+// runs when a signal arrives; a normal completion never triggers it.
+func installSignalCleanup() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		exit(cleanupOnSignal(<-signals))
+	}()
+}
+
+// cleanupOnSignal removes the incomplete bundle after a signal, or keeps it with
+// -no-clean-on-error. It runs in the goroutine of the signal handler while the build
+// continues; the application package serializes the removal with the bundle changes.
+//
+// Returns the exit code for the signal: 128 + the signal number by convention.
+func cleanupOnSignal(received os.Signal) int {
+	logger.Warn("Received %v, discarding the incomplete bundle and exiting", received)
+	application.DiscardFailedBundle()
+
+	if received == syscall.SIGTERM {
+		return 143
+	}
+	return 130
 }
 
 // errorExit is a helper function that handles errors by logging them and exiting the program.
@@ -195,7 +918,20 @@ func main() {
 // and provides clear feedback to the user about what went wrong.
 func errorExit(err error) {
 	if err != nil {
+		// With -json the failure is part of the JSON result, the log message goes to stderr
+		if jsonOutput() {
+			writeReport(err)
+		}
+		// logger.Error panics and does not return, so the trace file is closed first
+		fileManagement.CloseTrace()
 		logger.Error(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
+
+// exit closes the trace file and ends the program with the given exit code. os.Exit does not
+// run deferred calls, so every exit path goes through this function.
+func exit(code int) {
+	fileManagement.CloseTrace()
+	os.Exit(code)
+}
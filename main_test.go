@@ -0,0 +1,322 @@
+package main
+
+import (
+	"appbundler/application"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestCleanupOnSignal delivers a synthetic signal to a handler goroutine while a bundle is
+// being built, as installSignalCleanup does for a real one.
+func TestCleanupOnSignal(t *testing.T) {
+	tests := []struct {
+		name       string
+		signal     os.Signal
+		keep       bool
+		wantCode   int
+		wantRemove bool
+	}{
+		{"interrupt", os.Interrupt, false, 130, true},
+		{"terminate", syscall.SIGTERM, false, 143, true},
+		{"interrupt with -no-clean-on-error", os.Interrupt, true, 130, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			application.SetKeepFailedBundle(test.keep)
+			t.Cleanup(func() { application.SetKeepFailedBundle(false) })
+
+			if err := application.CreateDirectoryStructure(filepath.Join(t.TempDir(), "MyApp")); err != nil {
+				t.Fatal(err)
+			}
+			bundleDirectory := application.GetApplicationDirectory()
+
+			signals := make(chan os.Signal, 1)
+			exitCodes := make(chan int)
+			go func() { exitCodes <- cleanupOnSignal(<-signals) }()
+
+			signals <- test.signal
+			if code := <-exitCodes; code != test.wantCode {
+				t.Errorf("exit code = %d, want %d", code, test.wantCode)
+			}
+
+			_, err := os.Stat(bundleDirectory)
+			if removed := os.IsNotExist(err); removed != test.wantRemove {
+				t.Errorf("incomplete bundle removed = %v, want %v", removed, test.wantRemove)
+			}
+		})
+	}
+}
+
+// testBatchConfig is a configuration building the bundle <name>.app from the executable MyApp
+// and the icon <icon>.icns next to it.
+const testBatchConfig = `id: com.example.myapp
+name: %s
+version: "1"
+executable: MyApp
+exec_file: MyApp
+exec_file_directory: .
+icon_file: %s.icns
+icon_file_directory: .
+`
+
+// runTestMain runs the program with the given arguments in the directory and returns the exit
+// code and the output.
+func runTestMain(t *testing.T, directory string, arguments ...string) (int, string) {
+	t.Helper()
+	command := exec.Command(os.Args[0], arguments...)
+	command.Dir = directory
+	command.Env = append(os.Environ(), testMainVariable+"=1")
+
+	output, err := command.CombinedOutput()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode(), string(output)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, string(output)
+}
+
+// TestKeepGoing builds a batch of two valid configurations and one whose icon is missing.
+func TestKeepGoing(t *testing.T) {
+	tests := []struct {
+		name        string
+		arguments   []string
+		wantBundles []string
+		wantOutput  string
+	}{
+		{
+			name:        "stop at the first failure",
+			arguments:   []string{"-application", "first.yaml", "broken.yaml", "second.yaml"},
+			wantBundles: []string{"First.app"},
+			wantOutput:  "icon file not found: Missing.icns",
+		},
+		{
+			name:        "keep going",
+			arguments:   []string{"-keep-going", "-application", "first.yaml", "broken.yaml", "second.yaml"},
+			wantBundles: []string{"First.app", "Second.app"},
+			wantOutput:  "1 of 3 bundles failed to build",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			files := map[string]string{
+				"MyApp":       "\xcf\xfa\xed\xfe binary content",
+				"First.icns":  "icon",
+				"Second.icns": "icon",
+				"first.yaml":  fmt.Sprintf(testBatchConfig, "First", "First"),
+				"broken.yaml": fmt.Sprintf(testBatchConfig, "Broken", "Missing"),
+				"second.yaml": fmt.Sprintf(testBatchConfig, "Second", "Second"),
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			code, output := runTestMain(t, directory, test.arguments...)
+			if code != 1 {
+				t.Errorf("exit code = %d, want 1", code)
+			}
+			if !strings.Contains(output, test.wantOutput) {
+				t.Errorf("output does not contain %q:\n%s", test.wantOutput, output)
+			}
+
+			bundles, err := filepath.Glob(filepath.Join(directory, "*.app"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index := range bundles {
+				bundles[index] = filepath.Base(bundles[index])
+			}
+			if !reflect.DeepEqual(bundles, test.wantBundles) {
+				t.Errorf("bundles = %q, want %q", bundles, test.wantBundles)
+			}
+		})
+	}
+}
+
+// TestOutputName builds a bundle with -output-name: only the .app directory is renamed, the
+// plist keeps the bundle name.
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		name        string
+		arguments   []string
+		wantCode    int
+		wantBundles []string
+		wantOutput  string
+	}{
+		{"default", []string{"-application", "first.yaml"}, 0, []string{"First.app"}, ""},
+		{"output name", []string{"-output-name", "First-beta", "-application", "first.yaml"}, 0, []string{"First-beta.app"}, ""},
+		{"path separator", []string{"-output-name", "beta/First", "-application", "first.yaml"}, 1, nil, "must not contain path separators"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			files := map[string]string{
+				"MyApp":      "\xcf\xfa\xed\xfe binary content",
+				"First.icns": "icon",
+				"first.yaml": fmt.Sprintf(testBatchConfig, "First", "First"),
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			code, output := runTestMain(t, directory, test.arguments...)
+			if code != test.wantCode {
+				t.Errorf("exit code = %d, want %d:\n%s", code, test.wantCode, output)
+			}
+			if !strings.Contains(output, test.wantOutput) {
+				t.Errorf("output does not contain %q:\n%s", test.wantOutput, output)
+			}
+
+			bundles, err := filepath.Glob(filepath.Join(directory, "*.app"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index := range bundles {
+				bundles[index] = filepath.Base(bundles[index])
+			}
+			if !reflect.DeepEqual(bundles, test.wantBundles) {
+				t.Fatalf("bundles = %q, want %q", bundles, test.wantBundles)
+			}
+
+			for _, bundle := range bundles {
+				plist, err := os.ReadFile(filepath.Join(directory, bundle, "Contents", "Info.plist"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(plist), "<key>CFBundleName</key>\n    <string>First</string>") {
+					t.Errorf("%s/Contents/Info.plist does not name the bundle First:\n%s", bundle, plist)
+				}
+			}
+		})
+	}
+}
+
+// TestSignOnly signs a prebuilt bundle with -sign-only and a fake codesign: the bundle is
+// signed and verified, nothing is built.
+func TestSignOnly(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantCalls  []string
+		wantOutput string
+	}{
+		{
+			name:      "bundle",
+			path:      "MyApp.app",
+			wantCalls: []string{"--sign - --deep --force --options runtime --timestamp MyApp.app", "--verify --deep --strict --verbose=2 MyApp.app"},
+		},
+		{"not a bundle", "MyApp", 1, nil, "MyApp is not an application bundle"},
+		{"missing bundle", "Missing.app", 1, nil, "no such file or directory"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			toolDirectory := t.TempDir()
+			files := map[string]string{
+				"MyApp.app/Contents/Info.plist":  "<plist></plist>",
+				"MyApp.app/Contents/MacOS/MyApp": "\xcf\xfa\xed\xfe binary content",
+				"MyApp/Contents/MacOS/MyApp":     "\xcf\xfa\xed\xfe binary content",
+			}
+			for name, content := range files {
+				path := filepath.Join(directory, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// codesign records the signing and verification calls (-dvv finds no signature), security
+			// is only required by the preflight check
+			tools := map[string]string{
+				"codesign": "#!/bin/sh\n[ \"$1\" = -dvv ] && exit 1\necho \"$*\" >> \"$(dirname \"$0\")/calls\"\n",
+				"security": "#!/bin/sh\n",
+			}
+			for name, content := range tools {
+				if err := os.WriteFile(filepath.Join(toolDirectory, name), []byte(content), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PATH", toolDirectory+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			code, output := runTestMain(t, directory, "-identity", "-", "-sign-only", test.path)
+			if code != test.wantCode {
+				t.Errorf("exit code = %d, want %d:\n%s", code, test.wantCode, output)
+			}
+			if !strings.Contains(output, test.wantOutput) {
+				t.Errorf("output does not contain %q:\n%s", test.wantOutput, output)
+			}
+
+			var calls []string
+			if content, err := os.ReadFile(filepath.Join(toolDirectory, "calls")); err == nil {
+				calls = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+			}
+			if !reflect.DeepEqual(calls, test.wantCalls) {
+				t.Errorf("codesign calls = %q, want %q", calls, test.wantCalls)
+			}
+
+			if entries, err := os.ReadDir(directory); err != nil || len(entries) != 2 {
+				t.Errorf("directory contains %v (%v), want only the fixtures", entries, err)
+			}
+		})
+	}
+}
+
+// TestTrace builds a bundle with -trace: the trace file records the operations of the build
+// with their absolute paths and results.
+func TestTrace(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"MyApp":      "\xcf\xfa\xed\xfe binary content",
+		"First.icns": "icon",
+		"first.yaml": fmt.Sprintf(testBatchConfig, "First", "First"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code, output := runTestMain(t, directory, "-trace", "trace.log", "-application", "first.yaml"); code != 0 {
+		t.Fatalf("exit code = %d, want 0:\n%s", code, output)
+	}
+	trace, err := os.ReadFile(filepath.Join(directory, "trace.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bundle is built in a staging directory and renamed when it is complete
+	const staging = `/\S*/First\.app\.tmp-\d+`
+	for _, entry := range []string{
+		`mkdir +` + staging + `/Contents/MacOS \[ok\]`,
+		`write +` + staging + `/Contents/Info\.plist \[ok\]`,
+		`copy +/\S*/MyApp -> ` + staging + `/Contents/MacOS/MyApp \[ok\]`,
+		`chmod +` + staging + `/Contents/MacOS/MyApp \[ok\]`,
+		`create +` + staging + `/Contents/Resources/First\.icns \[ok\]`,
+		`rename +` + staging + ` -> /\S*/First\.app \[ok\]`,
+	} {
+		if !regexp.MustCompile(`(?m)^\S+ ` + entry + `$`).Match(trace) {
+			t.Errorf("trace has no entry matching %s:\n%s", entry, trace)
+		}
+	}
+}
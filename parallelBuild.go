@@ -0,0 +1,124 @@
+// Package main: This file runs batch builds concurrently (-jobs). The bundling code keeps its
+// state in package-level variables, so bundles cannot be built in parallel within one
+// process. Instead, every configuration file is built by a separate appbundler process
+// with the same flags. Signing is serialized between these processes by the application
+// package, as codesign accesses the shared keychain.
+package main
+
+import (
+	"appbundler/utilities/logger"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// buildResult is the outcome of building one configuration file in a child process.
+type buildResult struct {
+	packageFile string // Configuration file of the bundle
+	output      []byte // Combined stdout/stderr of the child process
+	err         error  // nil if the bundle was built successfully
+}
+
+// childArguments returns the command-line arguments for a child process building a single
+// configuration file: all flags set on the command line except -application and -jobs,
+// followed by -application <packageFile>. A -trace file is shared by all children, which
+// append to it line by line.
+func childArguments(packageFile string) []string {
+	var arguments []string
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "application" || f.Name == "jobs" {
+			return
+		}
+		arguments = append(arguments, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
+	})
+
+	return append(arguments, "-application", packageFile)
+}
+
+// runParallelBuilds builds the given configuration files with at most jobs child processes
+// running at the same time. The output of each child is printed in one piece when it has
+// finished, so the logs of different bundles don't interleave.
+//
+// Without -keep-going no new builds are started after the first failure; running builds
+// are still finished.
+//
+// Parameters:
+//   - packageFiles: Configuration files to build
+//   - jobs: Maximum number of concurrent builds
+//
+// Returns the number of bundles that failed to build.
+func runParallelBuilds(packageFiles []string, jobs int) int {
+	executable, err := os.Executable()
+	if err != nil {
+		errorExit(err)
+	}
+
+	keepGoing := keepGoingFlag != nil && *keepGoingFlag
+
+	results := make(chan buildResult)
+	semaphore := make(chan struct{}, jobs)
+	var waitGroup sync.WaitGroup
+	var stopMutex sync.Mutex
+	stopped := false
+
+	go func() {
+		for _, packageFile := range packageFiles {
+			semaphore <- struct{}{}
+
+			stopMutex.Lock()
+			stop := stopped
+			stopMutex.Unlock()
+			if stop {
+				<-semaphore
+				break
+			}
+
+			waitGroup.Add(1)
+			go func(packageFile string) {
+				defer waitGroup.Done()
+				defer func() { <-semaphore }()
+
+				logger.Info("Building the bundle for %s", packageFile)
+
+				var output bytes.Buffer
+				command := exec.Command(executable, childArguments(packageFile)...)
+				command.Stdout = &output
+				command.Stderr = &output
+				err := command.Run()
+
+				if err != nil && !keepGoing {
+					stopMutex.Lock()
+					stopped = true
+					stopMutex.Unlock()
+				}
+
+				results <- buildResult{packageFile: packageFile, output: output.Bytes(), err: err}
+			}(packageFile)
+		}
+
+		waitGroup.Wait()
+		close(results)
+	}()
+
+	failedBuilds := 0
+	finishedBuilds := 0
+	for result := range results {
+		os.Stdout.Write(result.output)
+		finishedBuilds++
+
+		if result.err != nil {
+			logger.Warn("Building the bundle for %s failed: %v", result.packageFile, result.err)
+			failedBuilds++
+		}
+	}
+
+	if skipped := len(packageFiles) - finishedBuilds; skipped > 0 {
+		logger.Warn("%d bundles were not built after a failure (use -keep-going to build all)", skipped)
+	}
+
+	return failedBuilds
+}
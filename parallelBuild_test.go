@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testChildVariable makes the test binary act as a child build process. The child records
+// when it built the configuration in <configuration>.built, or fails for configurations
+// whose name starts with "fail".
+const testChildVariable = "APPBUNDLER_TEST_CHILD"
+
+// testMainVariable makes the test binary run main() with its arguments, to test complete runs
+// of the program including the exit code.
+const testMainVariable = "APPBUNDLER_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testChildVariable) != "" {
+		os.Exit(runTestChild(os.Args[1:]))
+	}
+	if os.Getenv(testMainVariable) != "" {
+		main()
+		exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestChild builds the configuration passed with -application in a test child process.
+func runTestChild(arguments []string) int {
+	packageFile := ""
+	for index, argument := range arguments {
+		if argument == "-application" && index+1 < len(arguments) {
+			packageFile = arguments[index+1]
+		}
+	}
+	if packageFile == "" || strings.HasPrefix(filepath.Base(packageFile), "fail") {
+		return 1
+	}
+
+	start := time.Now()
+	time.Sleep(50 * time.Millisecond)
+	interval := fmt.Sprintf("%d %d", start.UnixNano(), time.Now().UnixNano())
+	if err := os.WriteFile(packageFile+".built", []byte(interval), 0644); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// buildInterval is the time a child process spent building one configuration.
+type buildInterval struct {
+	start int64
+	end   int64
+}
+
+// readBuildIntervals returns the build times of the given configurations, failing the test
+// if a configuration was not built.
+func readBuildIntervals(t *testing.T, packageFiles []string) []buildInterval {
+	t.Helper()
+	var intervals []buildInterval
+	for _, packageFile := range packageFiles {
+		content, err := os.ReadFile(packageFile + ".built")
+		if err != nil {
+			t.Fatalf("%s was not built: %v", packageFile, err)
+		}
+		var interval buildInterval
+		if _, err := fmt.Sscanf(string(content), "%d %d", &interval.start, &interval.end); err != nil {
+			t.Fatal(err)
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// maximumConcurrency returns the highest number of overlapping build intervals.
+func maximumConcurrency(intervals []buildInterval) int {
+	type event struct {
+		time  int64
+		delta int
+	}
+	var events []event
+	for _, interval := range intervals {
+		events = append(events, event{interval.start, 1}, event{interval.end, -1})
+	}
+	// Ends sort before starts at the same time
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].delta < events[j].delta
+	})
+
+	running, maximum := 0, 0
+	for _, event := range events {
+		running += event.delta
+		maximum = max(maximum, running)
+	}
+	return maximum
+}
+
+// testPackageFiles returns the paths of the named configurations in a temporary directory.
+func testPackageFiles(t *testing.T, names ...string) []string {
+	t.Helper()
+	directory := t.TempDir()
+	var packageFiles []string
+	for _, name := range names {
+		packageFiles = append(packageFiles, filepath.Join(directory, name+".yaml"))
+	}
+	return packageFiles
+}
+
+func TestRunParallelBuilds(t *testing.T) {
+	t.Setenv(testChildVariable, "1")
+
+	for _, jobs := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("%d jobs", jobs), func(t *testing.T) {
+			packageFiles := testPackageFiles(t, "one", "two", "three", "four", "five", "six")
+
+			if failed := runParallelBuilds(packageFiles, jobs); failed != 0 {
+				t.Fatalf("%d builds failed", failed)
+			}
+
+			if concurrency := maximumConcurrency(readBuildIntervals(t, packageFiles)); concurrency > jobs {
+				t.Errorf("%d bundles built concurrently, limit is %d", concurrency, jobs)
+			}
+		})
+	}
+}
+
+func TestRunParallelBuildsKeepGoing(t *testing.T) {
+	t.Setenv(testChildVariable, "1")
+
+	previous := *keepGoingFlag
+	*keepGoingFlag = true
+	t.Cleanup(func() { *keepGoingFlag = previous })
+
+	packageFiles := testPackageFiles(t, "one", "fail", "two", "three")
+	if failed := runParallelBuilds(packageFiles, 2); failed != 1 {
+		t.Errorf("%d builds failed, want 1", failed)
+	}
+	readBuildIntervals(t, []string{packageFiles[0], packageFiles[2], packageFiles[3]})
+}
+
+func TestChildArguments(t *testing.T) {
+	previousJobs, previousKeepGoing, previousPackageFile := *jobsFlag, *keepGoingFlag, *packageFileFlag
+	t.Cleanup(func() {
+		*jobsFlag, *keepGoingFlag, *packageFileFlag = previousJobs, previousKeepGoing, previousPackageFile
+	})
+	for name, value := range map[string]string{"jobs": "4", "keep-going": "true", "application": "a.yaml,b.yaml"} {
+		if err := flag.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := strings.Join(childArguments("config/app.yaml"), " ")
+	if !strings.Contains(got, "-keep-going=true") || strings.Contains(got, "-jobs") || strings.Contains(got, "a.yaml") ||
+		!strings.HasSuffix(got, "-application config/app.yaml") {
+		t.Errorf("childArguments() = %q", got)
+	}
+}
@@ -4,26 +4,134 @@
 package fileManagement
 
 import (
+	"appbundler/utilities/logger"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 )
 
+// ownershipWarning makes sure the warning about ownership that cannot be preserved is
+// logged only once, not for every copied file
+var ownershipWarning sync.Once
+
+// isOwnershipUnsupported reports whether a chown error means that ownership cannot be set
+// on the destination: file systems like exFAT, FAT or network shares don't support it
+// (ENOTSUP), and only root may give files to other users (EPERM).
+func isOwnershipUnsupported(err error) bool {
+	return errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.EPERM)
+}
+
+// preserveOwnership sets the owner of a copied file to the owner of its source. If the
+// destination does not support it, the copy continues without ownership preservation
+// and a warning is logged once.
+//
+// Parameters:
+//   - path: Path of the copied file
+//   - uid, gid: Owner and group of the source file
+//
+// Returns an error only for failures other than unsupported ownership changes.
+func preserveOwnership(path string, uid int, gid int) error {
+	err := os.Lchown(path, uid, gid)
+	Trace("chown", err, path)
+	if err == nil || !isOwnershipUnsupported(err) {
+		return err
+	}
+
+	ownershipWarning.Do(func() {
+		logger.Warn("Cannot preserve file ownership on the destination (%v), continuing without it", err)
+	})
+	return nil
+}
+
 // CopyDirectory recursively copies a directory tree from source to destination.
 // This function:
 //   - Preserves file permissions and ownership
 //   - Handles directories, regular files, and symlinks
 //   - Maintains the directory structure
 //
+// Symbolic links are recreated, not followed. Absolute links pointing into the copied tree
+// (e.g. inside a JDK) are rewritten as relative links, so they still resolve after the tree
+// is relocated; links to locations outside the tree are kept unchanged.
+//
 // Parameters:
 //   - scrDir: Source directory to copy from
 //   - dest: Destination directory to copy to
 //
 // Returns an error if any file operation fails.
 func CopyDirectory(scrDir, dest string) error {
+	roots, err := sourceRoots(scrDir)
+	if err != nil {
+		return err
+	}
+
+	return copyTree(roots, scrDir, dest)
+}
+
+// sourceRoots returns the absolute forms of a copy source root: the cleaned path and, if it
+// differs, the path with symbolic links resolved (e.g. /var and /private/var on macOS), as
+// absolute link targets can use either form.
+func sourceRoots(scrDir string) ([]string, error) {
+	root, err := filepath.Abs(scrDir)
+	if err != nil {
+		return nil, err
+	}
+	roots := []string{root}
+
+	if resolved, err := filepath.EvalSymlinks(root); err == nil && resolved != root {
+		roots = append(roots, resolved)
+	}
+
+	return roots, nil
+}
+
+// relocatedLinkTarget returns the target for a copy of a symbolic link. An absolute target
+// inside one of the source roots is converted to a path relative to the link's directory;
+// all other targets are returned unchanged.
+//
+// Parameters:
+//   - roots: Absolute forms of the copy source root (see sourceRoots)
+//   - linkPath: Path of the source link, inside the source root
+//   - target: Target of the source link (as returned by os.Readlink)
+func relocatedLinkTarget(roots []string, linkPath string, target string) string {
+	if !filepath.IsAbs(target) {
+		return target
+	}
+	target = filepath.Clean(target)
+
+	linkDirectory, err := filepath.Abs(filepath.Dir(linkPath))
+	if err != nil {
+		return target
+	}
+
+	for index, root := range roots {
+		relativeToRoot, err := filepath.Rel(root, target)
+		if err != nil || relativeToRoot == ".." || strings.HasPrefix(relativeToRoot, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		// The link directory is expressed in terms of the first root; map it to the matching one
+		directoryInRoot, err := filepath.Rel(roots[0], linkDirectory)
+		if err != nil {
+			return target
+		}
+		relativeTarget, err := filepath.Rel(filepath.Join(roots[index], directoryInRoot), target)
+		if err != nil {
+			return target
+		}
+		return relativeTarget
+	}
+
+	return target
+}
+
+// copyTree copies the directory scrDir (below the copy source root) to dest, see CopyDirectory.
+func copyTree(roots []string, scrDir, dest string) error {
 	// Read all entries in the source directory
 	entries, err := os.ReadDir(scrDir)
 	if err != nil {
@@ -35,8 +143,8 @@ func CopyDirectory(scrDir, dest string) error {
 		sourcePath := filepath.Join(scrDir, entry.Name())
 		destPath := filepath.Join(dest, entry.Name())
 
-		// Get file information to determine type and permissions
-		fileInfo, err := os.Stat(sourcePath)
+		// Get file information to determine type and permissions (without following symlinks)
+		fileInfo, err := os.Lstat(sourcePath)
 		if err != nil {
 			return err
 		}
@@ -54,12 +162,23 @@ func CopyDirectory(scrDir, dest string) error {
 			if err := CreateIfNotExists(destPath, 0755); err != nil {
 				return err
 			}
-			if err := CopyDirectory(sourcePath, destPath); err != nil {
+			if err := copyTree(roots, sourcePath, destPath); err != nil {
 				return err
 			}
 		case os.ModeSymlink:
-			// Copy symlinks by recreating them (not following the link)
-			if err := CopySymLink(sourcePath, destPath); err != nil {
+			// Copy symlinks by recreating them (not following the link), relocating
+			// absolute targets inside the copied tree
+			link, err := os.Readlink(sourcePath)
+			if err != nil {
+				return err
+			}
+			target := relocatedLinkTarget(roots, sourcePath, link)
+			if target != link {
+				logger.Debug("Rewriting symbolic link %s: %s -> %s", destPath, link, target)
+			}
+			err = os.Symlink(target, destPath)
+			Trace("symlink", err, destPath, target)
+			if err != nil {
 				return err
 			}
 		default:
@@ -71,7 +190,8 @@ func CopyDirectory(scrDir, dest string) error {
 
 		// Preserve file ownership (UID/GID)
 		// Note: This may fail if running without appropriate permissions
-		if err := os.Lchown(destPath, int(stat.Uid), int(stat.Gid)); err != nil {
+		err = preserveOwnership(destPath, int(stat.Uid), int(stat.Gid))
+		if err != nil {
 			return err
 		}
 
@@ -84,7 +204,9 @@ func CopyDirectory(scrDir, dest string) error {
 		// Preserve file permissions (but not for symlinks - they have their own permissions)
 		isSymlink := fInfo.Mode()&os.ModeSymlink != 0
 		if !isSymlink {
-			if err := os.Chmod(destPath, fInfo.Mode()); err != nil {
+			err = os.Chmod(destPath, fInfo.Mode())
+			Trace("chmod", err, destPath)
+			if err != nil {
 				return err
 			}
 		}
@@ -101,7 +223,9 @@ func CopyDirectory(scrDir, dest string) error {
 //   - dstFile: Path to the destination file
 //
 // Returns an error if the copy operation fails.
-func Copy(srcFile, dstFile string) error {
+func Copy(srcFile, dstFile string) (err error) {
+	defer func() { Trace("copy", err, srcFile, dstFile) }()
+
 	// Create the destination file
 	out, err := os.Create(dstFile)
 	if err != nil {
@@ -156,7 +280,9 @@ func CreateIfNotExists(dir string, perm os.FileMode) error {
 
 	// Create directory and all parent directories
 	// os.MkdirAll is idempotent - it won't fail if parts of the path already exist
-	if err := os.MkdirAll(dir, perm); err != nil {
+	err := os.MkdirAll(dir, perm)
+	Trace("mkdir", err, dir)
+	if err != nil {
 		return fmt.Errorf("failed to create directory: '%s', error: '%s'", dir, err.Error())
 	}
 
@@ -178,7 +304,30 @@ func CopySymLink(source, dest string) error {
 		return err
 	}
 	// Create a new symlink with the same target
-	return os.Symlink(link, dest)
+	err = os.Symlink(link, dest)
+	Trace("symlink", err, dest, link)
+	return err
+}
+
+// xcodeToolsHint tells how to install the Xcode Command Line Tools
+const xcodeToolsHint = "install the Xcode Command Line Tools with: xcode-select --install"
+
+// installHints maps tools that are not part of every macOS installation to instructions
+// for installing them.
+var installHints = map[string]string{
+	"codesign": xcodeToolsHint,
+	"xcrun":    xcodeToolsHint,
+	"lipo":     xcodeToolsHint,
+	"strip":    xcodeToolsHint,
+	"go":       "install Go from https://go.dev/dl/ (or with Homebrew: brew install go)",
+}
+
+// InstallHint returns instructions for installing a missing program, or "" if none are known.
+//
+// Parameters:
+//   - program: Name of the program (e.g., "codesign")
+func InstallHint(program string) string {
+	return installHints[program]
 }
 
 // FindProgramPath locates an executable program in the system PATH.
@@ -189,11 +338,15 @@ func CopySymLink(source, dest string) error {
 //
 // Returns:
 //   - Full path to the executable
-//   - An error if the program is not found in PATH
+//   - An error if the program is not found in PATH, including install instructions
+//     for known tools (see InstallHint)
 func FindProgramPath(program string) (string, error) {
 	// exec.LookPath searches for the executable in directories listed in PATH
 	path, err := exec.LookPath(program)
 	if err != nil {
+		if hint := InstallHint(program); hint != "" {
+			return "", fmt.Errorf("program %q not found in PATH: %s", program, hint)
+		}
 		return "", fmt.Errorf("program %q not found in PATH", program)
 	}
 	return path, nil
@@ -0,0 +1,131 @@
+package fileManagement
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestIsOwnershipUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not supported", &os.PathError{Op: "lchown", Path: "/Volumes/USB/MyApp", Err: syscall.ENOTSUP}, true},
+		{"operation not supported", &os.PathError{Op: "lchown", Path: "/Volumes/Share/MyApp", Err: syscall.EOPNOTSUPP}, true},
+		{"not permitted", &os.PathError{Op: "lchown", Path: "/tmp/MyApp", Err: syscall.EPERM}, true},
+		{"missing file", &os.PathError{Op: "lchown", Path: "/tmp/MyApp", Err: syscall.ENOENT}, false},
+		{"other error", errors.New("disk full"), false},
+		{"no error", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isOwnershipUnsupported(test.err); got != test.want {
+				t.Errorf("isOwnershipUnsupported(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRelocatedLinkTarget(t *testing.T) {
+	roots := []string{"/tmp/Runtime", "/private/tmp/Runtime"}
+	linkPath := "/tmp/Runtime/Contents/Home/lib/libjli.dylib"
+
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"relative target", "../../MacOS/libjli.dylib", "../../MacOS/libjli.dylib"},
+		{"absolute target inside the tree", "/tmp/Runtime/Contents/MacOS/libjli.dylib", "../../MacOS/libjli.dylib"},
+		{"target in the resolved root", "/private/tmp/Runtime/Contents/MacOS/libjli.dylib", "../../MacOS/libjli.dylib"},
+		{"unclean target", "/tmp/Runtime/Contents/Home/../MacOS//libjli.dylib", "../../MacOS/libjli.dylib"},
+		{"target is the root", "/tmp/Runtime", "../../.."},
+		{"sibling with the same prefix", "/tmp/Runtime2/libjli.dylib", "/tmp/Runtime2/libjli.dylib"},
+		{"system library", "/usr/lib/libz.dylib", "/usr/lib/libz.dylib"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := relocatedLinkTarget(roots, linkPath, test.target); got != test.want {
+				t.Errorf("relocatedLinkTarget(%q) = %q, want %q", test.target, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCopyDirectoryRelocatesAbsoluteLinks(t *testing.T) {
+	directory := t.TempDir()
+	source := filepath.Join(directory, "Runtime")
+	destination := filepath.Join(directory, "MyApp.app", "Runtime")
+
+	if err := os.MkdirAll(filepath.Join(source, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "lib", "libjli.dylib"), []byte("library"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(source, "lib", "libjli.dylib"), filepath.Join(source, "libjli.dylib")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/usr/lib/libz.dylib", filepath.Join(source, "libz.dylib")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyDirectory(source, destination); err != nil {
+		t.Fatal(err)
+	}
+
+	links := map[string]string{
+		"libjli.dylib": filepath.Join("lib", "libjli.dylib"),
+		"libz.dylib":   "/usr/lib/libz.dylib",
+	}
+	for name, want := range links {
+		got, err := os.Readlink(filepath.Join(destination, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s points to %q, want %q", name, got, want)
+		}
+	}
+
+	// The relocated link resolves inside the copy, even after the source is gone
+	if err := os.RemoveAll(source); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(destination, "libjli.dylib")); err != nil || string(content) != "library" {
+		t.Errorf("relocated link does not resolve to the copied library: %q, %v", content, err)
+	}
+}
+
+// TestFindProgramPathHint looks up tools with an empty PATH: Apple developer tools are
+// reported with the advice to install the Xcode Command Line Tools.
+func TestFindProgramPathHint(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{"codesign", `program "codesign" not found in PATH: install the Xcode Command Line Tools with: xcode-select --install`},
+		{"xcrun", `program "xcrun" not found in PATH: install the Xcode Command Line Tools with: xcode-select --install`},
+		{"zip", `program "zip" not found in PATH`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.program, func(t *testing.T) {
+			_, err := FindProgramPath(test.program)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package fileManagement: This file records file system operations in a trace file.
+// When tracing is enabled, every create, copy, chmod, mkdir, symlink and remove performed
+// during a build is written with its full paths and result, which helps to answer
+// "why is this file here?" questions and serves as an audit log of a build.
+package fileManagement
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace state (set via SetTraceFile)
+var (
+	traceFile  *os.File   // Open trace file, nil if tracing is disabled
+	traceMutex sync.Mutex // Serializes writes from concurrent operations
+)
+
+// SetTraceFile enables tracing of file system operations into the given file.
+// An existing file is appended to, so several builds can share one trace. Every line is
+// written with a single append, so the lines of concurrent builds (-jobs) don't tear but
+// interleave; the absolute paths of each line identify the bundle it belongs to.
+//
+// Parameters:
+//   - path: Path of the trace file
+//
+// Returns an error if the trace file cannot be opened.
+func SetTraceFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %v", err)
+	}
+
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+
+	if traceFile != nil {
+		traceFile.Close()
+	}
+	traceFile = file
+	return nil
+}
+
+// CloseTrace disables tracing and closes the trace file.
+func CloseTrace() error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+
+	if traceFile == nil {
+		return nil
+	}
+
+	err := traceFile.Close()
+	traceFile = nil
+	return err
+}
+
+// Trace records a file system operation in the trace file (if tracing is enabled).
+// Paths are written as absolute paths.
+//
+// Parameters:
+//   - operation: Name of the operation (e.g. "mkdir", "copy", "remove")
+//   - err: Result of the operation (nil for success)
+//   - paths: Paths involved in the operation (e.g. source and destination)
+func Trace(operation string, err error, paths ...string) {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+
+	if traceFile == nil {
+		return
+	}
+
+	absolutePaths := make([]string, len(paths))
+	for index, path := range paths {
+		absolutePaths[index] = path
+		if absolutePath, absErr := filepath.Abs(path); absErr == nil {
+			absolutePaths[index] = absolutePath
+		}
+	}
+
+	result := "ok"
+	if err != nil {
+		result = "error: " + err.Error()
+	}
+
+	// One write per line: with O_APPEND it is added at the end of the file as a whole, even
+	// if other processes append to the same trace file
+	line := fmt.Sprintf("%s %-8s %s [%s]\n", time.Now().Format(time.RFC3339),
+		operation, strings.Join(absolutePaths, " -> "), result)
+	traceFile.WriteString(line)
+}
@@ -5,19 +5,34 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
 // Package-level variables for logger configuration
 var (
-	logFile     string                    // Path to log file (if logging to file)
-	logDest     = log.New(os.Stdout, "", log.Ldate|log.Ltime) // Default: log to stdout
-	logFileDest *log.Logger                // Logger for file output (nil if not set)
-	logLevel    string                    // Current log level (not currently used)
-	silence     bool = false              // If true, suppress non-error messages
+	logFile     string                                                // Path to log file (if logging to file)
+	logDest     = log.New(os.Stdout, "", log.Ldate|log.Ltime)         // Default: log to stdout
+	logFileDest *log.Logger                                           // Logger for file output (nil if not set)
+	logLevel    string                                                // Current log level (not currently used)
+	silence     bool                                          = false // If true, suppress non-error messages
+)
+
+// utcTimeFormat is the timestamp format used with SetUTCTimestamps: RFC 3339 with milliseconds
+const utcTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// utcTimestamps enables RFC 3339 UTC timestamps with milliseconds (set via SetUTCTimestamps)
+var utcTimestamps bool
+
+// Warnings logged so far (see Warnings), guarded by warningsMutex as warnings can be
+// logged from concurrent goroutines
+var (
+	warnings      []string
+	warningsMutex sync.Mutex
 )
 
 // SetSilent enables or disables silent mode.
@@ -30,6 +45,50 @@ func SetSilent(isSilent bool) {
 	silence = isSilent
 }
 
+// SetUTCTimestamps switches the timestamps of all log messages (stdout, file and errors)
+// between the default local time with second precision ("2025/01/15 14:30:45") and
+// RFC 3339 in UTC with milliseconds ("2025-01-15T13:30:45.123Z"), which is unambiguous
+// across time zones and fine enough to correlate fast operations.
+//
+// Parameters:
+//   - enabled: true for UTC timestamps with milliseconds
+func SetUTCTimestamps(enabled bool) {
+	utcTimestamps = enabled
+
+	logDest.SetFlags(logFlags())
+	if logFileDest != nil {
+		logFileDest.SetFlags(logFlags())
+	}
+	log.SetFlags(logFlags())
+}
+
+// logFlags returns the flags of the standard log package: its date and time for the default
+// timestamps, none if the timestamp is written by logPrint.
+func logFlags() int {
+	if utcTimestamps {
+		return 0
+	}
+	return log.Ldate | log.Ltime
+}
+
+// SetOutput redirects the log messages from stdout to the given writer.
+// This keeps stdout free for machine-readable output (e.g. -json writes logs to stderr).
+//
+// Parameters:
+//   - writer: Destination of the log messages
+func SetOutput(writer io.Writer) {
+	logDest = log.New(writer, "", logFlags())
+}
+
+// Warnings returns the messages of all warnings logged so far, in the order they were logged.
+// Warnings are recorded even in silent mode.
+func Warnings() []string {
+	warningsMutex.Lock()
+	defer warningsMutex.Unlock()
+
+	return append([]string(nil), warnings...)
+}
+
 // logFormat formats a log message with optional values and then prints it.
 // This is an internal helper function used by the public logging functions.
 //
@@ -61,6 +120,9 @@ func logFormat(logType string, format string, values ...any) {
 func logPrint(logType string, message string) {
 	// Format the log message with type prefix
 	logMessage := "[" + logType + "] " + message
+	if utcTimestamps {
+		logMessage = time.Now().UTC().Format(utcTimeFormat) + " " + logMessage
+	}
 
 	// Error messages always print and exit the program
 	if logType == "Error" {
@@ -72,6 +134,13 @@ func logPrint(logType string, message string) {
 		return
 	}
 
+	// Remember warnings for summaries at the end of the run
+	if logType == "Warn" {
+		warningsMutex.Lock()
+		warnings = append(warnings, message)
+		warningsMutex.Unlock()
+	}
+
 	// In silent mode, suppress non-error messages to stdout
 	// But still write to file if configured
 	if !silence {
@@ -91,7 +160,7 @@ func logPrint(logType string, message string) {
 // These messages are typically only useful during development and debugging.
 func Debug(format string, values ...any) {
 	if values != nil {
-		logFormat("Debug", format, values)
+		logFormat("Debug", format, values...)
 	} else {
 		logPrint("Debug", format)
 	}
@@ -101,7 +170,7 @@ func Debug(format string, values ...any) {
 // These messages inform users about what the program is doing.
 func Info(format string, values ...any) {
 	if values != nil {
-		logFormat("Info", format, values)
+		logFormat("Info", format, values...)
 	} else {
 		logPrint("Info", format)
 	}
@@ -111,7 +180,7 @@ func Info(format string, values ...any) {
 // The program continues execution after a warning.
 func Warn(format string, values ...any) {
 	if values != nil {
-		logFormat("Warn", format, values)
+		logFormat("Warn", format, values...)
 	} else {
 		logPrint("Warn", format)
 	}
@@ -132,7 +201,7 @@ func Error(err error) {
 // This is for critical errors that should stop program execution.
 func Fatal(format string, values ...any) {
 	if values != nil {
-		logFormat("Fatal", format, values)
+		logFormat("Fatal", format, values...)
 	} else {
 		logPrint("Fatal", format)
 	}
@@ -179,7 +248,7 @@ func SetLogFile(appName string, logDir string) error {
 
 	// Store the file path and create a logger for file output
 	logFile = filePath
-	logFileDest = log.New(file, "", log.Ldate|log.Ltime)
+	logFileDest = log.New(file, "", logFlags())
 
 	return nil
 }
@@ -212,7 +281,7 @@ func SetLogFileWithPath(filePath string) error {
 
 	// Store the file path and create a logger for file output
 	logFile = filePath
-	logFileDest = log.New(file, "", log.Ldate|log.Ltime)
+	logFileDest = log.New(file, "", logFlags())
 
 	return nil
 }
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUTCTimestamps logs a message to a buffer and a log file with both timestamp formats:
+// with UTC timestamps, each line starts with an RFC 3339 UTC timestamp with milliseconds.
+func TestUTCTimestamps(t *testing.T) {
+	t.Cleanup(func() {
+		SetOutput(os.Stdout)
+		SetUTCTimestamps(false)
+		logFile, logFileDest = "", nil
+	})
+
+	tests := []struct {
+		name    string
+		utc     bool
+		layout  string
+		pattern string
+	}{
+		{"default", false, "2006/01/02 15:04:05", `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[Info\] started$`},
+		{"UTC", true, time.RFC3339, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z \[Info\] started$`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			SetOutput(&output)
+			filePath := filepath.Join(t.TempDir(), "MyApp.log")
+			if err := SetLogFileWithPath(filePath); err != nil {
+				t.Fatal(err)
+			}
+			SetUTCTimestamps(test.utc)
+
+			Info("started")
+
+			fileContent, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for destination, line := range map[string]string{"stdout": output.String(), "file": string(fileContent)} {
+				line = strings.TrimSuffix(line, "\n")
+				if !regexp.MustCompile(test.pattern).MatchString(line) {
+					t.Errorf("%s line %q does not match %s", destination, line, test.pattern)
+					continue
+				}
+
+				timestamp := strings.TrimSuffix(line, " [Info] started")
+				parsed, err := time.Parse(test.layout, timestamp)
+				if err != nil {
+					t.Errorf("%s timestamp %q: %v", destination, timestamp, err)
+				} else if test.utc && parsed.Location() != time.UTC {
+					t.Errorf("%s timestamp %q is not in UTC", destination, timestamp)
+				}
+			}
+		})
+	}
+}